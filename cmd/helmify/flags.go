@@ -9,6 +9,9 @@ import (
 	"strings"
 
 	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/plugin"
+	"github.com/arttor/helmify/pkg/processor/hook"
+	"github.com/arttor/helmify/pkg/script"
 )
 
 const helpText = `Helmify parses kubernetes resources from std.in and converts it to a Helm chart.
@@ -31,16 +34,39 @@ Example 5: 'helmify -f ./test_data/dir -f ./test_data/sample-app.yaml -f ./test_
 Example 6: 'awk 'FNR==1 && NR!=1  {print "---"}{print}' /my_directory/*.yaml | helmify mychart' 
   - will create 'mychart' directory with Helm chart from all yaml files in my_directory directory.
 
+Example 7: 'helmify -k ./config/default mychart' 
+  - will build kustomization from ./config/default directory and create 'mychart' directory with Helm chart.
+    Template files follow the layout of kustomization source files.
+
+Example 8: 'helmify -env dev=./overlays/dev -env prod=./overlays/prod mychart' 
+  - will create 'mychart' directory with Helm chart from both overlays. Templates and values.yaml are generated from
+    the first environment, values differing in other environments are placed into values-<env>.yaml files.
+
+Example 9: 'helmify from-cluster -namespace my-ns -selector app=web mychart'
+  - will create 'mychart' directory with Helm chart from objects of namespace my-ns with label app=web
+    of the current kubeconfig context cluster.
+
 Usage:
   helmify [flags] CHART_NAME  -  CHART_NAME is optional. Default is 'chart'. Can be a directory, e.g. 'deploy/charts/mychart'.
+  helmify [flags] from-cluster [flags] CHART_NAME  -  imports objects from a live cluster instead of manifests.
 
 Flags:
 `
 
+// fromClusterCmd - command importing objects from a live cluster.
+const fromClusterCmd = "from-cluster"
+
+// clusterFlags - flags used only by from-cluster command.
+var clusterFlags = []string{"kubeconfig", "context", "namespace", "selector", "cluster-scoped"}
+
 type arrayFlags []string
 
 var osExit = os.Exit
 var errMutuallyExclusiveCRDs = errors.New("-crd and -optional-crds cannot be used together")
+var errMutuallyExclusiveSources = errors.New("only one of -f, -k and -env sources can be used")
+var errInvalidEnv = errors.New("-env must be in <name>=<path> format")
+var errUnknownOption = errors.New("unknown config file option")
+var errClusterFlag = errors.New("flag can be used only with " + fromClusterCmd)
 
 func (i *arrayFlags) String() string {
 	if i == nil || len(*i) == 0 {
@@ -57,8 +83,16 @@ func (i *arrayFlags) Set(value string) error {
 // ReadFlags command-line flags into app config.
 func ReadFlags() (config.Config, error) {
 	files := arrayFlags{}
+	envs := arrayFlags{}
+	plugins := arrayFlags{}
+	workloads := arrayFlags{}
+	skipNormalize := arrayFlags{}
+	include := arrayFlags{}
+	exclude := arrayFlags{}
+	cluster := config.Cluster{}
 	result := config.Config{}
 	var h, help, version bool
+	var configFile string
 	flag.BoolVar(&h, "h", false, "Print help. Example: helmify -h")
 	flag.BoolVar(&help, "help", false, "Print help. Example: helmify -help")
 	flag.BoolVar(&version, "version", false, "Print helmify version. Example: helmify -version")
@@ -66,16 +100,47 @@ func ReadFlags() (config.Config, error) {
 	flag.BoolVar(&result.VeryVerbose, "vv", false, "Enable very verbose output. Same as verbose but with DEBUG. Example: helmify -vv")
 	flag.BoolVar(&result.Crd, "crd-dir", false, "Enable crd install into 'crds' directory. (cannot be used with 'optional-crds').\nWarning: CRDs placed in 'crds' directory will not be templated by Helm.\nSee https://helm.sh/docs/chart_best_practices/custom_resource_definitions/#some-caveats-and-explanations\nExample: helmify -crd-dir")
 	flag.BoolVar(&result.ImagePullSecrets, "image-pull-secrets", false, "Allows the user to use existing secrets as imagePullSecrets in values.yaml.")
-	flag.BoolVar(&result.GenerateDefaults, "generate-defaults", false, "Allows the user to add empty placeholders for typical customization options in values.yaml. Currently covers: topology constraints, node selectors, tolerances")
+	flag.BoolVar(&result.GenerateDefaults, "generate-defaults", false, "Allows the user to add empty placeholders for typical customization options in values.yaml. Covers: affinity, tolerations, node selectors, topology constraints, pod annotations and labels, priority class, extra containers, env, volumes and volume mounts. Example: helmify -generate-defaults")
+	flag.BoolVar(&result.GenerateAutoscaling, "generate-autoscaling", false, "Allows the user to enable HorizontalPodAutoscaler for Deployments and StatefulSets without one. Generated autoscaler is disabled by default. Example: helmify -generate-autoscaling")
 	flag.BoolVar(&result.CertManagerAsSubchart, "cert-manager-as-subchart", false, "Allows the user to add cert-manager as a subchart")
 	flag.StringVar(&result.CertManagerVersion, "cert-manager-version", "v1.12.2", "Allows the user to specify cert-manager subchart version. Only useful with cert-manager-as-subchart.")
 	flag.BoolVar(&result.CertManagerInstallCRD, "cert-manager-install-crd", true, "Allows the user to install cert-manager CRD. Only useful with cert-manager-as-subchart.")
 	flag.BoolVar(&result.FilesRecursively, "r", false, "Scan dirs from -f option recursively")
 	flag.BoolVar(&result.OriginalName, "original-name", false, "Use the object's original name instead of adding the chart's release name as the common prefix.")
 	flag.Var(&files, "f", "File or directory containing k8s manifests.")
+	flag.Var(&envs, "env", "Environment name and its kustomization directory or manifests path. Multiple environments are merged into one chart with values-<env>.yaml files. Example: helmify -env dev=./overlays/dev -env prod=./overlays/prod")
+	flag.StringVar(&result.Kustomization, "k", "", "Kustomization directory to build k8s manifests from. Template files follow source files layout. Example: helmify -k ./config/default")
 	flag.BoolVar(&result.PreserveNs, "preserve-ns", false, "Use the object's original namespace instead of adding all the resources to a common namespace.")
 	flag.BoolVar(&result.AddWebhookOption, "add-webhook-option", false, "Allows the user to add webhook option in values.yaml.")
 	flag.BoolVar(&result.OptionalCRDs, "optional-crds", false, "Enable optional CRD installation through values. (cannot be used with 'crd-dir')")
+	flag.BoolVar(&result.GenerateSchema, "generate-schema", false, "Generate values.schema.json to validate chart values. Example: helmify -generate-schema")
+	flag.BoolVar(&result.ValuesDocs, "values-docs", false, "Comment values.yaml keys with their origin and Kubernetes field descriptions in helm-docs format. Example: helmify -values-docs")
+	flag.BoolVar(&result.Verify, "verify", false, "Lint generated chart, render it with default values and report input object fields lost or changed by the conversion. Example: helmify -verify")
+	flag.StringVar(&result.Report, "report", "", "Write verification report to the given file, '-' for stdout. Enables -verify. Exits with code 2 if chart has lint errors or does not reproduce input objects. Example: helmify -report=report.json -report-format=json")
+	flag.StringVar(&result.ReportFormat, "report-format", "text", "Verification report format: text or json. Example: helmify -report=- -report-format=json")
+	flag.StringVar(&result.ConversionReport, "conversion-report", "", "Write conversion report with processor, template, values and warnings of each input object and per-kind coverage to the given file, '-' for stdout. Example: helmify -conversion-report=conversion.json")
+	flag.StringVar(&result.ConversionReportFormat, "conversion-report-format", "json", "Conversion report format: json or yaml. Example: helmify -conversion-report=- -conversion-report-format=yaml")
+	flag.BoolVar(&result.DryRun, "dry-run", false, "Print generated chart files to stdout instead of writing them into chart directory. Example: helmify -dry-run")
+	flag.BoolVar(&result.Diff, "diff", false, "Print unified diff of chart directory and generated chart instead of writing it. Enables -dry-run. Exits with code 3 if there are changes. Example: helmify -diff")
+	flag.BoolVar(&result.Merge, "merge", false, "Preserve manual changes of templates and values.yaml with three-way merge. Previously generated files are stored in '.helmify' chart dir. Example: helmify -merge")
+	flag.Var(&plugins, "plugin", "External processor plugin executable. Plugins exchange JSON over stdin/stdout and process objects of claimed kinds ahead of built-in processors. Example: helmify -plugin ./bin/widget-plugin")
+	flag.Var(&workloads, "workload", "Custom workload kind with API group and pod template path: <Kind>[.<group>][=<path>]. Objects of any kind with pod template at spec.template are processed as workloads without it. Example: helmify -workload CloneSet.apps.kruise.io=spec.workload.template")
+	flag.BoolVar(&result.Strict, "strict", false, "Fail if input documents can't be read, decoded or don't match API schema of built-in kinds. Invalid documents are reported and skipped otherwise. Example: helmify -strict")
+	flag.BoolVar(&result.AllowUnknownFields, "allow-unknown-fields", false, "Report fields unknown to the Kubernetes API version of helmify without failing -strict, e.g. fields added in newer Kubernetes versions. Example: helmify -strict -allow-unknown-fields")
+	flag.Var(&include, "include", "Add only input objects matching the filter: semicolon separated kind (glob of <Kind>[.<version>][.<group>]), name (regexp), selector and namespace (glob). Can be repeated. Example: helmify -include 'kind=*.apps;selector=app=web'")
+	flag.Var(&exclude, "exclude", "Leave out input objects matching the filter. Same format as -include. Can be repeated. Example: helmify -exclude 'kind=Secret;name=^dev-'")
+	flag.Var(&skipNormalize, "skip-normalize", "Comma-separated input normalization filters to disable: server-fields, status, last-applied. Example: helmify -skip-normalize=status,last-applied")
+	flag.BoolVar(&result.RemoveDefaults, "remove-defaults", false, "Remove input fields equal to values defaulted by k8s API server, e.g. Deployment revisionHistoryLimit or container terminationMessagePath. Example: helmify -remove-defaults")
+	flag.StringVar(&result.PluginDir, "plugin-dir", "", "Directory with external processor plugin executables. Example: helmify -plugin-dir ./plugins")
+	flag.DurationVar(&result.PluginTimeout, "plugin-timeout", plugin.DefaultTimeout, "Time limit of a single plugin call. Example: helmify -plugin ./bin/widget-plugin -plugin-timeout=1m")
+	flag.StringVar(&result.Script, "script", "", "Starlark script with object transforms and processors. Default is '"+script.FileName+"' next to the chart directory if exists. Example: helmify -script ./helmify.star")
+	flag.StringVar(&cluster.Kubeconfig, "kubeconfig", "", "Path to kubeconfig file for "+fromClusterCmd+". Default kubeconfig loading rules are used if not set. Example: helmify from-cluster -kubeconfig ~/.kube/prod")
+	flag.StringVar(&cluster.Context, "context", "", "Kubeconfig context for "+fromClusterCmd+". Default is the current context. Example: helmify from-cluster -context prod")
+	flag.StringVar(&cluster.Namespace, "namespace", "", "Namespace to import objects from with "+fromClusterCmd+". Default is the namespace of kubeconfig context. Example: helmify from-cluster -namespace my-ns")
+	flag.StringVar(&cluster.Selector, "selector", "", "Label selector of objects imported with "+fromClusterCmd+". Example: helmify from-cluster -selector app=web")
+	flag.BoolVar(&cluster.ClusterScoped, "cluster-scoped", false, "Also import ClusterRoles and ClusterRoleBindings of namespace service accounts and CRDs of imported custom resources with "+fromClusterCmd+". Example: helmify from-cluster -cluster-scoped")
+	flag.StringVar(&configFile, "config", "", "Project config file with flags and per-object conversion rules. Default is '"+config.FileName+"' next to the chart directory if exists. Example: helmify -config ./helmify.yaml")
+	flag.StringVar(&result.HookMarker, "hook-marker", hook.DefaultMarker, "Annotation or label marking objects to be converted into Helm hooks. Marker value is a hook type, '<marker>-weight' and '<marker>-delete-policy' set hook weight and delete policy. Example: helmify -hook-marker=example.com/hook")
 
 	flag.Parse()
 	if h || help {
@@ -88,14 +153,130 @@ func ReadFlags() (config.Config, error) {
 		printVersion()
 		osExit(0)
 	}
+	fromCluster := flag.Arg(0) == fromClusterCmd
+	if fromCluster {
+		// command flags follow the command name
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			return config.Config{}, err
+		}
+	}
 	name := flag.Arg(0)
 	if name != "" {
 		result.ChartName = filepath.Base(name)
 		result.ChartDir = filepath.Dir(name)
 	}
+	if configFile == "" {
+		if _, err := os.Stat(filepath.Join(result.ChartDir, config.FileName)); err == nil {
+			configFile = filepath.Join(result.ChartDir, config.FileName)
+		}
+	}
+	if configFile != "" {
+		file, err := config.LoadFile(configFile)
+		if err != nil {
+			return config.Config{}, err
+		}
+		err = applyOptions(file.Options)
+		if err != nil {
+			return config.Config{}, fmt.Errorf("%w: %s", err, configFile)
+		}
+		result.Rules = file.Rules
+		result.Include = append(result.Include, file.Include...)
+		result.Exclude = append(result.Exclude, file.Exclude...)
+	}
+	if result.Script == "" {
+		if _, err := os.Stat(filepath.Join(result.ChartDir, script.FileName)); err == nil {
+			result.Script = filepath.Join(result.ChartDir, script.FileName)
+		}
+	}
 	if result.Crd && result.OptionalCRDs {
 		return config.Config{}, errMutuallyExclusiveCRDs
 	}
+	if len(files) != 0 && result.Kustomization != "" || len(envs) != 0 && (len(files) != 0 || result.Kustomization != "") ||
+		fromCluster && (len(files) != 0 || result.Kustomization != "" || len(envs) != 0) {
+		return config.Config{}, errMutuallyExclusiveSources
+	}
+	if fromCluster {
+		result.Cluster = &cluster
+	} else {
+		for _, name := range clusterFlags {
+			if isPassed(name) {
+				return config.Config{}, fmt.Errorf("%w: -%s", errClusterFlag, name)
+			}
+		}
+	}
 	result.Files = files
+	result.Plugins = plugins
+	for _, w := range workloads {
+		gk, path, _ := strings.Cut(w, "=")
+		kind, group, _ := strings.Cut(gk, ".")
+		result.Workloads = append(result.Workloads, config.Workload{Kind: kind, Group: group, TemplatePath: path})
+	}
+	for _, f := range include {
+		filter, err := config.ParseFilter(f)
+		if err != nil {
+			return config.Config{}, err
+		}
+		result.Include = append(result.Include, filter)
+	}
+	for _, f := range exclude {
+		filter, err := config.ParseFilter(f)
+		if err != nil {
+			return config.Config{}, err
+		}
+		result.Exclude = append(result.Exclude, filter)
+	}
+	for _, f := range skipNormalize {
+		for _, name := range strings.Split(f, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result.SkipNormalize = append(result.SkipNormalize, name)
+			}
+		}
+	}
+	for _, env := range envs {
+		name, path, ok := strings.Cut(env, "=")
+		if !ok || name == "" || path == "" {
+			return config.Config{}, fmt.Errorf("%w: %q", errInvalidEnv, env)
+		}
+		result.Environments = append(result.Environments, config.Environment{Name: name, Path: path})
+	}
 	return result, nil
 }
+
+// isPassed - returns true if flag was set on command line or in config file.
+func isPassed(name string) bool {
+	res := false
+	flag.Visit(func(f *flag.Flag) {
+		res = res || f.Name == name
+	})
+	return res
+}
+
+// applyOptions - sets flags from config file options. Flags passed on command line are not overridden.
+func applyOptions(options map[string]interface{}) error {
+	passed := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	for name, value := range options {
+		switch name {
+		case "h", "help", "version", "config":
+			return fmt.Errorf("%w %q", errUnknownOption, name)
+		}
+		if flag.Lookup(name) == nil {
+			return fmt.Errorf("%w %q", errUnknownOption, name)
+		}
+		if passed[name] {
+			continue
+		}
+		items, isList := value.([]interface{})
+		if !isList {
+			items = []interface{}{value}
+		}
+		for _, item := range items {
+			if err := flag.Set(name, fmt.Sprint(item)); err != nil {
+				return fmt.Errorf("%w: invalid option %q", err, name)
+			}
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -48,6 +49,181 @@ func TestReadFlags_MutuallyExclusive(t *testing.T) {
 	require.Equal(t, errMutuallyExclusiveCRDs.Error(), err.Error())
 }
 
+func TestReadFlags_MutuallyExclusiveSources(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	os.Args = []string{
+		"helmify",
+		"-f", "./test_data",
+		"-k", "./config/default",
+	}
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+
+	_, err := ReadFlags()
+	require.ErrorIs(t, err, errMutuallyExclusiveSources)
+}
+
+func TestReadFlags_Environments(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	os.Args = []string{
+		"helmify",
+		"-env", "dev=./overlays/dev",
+		"-env", "prod=./overlays/prod",
+	}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	cfg, err := ReadFlags()
+	require.NoError(t, err)
+	require.Equal(t, []config.Environment{{Name: "dev", Path: "./overlays/dev"}, {Name: "prod", Path: "./overlays/prod"}}, cfg.Environments)
+
+	os.Args = []string{"helmify", "-env", "./overlays/dev"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	_, err = ReadFlags()
+	require.ErrorIs(t, err, errInvalidEnv)
+}
+
+func TestReadFlags_Workloads(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	os.Args = []string{
+		"helmify",
+		"-workload", "Rollout",
+		"-workload", "CloneSet.apps.kruise.io=spec.workload.template",
+	}
+	resetFlags(t)
+	cfg, err := ReadFlags()
+	require.NoError(t, err)
+	require.Equal(t, []config.Workload{
+		{Kind: "Rollout"},
+		{Kind: "CloneSet", Group: "apps.kruise.io", TemplatePath: "spec.workload.template"},
+	}, cfg.Workloads)
+}
+
+func TestReadFlags_Filters(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	os.Args = []string{"helmify", "-include", "kind=*.apps;selector=app=web", "-exclude", "kind=Secret;name=^dev-", "-exclude", "namespace=test-*"}
+	resetFlags(t)
+	cfg, err := ReadFlags()
+	require.NoError(t, err)
+	assert.Equal(t, []config.Filter{{Kind: "*.apps", Selector: "app=web"}}, cfg.Include)
+	assert.Equal(t, []config.Filter{{Kind: "Secret", Name: "^dev-"}, {Namespace: "test-*"}}, cfg.Exclude)
+
+	os.Args = []string{"helmify", "-exclude", "type=Opaque"}
+	resetFlags(t)
+	_, err = ReadFlags()
+	require.Error(t, err)
+}
+
+func TestReadFlags_SkipNormalize(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	os.Args = []string{"helmify", "-skip-normalize", "status, last-applied", "-skip-normalize", "server-fields", "-remove-defaults"}
+	resetFlags(t)
+	cfg, err := ReadFlags()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "last-applied", "server-fields"}, cfg.SkipNormalize)
+	assert.True(t, cfg.RemoveDefaults)
+}
+
+func TestReadFlags_FromCluster(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	os.Args = []string{"helmify", "-v", "from-cluster", "-namespace", "my-ns", "-selector", "app=web", "-cluster-scoped", "deploy/mychart"}
+	resetFlags(t)
+	cfg, err := ReadFlags()
+	require.NoError(t, err)
+	assert.True(t, cfg.Verbose)
+	assert.Equal(t, &config.Cluster{Namespace: "my-ns", Selector: "app=web", ClusterScoped: true}, cfg.Cluster)
+	assert.Equal(t, "mychart", cfg.ChartName)
+	assert.Equal(t, "deploy", cfg.ChartDir)
+
+	os.Args = []string{"helmify", "from-cluster", "-f", "./test_data"}
+	resetFlags(t)
+	_, err = ReadFlags()
+	require.ErrorIs(t, err, errMutuallyExclusiveSources)
+
+	os.Args = []string{"helmify", "-namespace", "my-ns", "mychart"}
+	resetFlags(t)
+	_, err = ReadFlags()
+	require.ErrorIs(t, err, errClusterFlag)
+}
+
+func TestReadFlags_ConfigFile(t *testing.T) {
+	oldArgs := os.Args
+	oldCommandLine := flag.CommandLine
+
+	t.Cleanup(func() {
+		os.Args = oldArgs
+		flag.CommandLine = oldCommandLine
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.FileName), []byte(`apiVersion: helmify.io/v1alpha1
+kind: Config
+options:
+  generate-defaults: true
+  hook-marker: example.com/hook
+  f: [a.yaml, b.yaml]
+rules:
+  - kind: Secret
+    skip: true
+`), 0o600))
+
+	os.Args = []string{"helmify", "-hook-marker", "example.com/cli", filepath.Join(dir, "mychart")}
+	resetFlags(t)
+	cfg, err := ReadFlags()
+	require.NoError(t, err)
+	assert.True(t, cfg.GenerateDefaults)
+	assert.Equal(t, "example.com/cli", cfg.HookMarker)
+	assert.Equal(t, []string{"a.yaml", "b.yaml"}, cfg.Files)
+	assert.Equal(t, []config.Rule{{Kind: "Secret", Skip: true}}, cfg.Rules)
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+	require.NoError(t, os.WriteFile(unknown, []byte("apiVersion: helmify.io/v1alpha1\nkind: Config\noptions:\n  no-such-flag: true\n"), 0o600))
+	os.Args = []string{"helmify", "-config", unknown}
+	resetFlags(t)
+	_, err = ReadFlags()
+	require.ErrorIs(t, err, errUnknownOption)
+}
+
 func TestReadFlags_Version(t *testing.T) {
 	oldArgs := os.Args
 	oldCommandLine := flag.CommandLine
@@ -154,6 +330,58 @@ func TestReadFlags_DefaultValuesMatchFlagDefaults(t *testing.T) {
 			flagName: "cert-manager-version",
 			getValue: func(cfg config.Config) string { return cfg.CertManagerVersion },
 		},
+		{
+			flagName: "k",
+			getValue: func(cfg config.Config) string { return cfg.Kustomization },
+		},
+		{
+			flagName: "hook-marker",
+			getValue: func(cfg config.Config) string { return cfg.HookMarker },
+		},
+		{
+			flagName: "script",
+			getValue: func(cfg config.Config) string { return cfg.Script },
+		},
+		{
+			flagName: "plugin-dir",
+			getValue: func(cfg config.Config) string { return cfg.PluginDir },
+		},
+		{
+			flagName: "plugin-timeout",
+			getValue: func(cfg config.Config) string { return cfg.PluginTimeout.String() },
+		},
+		{
+			flagName: "report",
+			getValue: func(cfg config.Config) string { return cfg.Report },
+		},
+		{
+			flagName: "report-format",
+			getValue: func(cfg config.Config) string { return cfg.ReportFormat },
+		},
+		{
+			flagName: "conversion-report",
+			getValue: func(cfg config.Config) string { return cfg.ConversionReport },
+		},
+		{
+			flagName: "conversion-report-format",
+			getValue: func(cfg config.Config) string { return cfg.ConversionReportFormat },
+		},
+		{
+			flagName: "kubeconfig",
+			getValue: func(cfg config.Config) string { return cluster(cfg).Kubeconfig },
+		},
+		{
+			flagName: "context",
+			getValue: func(cfg config.Config) string { return cluster(cfg).Context },
+		},
+		{
+			flagName: "namespace",
+			getValue: func(cfg config.Config) string { return cluster(cfg).Namespace },
+		},
+		{
+			flagName: "selector",
+			getValue: func(cfg config.Config) string { return cluster(cfg).Selector },
+		},
 	}
 
 	boolToStr := func(b bool) string {
@@ -175,11 +403,22 @@ func TestReadFlags_DefaultValuesMatchFlagDefaults(t *testing.T) {
 		{"optional-crds", func(cfg config.Config) bool { return cfg.OptionalCRDs }},
 		{"image-pull-secrets", func(cfg config.Config) bool { return cfg.ImagePullSecrets }},
 		{"generate-defaults", func(cfg config.Config) bool { return cfg.GenerateDefaults }},
+		{"generate-autoscaling", func(cfg config.Config) bool { return cfg.GenerateAutoscaling }},
 		{"cert-manager-as-subchart", func(cfg config.Config) bool { return cfg.CertManagerAsSubchart }},
 		{"cert-manager-install-crd", func(cfg config.Config) bool { return cfg.CertManagerInstallCRD }},
 		{"original-name", func(cfg config.Config) bool { return cfg.OriginalName }},
 		{"preserve-ns", func(cfg config.Config) bool { return cfg.PreserveNs }},
 		{"add-webhook-option", func(cfg config.Config) bool { return cfg.AddWebhookOption }},
+		{"generate-schema", func(cfg config.Config) bool { return cfg.GenerateSchema }},
+		{"merge", func(cfg config.Config) bool { return cfg.Merge }},
+		{"dry-run", func(cfg config.Config) bool { return cfg.DryRun }},
+		{"diff", func(cfg config.Config) bool { return cfg.Diff }},
+		{"values-docs", func(cfg config.Config) bool { return cfg.ValuesDocs }},
+		{"verify", func(cfg config.Config) bool { return cfg.Verify }},
+		{"cluster-scoped", func(cfg config.Config) bool { return cluster(cfg).ClusterScoped }},
+		{"strict", func(cfg config.Config) bool { return cfg.Strict }},
+		{"allow-unknown-fields", func(cfg config.Config) bool { return cfg.AllowUnknownFields }},
+		{"remove-defaults", func(cfg config.Config) bool { return cfg.RemoveDefaults }},
 	}
 
 	for _, tt := range stringTests {
@@ -198,3 +437,11 @@ func TestReadFlags_DefaultValuesMatchFlagDefaults(t *testing.T) {
 		})
 	}
 }
+
+// cluster - returns from-cluster options or zero options if from-cluster is not used.
+func cluster(cfg config.Config) config.Cluster {
+	if cfg.Cluster == nil {
+		return config.Cluster{}
+	}
+	return *cfg.Cluster
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/arttor/helmify/pkg/app"
+	"github.com/arttor/helmify/pkg/helm"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,12 +23,20 @@ func main() {
 		logrus.WithError(err).Error("stdin error")
 		os.Exit(1)
 	}
-	if len(conf.Files) == 0 && (stat.Mode()&os.ModeCharDevice) != 0 {
+	if len(conf.Files) == 0 && conf.Kustomization == "" && len(conf.Environments) == 0 && conf.Cluster == nil && (stat.Mode()&os.ModeCharDevice) != 0 {
 		logrus.Error("no data piped in stdin")
 		os.Exit(1)
 	}
 	if err = app.Start(os.Stdin, conf); err != nil {
+		if errors.Is(err, helm.ErrChangesPending) {
+			// chart drift is expected result of -diff and is already printed
+			logrus.Info(err.Error())
+			os.Exit(3)
+		}
 		logrus.WithError(err).Error("helmify finished with error")
+		if errors.Is(err, app.ErrVerifyFailed) {
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
@@ -2,31 +2,45 @@ package app
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/arttor/helmify/pkg/file"
 	"github.com/arttor/helmify/pkg/processor/job"
+	"github.com/arttor/helmify/pkg/processor/monitoring"
+	"github.com/arttor/helmify/pkg/processor/networkpolicy"
 	"github.com/arttor/helmify/pkg/processor/poddisruptionbudget"
 	"github.com/arttor/helmify/pkg/processor/statefulset"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/arttor/helmify/pkg/cluster"
 	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/decoder"
 	"github.com/arttor/helmify/pkg/helm"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/kustomize"
+	"github.com/arttor/helmify/pkg/normalize"
+	"github.com/arttor/helmify/pkg/plugin"
 	"github.com/arttor/helmify/pkg/processor"
 	"github.com/arttor/helmify/pkg/processor/configmap"
 	"github.com/arttor/helmify/pkg/processor/crd"
 	"github.com/arttor/helmify/pkg/processor/daemonset"
 	"github.com/arttor/helmify/pkg/processor/deployment"
+	"github.com/arttor/helmify/pkg/processor/gateway"
+	"github.com/arttor/helmify/pkg/processor/hpa"
 	"github.com/arttor/helmify/pkg/processor/rbac"
 	"github.com/arttor/helmify/pkg/processor/secret"
 	"github.com/arttor/helmify/pkg/processor/service"
 	"github.com/arttor/helmify/pkg/processor/storage"
 	"github.com/arttor/helmify/pkg/processor/webhook"
+	"github.com/arttor/helmify/pkg/processor/workload"
+	"github.com/arttor/helmify/pkg/script"
 )
 
 // Start - application entrypoint for processing input to a Helm chart.
@@ -36,6 +50,10 @@ func Start(stdin io.Reader, config config.Config) error {
 		return err
 	}
 	setLogLevel(config)
+	config.Plugins, err = plugin.Find(config.PluginDir, config.Plugins)
+	if err != nil {
+		return err
+	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 	done := make(chan os.Signal, 1)
@@ -45,8 +63,50 @@ func Start(stdin io.Reader, config config.Config) error {
 		logrus.Debug("Received termination, signaling shutdown")
 		cancelFunc()
 	}()
-	appCtx := New(config, helm.NewOutput())
-	appCtx = appCtx.WithProcessors(
+	if len(config.Environments) != 0 {
+		return createHelmEnvs(ctx, config, helm.NewOutput())
+	}
+	appCtx, err := newContext(ctx, config, helm.NewOutput())
+	if err != nil {
+		return err
+	}
+	err = load(ctx.Done(), appCtx, stdin, config)
+	if err != nil {
+		return err
+	}
+	err = appCtx.CreateHelm(ctx.Done())
+	if err != nil && !errors.Is(err, helm.ErrChangesPending) {
+		return err
+	}
+	// pending changes of dry run are returned after conversion report is written
+	pending := err
+	err = finish(config, []verifyTarget{{appMeta: appCtx.appMeta, inputs: appCtx.inputs}}, appCtx.report, appCtx.inputErrors)
+	if err != nil {
+		return err
+	}
+	return pending
+}
+
+// newContext - returns context with all supported processors.
+// Plugins and script processors are added first to be able to claim kinds supported by built-in processors.
+// Plugin calls are interrupted when ctx is done.
+func newContext(ctx context.Context, config config.Config, output helmify.Output) (*appContext, error) {
+	normalizers, err := normalize.New(config.SkipNormalize, config.RemoveDefaults)
+	if err != nil {
+		return nil, err
+	}
+	appCtx := New(config, output).WithNormalizers(normalizers...)
+	for _, path := range config.Plugins {
+		appCtx = appCtx.WithProcessors(plugin.New(ctx, path, config.PluginTimeout))
+	}
+	if config.Script != "" {
+		s, err := script.Load(config.Script)
+		if err != nil {
+			return nil, err
+		}
+		appCtx = appCtx.WithScript(s)
+	}
+	return appCtx.WithProcessors(
 		configmap.New(),
 		crd.New(),
 		daemonset.New(),
@@ -55,6 +115,9 @@ func Start(stdin io.Reader, config config.Config) error {
 		storage.New(),
 		service.New(),
 		service.NewIngress(),
+		gateway.New(),
+		gateway.NewRoute(),
+		networkpolicy.New(),
 		rbac.ClusterRoleBinding(),
 		rbac.Role(),
 		rbac.RoleBinding(),
@@ -67,26 +130,109 @@ func Start(stdin io.Reader, config config.Config) error {
 		job.NewCron(),
 		job.NewJob(),
 		poddisruptionbudget.New(),
-	).WithDefaultProcessor(processor.Default())
-	if len(config.Files) != 0 {
-		file.Walk(config.Files, config.FilesRecursively, func(filename string, fileReader io.Reader) {
-			objects := decoder.Decode(ctx.Done(), fileReader)
+		hpa.New(),
+		monitoring.ServiceMonitor(),
+		monitoring.PodMonitor(),
+		monitoring.PrometheusRule(),
+		workload.New(),
+	).WithDefaultProcessor(processor.Default()), nil
+}
+
+// load - adds k8s objects from kustomization, files or stdin into the context.
+// Objects are normalized and transformed by context script before adding. Invalid input documents are reported
+// together after loading and fail loading in strict mode.
+func load(stop <-chan struct{}, appCtx *appContext, stdin io.Reader, config config.Config) error {
+	var err error
+	add := func(obj *unstructured.Unstructured, filename string) {
+		if err != nil {
+			return
+		}
+		obj, err = appCtx.transform(obj)
+		if err == nil && obj != nil {
+			appCtx.Add(obj, filename)
+		}
+	}
+	// inputErrs - input documents which can't be read, decoded or don't match schema of their kind.
+	// Decoder calls onErr from its goroutine before closing objects channel, so errors are complete once it is drained.
+	var inputErrs decoder.Errors
+	onErr := func(path string) func(*decoder.Error) {
+		return func(e *decoder.Error) {
+			e.File = path
+			inputErrs = append(inputErrs, e)
+		}
+	}
+	switch {
+	case config.Cluster != nil:
+		clients, namespace, connErr := cluster.Connect(config.Cluster.Kubeconfig, config.Cluster.Context)
+		if connErr != nil {
+			return connErr
+		}
+		src := *config.Cluster
+		if src.Namespace == "" {
+			src.Namespace = namespace
+		}
+		importErr := cluster.Import(stop, clients, src, func(obj *unstructured.Unstructured) {
+			add(obj, "")
+		})
+		if importErr != nil {
+			return importErr
+		}
+	case config.Kustomization != "":
+		buildErr := kustomize.Build(config.Kustomization, func(obj *unstructured.Unstructured, filename string) {
+			if validErr := decoder.Validate(obj); validErr != nil {
+				path := config.Kustomization
+				if filename != "" {
+					path = filepath.Join(config.Kustomization, filename)
+				}
+				inputErrs = append(inputErrs, &decoder.Error{File: path, Err: validErr})
+			}
+			add(obj, filename)
+		})
+		if buildErr != nil {
+			return buildErr
+		}
+	case len(config.Files) != 0:
+		file.Walk(config.Files, config.FilesRecursively, func(path string, fileReader io.Reader) {
+			objects := decoder.Decode(stop, fileReader, onErr(path))
 			for obj := range objects {
-				appCtx.Add(obj, filename)
+				add(obj, filepath.Base(path))
 			}
+		}, func(path string, walkErr error) {
+			inputErrs = append(inputErrs, &decoder.Error{File: path, Err: walkErr})
 		})
-	} else {
-		objects := decoder.Decode(ctx.Done(), stdin)
+	default:
+		objects := decoder.Decode(stop, stdin, onErr(""))
 		for obj := range objects {
-			appCtx.Add(obj, "")
+			add(obj, "")
 		}
 	}
-
-	return appCtx.CreateHelm(ctx.Done())
+	if err != nil {
+		return err
+	}
+	if len(inputErrs) != 0 {
+		invalid := inputErrs
+		if config.AllowUnknownFields {
+			_, invalid = inputErrs.SplitUnknownFields()
+		}
+		if config.Strict && len(invalid) != 0 {
+			return invalid
+		}
+		logrus.Errorf("found %d invalid input documents", len(inputErrs))
+		for _, e := range inputErrs {
+			logrus.Error(e.Error())
+		}
+		appCtx.inputErrors = inputErrs
+	}
+	appCtx.logExcluded()
+	return nil
 }
 
 func setLogLevel(config config.Config) {
 	logrus.SetLevel(logrus.ErrorLevel)
+	if config.Verify {
+		// verification findings are reported as warnings
+		logrus.SetLevel(logrus.WarnLevel)
+	}
 	if config.Verbose {
 		logrus.SetLevel(logrus.InfoLevel)
 	}
@@ -2,12 +2,24 @@ package app
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/conversion"
+	"github.com/arttor/helmify/pkg/decoder"
+	"github.com/arttor/helmify/pkg/helm"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -15,6 +27,52 @@ const (
 	appChartName      = "test-app"
 )
 
+// sampleApp - returns sample app manifests with given objects prepended.
+func sampleApp(t *testing.T, objects string) string {
+	t.Helper()
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	require.NoError(t, err)
+	return objects + string(file)
+}
+
+// generate - generates chart from input. Chart is removed on test cleanup.
+func generate(t *testing.T, input string, conf config.Config) error {
+	t.Helper()
+	t.Cleanup(func() {
+		assert.NoError(t, os.RemoveAll(conf.ChartName))
+	})
+	return Start(strings.NewReader(input), conf)
+}
+
+// generateAndLint - generates chart from input and lints it with default values and each of values overrides.
+func generateAndLint(t *testing.T, input string, conf config.Config, overrides ...map[string]interface{}) {
+	t.Helper()
+	require.NoError(t, generate(t, input, conf))
+	lint(t, conf.ChartName, overrides...)
+}
+
+// lint - lints chart in strict mode with default values and each of values overrides.
+func lint(t *testing.T, chart string, overrides ...map[string]interface{}) {
+	t.Helper()
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	for _, values := range append([]map[string]interface{}{nil}, overrides...) {
+		result := helmLint.Run([]string{chart}, values)
+		for _, err := range result.Errors {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+// readFile - returns content of chart file.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
 func TestOperator(t *testing.T) {
 	file, err := os.Open("../../test_data/k8s-operator-kustomize.output")
 	assert.NoError(t, err)
@@ -58,3 +116,1101 @@ func TestApp(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestAppWithSchema(t *testing.T) {
+	require.NoError(t, generate(t, sampleApp(t, ""), config.Config{ChartName: appChartName, GenerateSchema: true, Verify: true}))
+	assert.FileExists(t, appChartName+"/values.schema.json")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	// required secret values are empty in values.yaml
+	assert.NotEmpty(t, helmLint.Run([]string{appChartName}, nil).Errors)
+	secrets := map[string]interface{}{
+		"mySecretCa": map[string]interface{}{"caCrt": "ca"},
+		"mySecretVars": map[string]interface{}{
+			"elasticFoobarHunter123MeowtownVerify": "a", "str": "b", "var1": "c", "var2": "d",
+		},
+	}
+	assert.Empty(t, helmLint.Run([]string{appChartName}, secrets).Errors)
+}
+
+func TestOperatorWithValuesDocs(t *testing.T) {
+	file, err := os.Open("../../test_data/k8s-operator-kustomize.output")
+	assert.NoError(t, err)
+
+	objects := bufio.NewReader(file)
+	err = Start(objects, config.Config{ChartName: operatorChartName, ValuesDocs: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(operatorChartName)
+		assert.NoError(t, err)
+	})
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{operatorChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithDefaults(t *testing.T) {
+	file, err := os.Open("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+
+	objects := bufio.NewReader(file)
+	err = Start(objects, config.Config{ChartName: appChartName, GenerateDefaults: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+
+	overrides := map[string]interface{}{
+		"myapp": map[string]interface{}{
+			"podLabels":         map[string]interface{}{"team": "a"},
+			"podAnnotations":    map[string]interface{}{"note": "b"},
+			"priorityClassName": "high",
+			"affinity":          map[string]interface{}{"nodeAffinity": map[string]interface{}{}},
+			"tolerations":       []interface{}{map[string]interface{}{"operator": "Exists"}},
+			"extraVolumes":      []interface{}{map[string]interface{}{"name": "extra", "emptyDir": map[string]interface{}{}}},
+			"extraContainers":   []interface{}{map[string]interface{}{"name": "extra", "image": "busybox"}},
+			"proxySidecar": map[string]interface{}{
+				"extraEnv":          []interface{}{map[string]interface{}{"name": "A", "value": "b"}},
+				"extraVolumeMounts": []interface{}{map[string]interface{}{"name": "extra", "mountPath": "/extra"}},
+			},
+		},
+	}
+	result = helmLint.Run([]string{appChartName}, overrides)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithDefaultsWithoutPodLabels(t *testing.T) {
+	workloads := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: app-db
+spec:
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    spec:
+      containers:
+        - name: db
+          image: postgres:15
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: app-agent
+spec:
+  selector:
+    matchLabels:
+      app: agent
+  template:
+    spec:
+      containers:
+        - name: agent
+          image: busybox:1.36
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: app-migrate
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+        - name: migrate
+          image: busybox:1.36
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: app-cron
+spec:
+  schedule: "* * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+            - name: cron
+              image: busybox:1.36
+`
+	overrides := map[string]interface{}{
+		"web":     map[string]interface{}{"podLabels": map[string]interface{}{"team": "a"}},
+		"db":      map[string]interface{}{"podLabels": map[string]interface{}{"team": "a"}},
+		"agent":   map[string]interface{}{"podLabels": map[string]interface{}{"team": "a"}},
+		"migrate": map[string]interface{}{"podLabels": map[string]interface{}{"team": "a"}},
+		"cron":    map[string]interface{}{"podLabels": map[string]interface{}{"team": "a"}},
+	}
+	generateAndLint(t, workloads, config.Config{ChartName: appChartName, GenerateDefaults: true, Verify: true}, overrides)
+}
+
+func TestAppWithHooks(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	hookJob := `
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: db-migrate
+  annotations:
+    helmify.io/hook: pre-install,pre-upgrade
+    helmify.io/hook-weight: "-5"
+spec:
+  template:
+    spec:
+      containers:
+        - name: migrate
+          image: migrate:v1
+      restartPolicy: Never
+`
+	objects := bufio.NewReader(strings.NewReader(string(file) + hookJob))
+	err = Start(objects, config.Config{ChartName: appChartName})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	template, err := os.ReadFile(appChartName + "/templates/db-migrate.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(template), `"helm.sh/hook": {{ .Values.dbMigrate.hook.type | quote }}`)
+	assert.NotContains(t, string(template), "helmify.io/hook")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithAutoscaling(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	autoscaler := `
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: myapp
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: myapp
+  minReplicas: 2
+  maxReplicas: 5
+  metrics:
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: 70
+`
+	objects := bufio.NewReader(strings.NewReader(string(file) + autoscaler))
+	err = Start(objects, config.Config{ChartName: appChartName, GenerateAutoscaling: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	deployment, err := os.ReadFile(appChartName + "/templates/deployment.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(deployment), "{{- if not .Values.myapp.autoscaling.enabled }}")
+	statefulset, err := os.ReadFile(appChartName + "/templates/statefulset.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(statefulset), "{{- if .Values.web.autoscaling.enabled }}")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+	overrides := map[string]interface{}{
+		"myapp": map[string]interface{}{"autoscaling": map[string]interface{}{"enabled": false}},
+		"web":   map[string]interface{}{"autoscaling": map[string]interface{}{"enabled": true}},
+	}
+	result = helmLint.Run([]string{appChartName}, overrides)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithIngress(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	ingress := `
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: myapp-tls-ingress
+spec:
+  ingressClassName: nginx
+  defaultBackend:
+    service:
+      name: myapp-service
+      port:
+        number: 8443
+  tls:
+    - hosts:
+        - example.com
+      secretName: my-secret-ca
+  rules:
+    - host: example.com
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: myapp-service
+                port:
+                  number: 8443
+`
+	objects := bufio.NewReader(strings.NewReader(string(file) + ingress))
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	template, err := os.ReadFile(appChartName + "/templates/tls-ingress.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(template), "{{- if .Values.tlsIngress.enabled }}")
+	assert.Contains(t, string(template), "name: '{{ include \"test-app.fullname\" . }}-service'")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+	overrides := map[string]interface{}{
+		"tlsIngress": map[string]interface{}{"enabled": false},
+	}
+	result = helmLint.Run([]string{appChartName}, overrides)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithGatewayAPI(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	gatewayAPI := `
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: myapp-gateway
+spec:
+  gatewayClassName: istio
+  listeners:
+    - name: https
+      hostname: example.com
+      port: 443
+      protocol: HTTPS
+      tls:
+        certificateRefs:
+          - name: my-secret-ca
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: myapp-route
+spec:
+  parentRefs:
+    - name: myapp-gateway
+  hostnames:
+    - example.com
+  rules:
+    - backendRefs:
+        - name: myapp-service
+          port: 8443
+`
+	objects := bufio.NewReader(strings.NewReader(string(file) + gatewayAPI))
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	route, err := os.ReadFile(appChartName + "/templates/route.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(route), "{{- if .Values.route.enabled }}")
+	assert.Contains(t, string(route), "- name: '{{ include \"test-app.fullname\" . }}-gateway'")
+	assert.Contains(t, string(route), "- name: '{{ include \"test-app.fullname\" . }}-service'")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+	overrides := map[string]interface{}{
+		"route": map[string]interface{}{"enabled": false},
+	}
+	result = helmLint.Run([]string{appChartName}, overrides)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithNetworkPolicy(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	policy := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: myapp-policy
+  namespace: kube-system
+spec:
+  podSelector:
+    matchLabels:
+      app: myapp
+  ingress:
+    - from:
+        - namespaceSelector:
+            matchLabels:
+              kubernetes.io/metadata.name: kube-system
+          podSelector:
+            matchLabels:
+              app: nginx
+        - ipBlock:
+            cidr: 10.0.0.0/16
+      ports:
+        - protocol: TCP
+          port: 8443
+---
+`
+	objects := bufio.NewReader(strings.NewReader(policy + string(file)))
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	template, err := os.ReadFile(appChartName + "/templates/myapp-policy.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(template), "{{- if .Values.networkPolicy.enabled }}")
+	assert.Contains(t, string(template), "kubernetes.io/metadata.name: '{{ .Release.Namespace }}'")
+	assert.Contains(t, string(template), "app: nginx\n          {{- include \"test-app.selectorLabels\" . | nindent 10 }}")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+	overrides := map[string]interface{}{
+		"networkPolicy": map[string]interface{}{"enabled": false},
+	}
+	result = helmLint.Run([]string{appChartName}, overrides)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithNetworkPolicyIPBlocksOnly(t *testing.T) {
+	policy := `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: myapp-egress
+  namespace: kube-system
+spec:
+  podSelector:
+    matchLabels:
+      app: myapp
+  egress:
+    - to:
+        - ipBlock:
+            cidr: 10.0.0.0/16
+      ports:
+        - protocol: TCP
+          port: 443
+`
+	noIPBlocks := map[string]interface{}{
+		"myappEgress": map[string]interface{}{"egress": []interface{}{map[string]interface{}{
+			"ipBlocks": []interface{}{},
+			"ports":    []interface{}{map[string]interface{}{"protocol": "TCP", "port": 443}},
+		}}},
+	}
+	generateAndLint(t, policy, config.Config{ChartName: appChartName, Verify: true}, noIPBlocks)
+
+	chrt, err := loader.Load(appChartName)
+	require.NoError(t, err)
+	render := func(values map[string]interface{}) string {
+		renderValues, err := chartutil.ToRenderValues(chrt, values, chartutil.ReleaseOptions{Name: "test", Namespace: "test-ns"}, nil)
+		require.NoError(t, err)
+		files, err := engine.Render(chrt, renderValues)
+		require.NoError(t, err)
+		return strings.TrimSpace(files[appChartName+"/templates/myapp-egress.yaml"])
+	}
+	assert.Contains(t, render(nil), "egress:\n  - to:\n    - ipBlock:\n        cidr: 10.0.0.0/16")
+	// rule without peers would allow all destinations
+	assert.True(t, strings.HasSuffix(render(noIPBlocks), "policyTypes:\n  - Ingress\n  - Egress\n  egress:"))
+}
+
+func TestAppWithRollout(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	rollout := `apiVersion: v1
+kind: Service
+metadata:
+  name: myapp-canary
+  namespace: kube-system
+spec:
+  selector:
+    app: myapp-web
+  ports:
+    - port: 80
+      targetPort: 8080
+---
+apiVersion: argoproj.io/v1alpha1
+kind: AnalysisTemplate
+metadata:
+  name: myapp-success-rate
+  namespace: kube-system
+spec:
+  metrics:
+    - name: success-rate
+      provider:
+        prometheus:
+          address: http://prometheus:9090
+          query: sum(rate(http_requests_total{code!~"5.."}[5m]))
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Rollout
+metadata:
+  name: myapp-web
+  namespace: kube-system
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: myapp-web
+  template:
+    metadata:
+      labels:
+        app: myapp-web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.25
+          resources:
+            limits:
+              cpu: 500m
+  strategy:
+    canary:
+      canaryService: myapp-canary
+      steps:
+        - setWeight: 20
+        - analysis:
+            templates:
+              - templateName: myapp-success-rate
+---
+`
+	objects := bufio.NewReader(strings.NewReader(rollout + string(file)))
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	template, err := os.ReadFile(appChartName + "/templates/myapp-web.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(template), "image: {{ .Values.myappWeb.web.image.repository }}")
+	assert.Contains(t, string(template), "canaryService: {{ include \"test-app.fullname\" . }}-myapp-canary")
+	assert.Contains(t, string(template), "{{- tpl (toYaml .Values.myappWeb.strategy.canary.steps) $ | nindent 8 }}")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppWithExportedObjects(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	exported := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp-exported
+  namespace: kube-system
+  uid: 0b5e8d1c-3f0a-4c5e-9d1e-7a1f2b3c4d5e
+  resourceVersion: "1234"
+  creationTimestamp: "2023-01-01T00:00:00Z"
+  managedFields:
+    - manager: kubectl-client-side-apply
+      operation: Update
+      apiVersion: v1
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"v1","kind":"ConfigMap"}
+    owner: team-a
+data:
+  key: value
+---
+`
+	objects := bufio.NewReader(strings.NewReader(exported + string(file)))
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	template, err := os.ReadFile(appChartName + "/templates/myapp-exported.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(template), "owner: team-a")
+	for _, field := range []string{"managedFields", "resourceVersion", "uid", "creationTimestamp", "last-applied-configuration"} {
+		assert.NotContains(t, string(template), field)
+	}
+}
+
+func TestAppWithFilters(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	fixtures := `apiVersion: v1
+kind: Secret
+metadata:
+  name: dev-creds
+  namespace: dev
+stringData:
+  password: dev
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-fixture
+  namespace: dev
+  labels:
+    fixture: "true"
+data:
+  key: value
+---
+`
+	objects := bufio.NewReader(strings.NewReader(fixtures + string(file)))
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true, Exclude: []config.Filter{
+		{Kind: "Secret", Name: "^dev-"},
+		{Selector: "fixture"},
+	}})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	assert.NoFileExists(t, appChartName+"/templates/dev-creds.yaml")
+	assert.NoFileExists(t, appChartName+"/templates/test-fixture.yaml")
+	assert.FileExists(t, appChartName+"/templates/deployment.yaml")
+}
+
+func TestAppStrict(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	invalid := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp-broken
+  namespace: kube-system
+data: [
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: myapp-account
+  namespace: kube-system
+automountServiceAccountToken: "no"
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp-typo
+  namespace: kube-system
+spec:
+  prots:
+    - port: 80
+---
+`
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	err = Start(strings.NewReader(invalid+string(file)), config.Config{ChartName: appChartName})
+	assert.NoError(t, err)
+	assert.FileExists(t, appChartName+"/templates/deployment.yaml")
+
+	err = Start(strings.NewReader(invalid+string(file)), config.Config{ChartName: appChartName, Strict: true})
+	var inputErrs decoder.Errors
+	if assert.ErrorAs(t, err, &inputErrs) && assert.Len(t, inputErrs, 3) {
+		assert.Equal(t, 6, inputErrs[0].Line)
+		assert.ErrorIs(t, inputErrs[1], decoder.ErrSchema)
+		assert.Equal(t, 2, inputErrs[1].Document)
+		assert.ErrorIs(t, inputErrs[2], decoder.ErrUnknownFields)
+		assert.Equal(t, 3, inputErrs[2].Document)
+	}
+
+	err = Start(strings.NewReader(invalid+string(file)), config.Config{ChartName: appChartName, Strict: true, AllowUnknownFields: true})
+	if assert.ErrorAs(t, err, &inputErrs) && assert.Len(t, inputErrs, 2) {
+		assert.Equal(t, 6, inputErrs[0].Line)
+		assert.ErrorIs(t, inputErrs[1], decoder.ErrSchema)
+	}
+}
+
+func TestAppWithRules(t *testing.T) {
+	file, err := os.Open("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+
+	objects := bufio.NewReader(file)
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true, Rules: []config.Rule{
+		{Kind: "Secret", Name: "my-secret-ca", Skip: true},
+		{Kind: "Service", Name: "myapp-service", OriginalName: true, PreserveNs: true},
+		{Kind: "Deployment", File: "app/deployment.yaml", ValuesPrefix: "backend", Values: []config.ValueRule{
+			{Path: "spec.template.spec.containers[name=proxy-sidecar].ports", Value: "myapp.proxySidecar.ports"},
+		}},
+	}})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	_, err = os.Stat(appChartName + "/templates/my-secret-ca.yaml")
+	assert.True(t, os.IsNotExist(err))
+	service, err := os.ReadFile(appChartName + "/templates/myapp-service.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(service), "  name: myapp-service\n  namespace: my-ns\n")
+	deployment, err := os.ReadFile(appChartName + "/templates/app/deployment.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(deployment), "ports: {{- toYaml .Values.backend.myapp.proxySidecar.ports | nindent 10 }}")
+	assert.Contains(t, string(deployment), "replicas: {{ .Values.backend.myapp.replicas }}")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestAppVerify(t *testing.T) {
+	file, err := os.Open("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+
+	objects := bufio.NewReader(file)
+	err = Start(objects, config.Config{ChartName: appChartName, Verify: true})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestReport(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "report.json")
+	err := Start(strings.NewReader(""), config.Config{
+		ChartName:     appChartName,
+		Kustomization: "../../test_data/kustomize/overlays/prod",
+		Report:        report,
+		ReportFormat:  config.ReportJSON,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	data, err := os.ReadFile(report)
+	assert.NoError(t, err)
+	res := struct {
+		OK      bool
+		Results []struct {
+			Release string
+			Summary struct{ Objects, OK int }
+		}
+	}{}
+	assert.NoError(t, json.Unmarshal(data, &res))
+	assert.True(t, res.OK)
+	assert.Equal(t, "my-app", res.Results[0].Release)
+	assert.Equal(t, 4, res.Results[0].Summary.Objects)
+	assert.Equal(t, 4, res.Results[0].Summary.OK)
+}
+
+func TestConversionReport(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	widget := `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: myapp-widget
+  namespace: kube-system
+---
+`
+	report := filepath.Join(t.TempDir(), "conversion.json")
+	err = Start(strings.NewReader(widget+string(file)), config.Config{
+		ChartName:        appChartName,
+		ConversionReport: report,
+		Exclude:          []config.Filter{{Kind: "Secret", Name: "^my-secret-ca$"}},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	data, err := os.ReadFile(report)
+	assert.NoError(t, err)
+	var res conversion.Report
+	assert.NoError(t, json.Unmarshal(data, &res))
+	assert.Equal(t, appChartName, res.Chart)
+	assert.Equal(t, []string{"Widget.example.com"}, res.UnsupportedKinds)
+	objects := map[string]conversion.Object{}
+	for _, o := range res.Objects {
+		objects[o.Kind+"/"+o.Name] = o
+	}
+	deployment := objects["Deployment/myapp"]
+	assert.Equal(t, conversion.StatusConverted, deployment.Status)
+	assert.Equal(t, "deployment.deployment", deployment.Processor)
+	assert.Equal(t, "templates/deployment.yaml", deployment.Template)
+	assert.Contains(t, deployment.Values, "myapp.replicas")
+	widgetRes := objects["Widget/myapp-widget"]
+	assert.Equal(t, conversion.StatusDefault, widgetRes.Status)
+	assert.Equal(t, "default", widgetRes.Processor)
+	assert.NotEmpty(t, widgetRes.Warnings)
+	assert.Equal(t, conversion.StatusExcluded, objects["Secret/my-secret-ca"].Status)
+	assert.Contains(t, res.Coverage, conversion.Coverage{APIVersion: "apps/v1", Kind: "Deployment", Objects: 1, Converted: 1})
+}
+
+func TestDryRun(t *testing.T) {
+	file, err := os.ReadFile("../../test_data/sample-app.yaml")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	err = Start(strings.NewReader(string(file)), config.Config{ChartName: appChartName, DryRun: true})
+	assert.NoError(t, err)
+	assert.NoDirExists(t, appChartName)
+
+	report := filepath.Join(t.TempDir(), "conversion.json")
+	err = Start(strings.NewReader(string(file)), config.Config{ChartName: appChartName, Diff: true, ConversionReport: report})
+	assert.ErrorIs(t, err, helm.ErrChangesPending)
+	assert.NoDirExists(t, appChartName)
+	var res conversion.Report
+	assert.NoError(t, json.Unmarshal([]byte(readFile(t, report)), &res))
+	assert.NotEmpty(t, res.Objects)
+
+	err = Start(strings.NewReader(string(file)), config.Config{ChartName: appChartName})
+	assert.NoError(t, err)
+	err = Start(strings.NewReader(string(file)), config.Config{ChartName: appChartName, Diff: true})
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(appChartName+"/templates/stale.yaml", []byte("kind: ConfigMap\n"), 0600))
+	err = Start(strings.NewReader(string(file)), config.Config{ChartName: appChartName, Diff: true})
+	assert.ErrorIs(t, err, helm.ErrChangesPending)
+	assert.FileExists(t, appChartName+"/templates/stale.yaml")
+}
+
+func TestOperatorWithMonitoring(t *testing.T) {
+	input := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-operator-controller-manager
+  namespace: my-operator-system
+spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+        - name: manager
+          image: controller:latest
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-operator-metrics-service
+  namespace: my-operator-system
+  labels:
+    control-plane: controller-manager
+spec:
+  selector:
+    control-plane: controller-manager
+  ports:
+    - name: https
+      port: 8443
+---
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: my-operator-metrics-monitor
+  namespace: my-operator-system
+spec:
+  endpoints:
+    - path: /metrics
+      port: https
+  namespaceSelector:
+    matchNames:
+      - my-operator-system
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+---
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: my-operator-alerts
+  namespace: my-operator-system
+spec:
+  groups:
+    - name: operator
+      rules:
+        - alert: OperatorDown
+          expr: up{job="my-operator"} == 0
+          annotations:
+            summary: "Instance {{ $labels.instance }} is down"
+`
+	report := filepath.Join(t.TempDir(), "report.json")
+	err := Start(strings.NewReader(input), config.Config{
+		ChartName:    operatorChartName,
+		Verify:       true,
+		Report:       report,
+		ReportFormat: config.ReportJSON,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		err = os.RemoveAll(operatorChartName)
+		assert.NoError(t, err)
+	})
+	data, err := os.ReadFile(report)
+	assert.NoError(t, err)
+	res := struct {
+		OK      bool
+		Results []struct {
+			Summary struct{ Objects, OK int }
+		}
+	}{}
+	assert.NoError(t, json.Unmarshal(data, &res))
+	assert.True(t, res.OK)
+	assert.Equal(t, 4, res.Results[0].Summary.OK)
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{operatorChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestReportFailed(t *testing.T) {
+	file, err := os.Open("../../test_data/k8s-operator-kustomize.output")
+	assert.NoError(t, err)
+
+	objects := bufio.NewReader(file)
+	err = Start(objects, config.Config{ChartName: operatorChartName, Report: filepath.Join(t.TempDir(), "report.txt")})
+	assert.ErrorIs(t, err, ErrVerifyFailed)
+	t.Cleanup(func() {
+		err = os.RemoveAll(operatorChartName)
+		assert.NoError(t, err)
+	})
+}
+
+func TestKustomization(t *testing.T) {
+	err := Start(strings.NewReader(""), config.Config{ChartName: appChartName, Kustomization: "../../test_data/kustomize/overlays/prod"})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	assert.FileExists(t, appChartName+"/templates/base/deployment.yaml")
+	assert.FileExists(t, appChartName+"/templates/pdb.yaml")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	result := helmLint.Run([]string{appChartName}, nil)
+	for _, err = range result.Errors {
+		assert.NoError(t, err)
+	}
+}
+
+func TestEnvironments(t *testing.T) {
+	err := Start(strings.NewReader(""), config.Config{ChartName: appChartName, Environments: []config.Environment{
+		{Name: "dev", Path: "../../test_data/kustomize/overlays/dev"},
+		{Name: "prod", Path: "../../test_data/kustomize/overlays/prod"},
+	}})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		err = os.RemoveAll(appChartName)
+		assert.NoError(t, err)
+	})
+	prodFile, err := os.ReadFile(appChartName + "/values-prod.yaml")
+	assert.NoError(t, err)
+	prodValues := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal(prodFile, &prodValues))
+	assert.Equal(t, map[string]interface{}{
+		"settings": map[string]interface{}{"logLevel": "info"},
+		"web": map[string]interface{}{
+			"podDisruptionBudget": map[string]interface{}{"enabled": true},
+			"replicas":            float64(3),
+			"web":                 map[string]interface{}{"image": map[string]interface{}{"tag": "1.25.3"}},
+		},
+	}, prodValues)
+	assert.FileExists(t, appChartName+"/values-dev.yaml")
+
+	helmLint := action.NewLint()
+	helmLint.Strict = true
+	helmLint.Namespace = "test-ns"
+	for _, values := range []map[string]interface{}{nil, prodValues} {
+		result := helmLint.Run([]string{appChartName}, values)
+		for _, err = range result.Errors {
+			assert.NoError(t, err)
+		}
+	}
+}
+func TestEnvironmentsTemplateDiff(t *testing.T) {
+	const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25.3
+        env:
+        - name: FOO
+          value: foo
+`
+	dir := t.TempDir()
+	dev, prod := filepath.Join(dir, "dev.yaml"), filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(dev, []byte(deployment), 0600))
+	require.NoError(t, os.WriteFile(prod, []byte(deployment+`        - name: BAR
+          value: bar
+`), 0600))
+	err := generate(t, "", config.Config{ChartName: appChartName, Environments: []config.Environment{
+		{Name: "dev", Path: dev},
+		{Name: "prod", Path: prod},
+	}})
+	assert.ErrorIs(t, err, ErrEnvTemplateDiff)
+	assert.NoFileExists(t, appChartName+"/Chart.yaml")
+}
+
+func TestEnvironmentsRemovedValues(t *testing.T) {
+	const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      nodeSelector:
+        disk: ssd
+%s
+      containers:
+      - name: web
+        image: nginx:1.25.3
+        resources:
+          limits:
+            cpu: 500m
+%s
+`
+	dir := t.TempDir()
+	dev, prod := filepath.Join(dir, "dev.yaml"), filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(dev, []byte(fmt.Sprintf(deployment, "        zone: a", "            memory: 128Mi")), 0600))
+	require.NoError(t, os.WriteFile(prod, []byte(fmt.Sprintf(deployment, "", "")), 0600))
+	err := generate(t, "", config.Config{ChartName: appChartName, Environments: []config.Environment{
+		{Name: "dev", Path: dev},
+		{Name: "prod", Path: prod},
+	}})
+	require.NoError(t, err)
+
+	prodValues := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(readFile(t, appChartName+"/values-prod.yaml")), &prodValues))
+	assert.Equal(t, map[string]interface{}{
+		"appWeb": map[string]interface{}{
+			"nodeSelector": map[string]interface{}{"zone": nil},
+			"web": map[string]interface{}{
+				"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": nil}},
+			},
+		},
+	}, prodValues)
+	devValues := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(readFile(t, appChartName+"/values-dev.yaml")), &devValues))
+	assert.Empty(t, devValues)
+	lint(t, appChartName, prodValues)
+}
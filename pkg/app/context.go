@@ -1,13 +1,28 @@
 package app
 
 import (
+	"errors"
+	"path"
+	"reflect"
+	"strings"
+
 	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/conversion"
+	"github.com/arttor/helmify/pkg/decoder"
+	"github.com/arttor/helmify/pkg/helm"
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/arttor/helmify/pkg/normalize"
+	"github.com/arttor/helmify/pkg/processor/hook"
+	"github.com/arttor/helmify/pkg/processor/rule"
+	"github.com/arttor/helmify/pkg/script"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// errStopped - returned if processing was interrupted with stop signal.
+var errStopped = errors.New("processing stopped")
+
 // appContext helm processing context. Stores processed objects.
 type appContext struct {
 	processors       []helmify.Processor
@@ -17,6 +32,18 @@ type appContext struct {
 	appMeta          *metadata.Service
 	objects          []*unstructured.Unstructured
 	fileNames        []string
+	// inputs - copies of objects before processing used for chart verification.
+	inputs []*unstructured.Unstructured
+	// script - transforms objects before adding them to the context.
+	script *script.Script
+	// normalizers - remove server-side and tooling noise from objects before transforms.
+	normalizers normalize.Pipeline
+	// excluded - kind/name of input objects left out of the chart by filters and rules.
+	excluded []string
+	// report - conversion results of input objects.
+	report []conversion.Object
+	// inputErrors - invalid input documents reported and skipped while loading.
+	inputErrors decoder.Errors
 }
 
 // New returns context with config set.
@@ -40,56 +67,173 @@ func (c *appContext) WithDefaultProcessor(processor helmify.Processor) *appConte
 	return c
 }
 
+// WithScript adds script transforms and processors to the context and returns it.
+func (c *appContext) WithScript(s *script.Script) *appContext {
+	c.script = s
+	return c.WithProcessors(s.Processors()...)
+}
+
+// WithNormalizers adds input normalization filters to the context and returns it.
+func (c *appContext) WithNormalizers(filters ...normalize.Filter) *appContext {
+	c.normalizers = append(c.normalizers, filters...)
+	return c
+}
+
+// transform - applies context normalizers and script transforms to the object. Returns nil if object is dropped.
+func (c *appContext) transform(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	c.normalizers.Normalize(obj)
+	if c.script == nil {
+		return obj, nil
+	}
+	return c.script.Transform(obj)
+}
+
 // Add k8s object to app context.
 func (c *appContext) Add(obj *unstructured.Unstructured, filename string) {
+	// filters are applied before loading metadata to not affect detected name prefix and namespace.
+	if excluded, reason := c.config.Excluded(obj.GroupVersionKind(), obj.GetName(), obj.GetNamespace(), obj.GetLabels()); excluded {
+		c.exclude(obj, filename, reason)
+		return
+	}
+	if c.appMeta.Rule(obj).Skip {
+		c.exclude(obj, filename, "excluded by config rule")
+		return
+	}
 	// we need to add all objects before start processing only to define app metadata.
 	c.appMeta.Load(obj)
+	if c.config.Verify {
+		c.inputs = append(c.inputs, obj.DeepCopy())
+	}
 	c.objects = append(c.objects, obj)
 	c.fileNames = append(c.fileNames, filename)
 }
 
+// exclude - records input object left out of the chart.
+func (c *appContext) exclude(obj *unstructured.Unstructured, filename, reason string) {
+	logrus.WithFields(logrus.Fields{
+		"ApiVersion": obj.GetAPIVersion(),
+		"Kind":       obj.GetKind(),
+		"Name":       obj.GetName(),
+	}).Infof("Skipping: %s.", reason)
+	c.excluded = append(c.excluded, obj.GetKind()+"/"+obj.GetName())
+	res := reportObject(obj, filename, conversion.StatusExcluded)
+	res.Warnings = []string{reason}
+	c.report = append(c.report, res)
+}
+
+// logExcluded - logs summary of input objects left out of the chart.
+func (c *appContext) logExcluded() {
+	if len(c.excluded) == 0 {
+		return
+	}
+	logrus.WithField("objects", strings.Join(c.excluded, ", ")).Warnf("%d input objects excluded from the chart", len(c.excluded))
+}
+
 // CreateHelm creates helm chart from context k8s objects.
 func (c *appContext) CreateHelm(stop <-chan struct{}) error {
 	logrus.WithFields(logrus.Fields{
 		"ChartName": c.appMeta.ChartName(),
 		"Namespace": c.appMeta.Namespace(),
 	}).Info("creating a chart")
-	var templates []helmify.Template
-	var filenames []string
+	objects, err := c.processAll(stop)
+	if errors.Is(err, errStopped) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	templates := make([]helmify.Template, 0, len(objects))
+	filenames := make([]string, 0, len(objects))
+	sources := make([]helmify.Source, 0, len(objects))
+	for _, o := range objects {
+		templates = append(templates, o.template)
+		filenames = append(filenames, o.filename)
+		sources = append(sources, o.source)
+	}
+	return c.output.Create(c.config, templates, filenames, sources, nil)
+}
+
+// processedObject - k8s object converted into template.
+type processedObject struct {
+	template helmify.Template
+	// filename - chart template file name.
+	filename string
+	source   helmify.Source
+}
+
+// processAll - converts context k8s objects into templates. Skips objects without suitable processor.
+func (c *appContext) processAll(stop <-chan struct{}) ([]processedObject, error) {
+	var res []processedObject
 	for i, obj := range c.objects {
-		template, err := c.process(obj)
+		source := newSource(obj, c.config.TemplatePath(obj.GroupVersionKind().Group, obj.GetKind()))
+		r := c.appMeta.Rule(obj)
+		input := obj
+		if len(r.Values) != 0 {
+			input = obj.DeepCopy()
+		}
+		h, isHook := hook.Extract(c.config.HookMarker, obj)
+		report := reportObject(obj, c.fileNames[i], conversion.StatusSkipped)
+		template, processor, err := c.process(obj)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if template != nil && isHook {
+			template, err = hook.Wrap(c.appMeta, obj, template, h)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if template != nil && (r.ValuesPrefix != "" || len(r.Values) != 0) {
+			template, err = rule.Wrap(c.appMeta, input, template, r)
+			if err != nil {
+				return nil, err
+			}
 		}
 		if template != nil {
-			templates = append(templates, template)
 			filename := template.Filename()
 			if c.fileNames[i] != "" {
 				filename = c.fileNames[i]
 			}
-			filenames = append(filenames, filename)
+			if r.File != "" {
+				filename = r.File
+			}
+			res = append(res, processedObject{template: template, filename: filename, source: source})
+			report.Status = conversion.StatusConverted
+			report.Template = helm.TemplatePath(c.config.Crd, filename)
+			report.Values = conversion.ValuesPaths(template.Values())
+		}
+		report.Processor = processor
+		switch {
+		case processor == "":
+			report.Warnings = append(report.Warnings, "no suitable processor for the kind")
+		case processor == defaultProcessorName && report.Status == conversion.StatusConverted:
+			report.Status = conversion.StatusDefault
+			report.Warnings = append(report.Warnings, "kind is not supported: object is copied into template as is")
 		}
+		c.report = append(c.report, report)
 		select {
 		case <-stop:
-			return nil
+			return nil, errStopped
 		default:
 		}
 	}
-	return c.output.Create(c.config.ChartDir, c.config.ChartName, c.config.Crd, c.config.CertManagerAsSubchart, c.config.CertManagerVersion, c.config.CertManagerInstallCRD, templates, filenames)
+	return res, nil
 }
 
-func (c *appContext) process(obj *unstructured.Unstructured) (helmify.Template, error) {
+// process - converts object with the first processor claimed it. Returns template and name of the processor.
+// Returns empty processor name if there is no suitable processor.
+func (c *appContext) process(obj *unstructured.Unstructured) (helmify.Template, string, error) {
 	for _, p := range c.processors {
 		if processed, result, err := p.Process(c.appMeta, obj); processed {
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			logrus.WithFields(logrus.Fields{
 				"ApiVersion": obj.GetAPIVersion(),
 				"Kind":       obj.GetKind(),
 				"Name":       obj.GetName(),
 			}).Debug("processed")
-			return result, nil
+			return result, processorName(p), nil
 		}
 	}
 	if c.defaultProcessor == nil {
@@ -98,8 +242,67 @@ func (c *appContext) process(obj *unstructured.Unstructured) (helmify.Template,
 			"Kind":       obj.GetKind(),
 			"Name":       obj.GetName(),
 		}).Warn("Skipping: no suitable processor for resource.")
-		return nil, nil
+		return nil, "", nil
 	}
 	_, t, err := c.defaultProcessor.Process(c.appMeta, obj)
-	return t, err
+	return t, defaultProcessorName, err
+}
+
+// defaultProcessorName - name of the processor for unsupported kinds in conversion report.
+const defaultProcessorName = "default"
+
+// processorName - returns processor name for conversion report. Processors can provide their name with Name method,
+// package qualified type name is used otherwise, e.g. "deployment.deployment".
+func processorName(p helmify.Processor) string {
+	if named, ok := p.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	t := reflect.TypeOf(p)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// reportObject - returns conversion report entry of the input object.
+func reportObject(obj *unstructured.Unstructured, filename string, status conversion.Status) conversion.Object {
+	return conversion.Object{
+		File:       filename,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Status:     status,
+	}
+}
+
+// podSpecPaths - known locations of pod spec in workload objects.
+var podSpecPaths = [][]string{
+	{"spec", "template", "spec"},
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// newSource - describes object before processing, because processors are allowed to modify it.
+// templatePath - pod template location configured for custom workload kind.
+func newSource(obj *unstructured.Unstructured, templatePath []string) helmify.Source {
+	res := helmify.Source{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+	}
+	paths := podSpecPaths
+	if strings.Join(templatePath, ".") != "spec.template" {
+		paths = append([][]string{append(templatePath, "spec")}, podSpecPaths...)
+	}
+	for _, path := range paths {
+		for _, key := range []string{"containers", "initContainers"} {
+			containers, _, _ := unstructured.NestedSlice(obj.Object, append(path, key)...)
+			for _, c := range containers {
+				if name, ok := c.(map[string]interface{})["name"].(string); ok {
+					res.Containers = append(res.Containers, name)
+				}
+			}
+		}
+	}
+	return res
 }
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/conversion"
+	"github.com/arttor/helmify/pkg/decoder"
+	"github.com/arttor/helmify/pkg/diff"
+	"github.com/arttor/helmify/pkg/helm"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/kustomize"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ErrEnvTemplateDiff - returned if an object template differs between environments in a way not expressed by values.
+var ErrEnvTemplateDiff = errors.New("template differs between environments")
+
+// envObjects - processed k8s objects of a single environment aligned by objectKey.
+type envObjects struct {
+	name    string
+	objects map[string]processedObject
+	appMeta *metadata.Service
+	inputs  []*unstructured.Unstructured
+}
+
+// createHelmEnvs - creates a single chart from manifests of several environments.
+// Objects are aligned by kind and name without common prefix. Templates are taken from the first environment
+// containing the object. values.yaml contains values of the first environment and values-<env>.yaml contains
+// values which differ from values.yaml. Objects missing in some environments are toggled with values.
+func createHelmEnvs(ctx context.Context, conf config.Config, output helmify.Output) error {
+	var envs []envObjects
+	var keys []string
+	var report []conversion.Object
+	var inputErrs decoder.Errors
+	seen := map[string]bool{}
+	for _, env := range conf.Environments {
+		envConf := conf
+		envConf.Environments = nil
+		if kustomize.IsKustomization(env.Path) {
+			envConf.Kustomization = env.Path
+		} else {
+			envConf.Files = []string{env.Path}
+		}
+		appCtx, err := newContext(ctx, envConf, output)
+		if err != nil {
+			return err
+		}
+		err = load(ctx.Done(), appCtx, nil, envConf)
+		if err != nil {
+			return fmt.Errorf("%w: unable to load %s environment", err, env.Name)
+		}
+		processed, err := appCtx.processAll(ctx.Done())
+		if err != nil {
+			return err
+		}
+		for _, o := range appCtx.report {
+			o.Env = env.Name
+			report = append(report, o)
+		}
+		inputErrs = append(inputErrs, appCtx.inputErrors...)
+		objects := envObjects{name: env.Name, objects: map[string]processedObject{}, appMeta: appCtx.appMeta, inputs: appCtx.inputs}
+		for _, o := range processed {
+			key := objectKey(appCtx.appMeta, o.source)
+			objects.objects[key] = o
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		envs = append(envs, objects)
+	}
+
+	var templates []helmify.Template
+	var filenames []string
+	var sources []helmify.Source
+	envValues := make([]helmify.Values, len(envs))
+	for i := range envValues {
+		envValues[i] = helmify.Values{}
+	}
+	for _, key := range keys {
+		base, baseEnv := pickBase(envs, key)
+		template := base.template
+		missing := false
+		for _, env := range envs {
+			if _, ok := env.objects[key]; !ok {
+				missing = true
+			}
+		}
+		if missing {
+			var err error
+			template, err = newToggledTemplate(envs[baseEnv].appMeta, base, envs[0].objects[key].template != nil)
+			if err != nil {
+				return err
+			}
+		}
+		templates = append(templates, template)
+		filenames = append(filenames, base.filename)
+		sources = append(sources, base.source)
+
+		for i, env := range envs {
+			o, ok := env.objects[key]
+			var err error
+			if ok {
+				err = checkTemplateDiff(envs[baseEnv].name, env.name, base, o)
+				if err != nil {
+					return err
+				}
+				err = envValues[i].Merge(copyValues(o.template.Values()))
+			}
+			if err == nil && missing {
+				err = unstructured.SetNestedField(envValues[i], ok, toggleValuePath(envs[baseEnv].appMeta, base.source)...)
+			}
+			if err != nil {
+				return fmt.Errorf("%w: unable to set %s environment values", err, env.name)
+			}
+		}
+	}
+
+	chartValues := helmify.Values{}
+	for _, t := range templates {
+		err := chartValues.Merge(copyValues(t.Values()))
+		if err != nil {
+			return err
+		}
+	}
+	diffs := make([]helmify.EnvValues, 0, len(envs))
+	for i, env := range envs {
+		diffs = append(diffs, helmify.EnvValues{Env: env.name, Values: chartValues.Diff(envValues[i])})
+	}
+	err := output.Create(conf, templates, filenames, sources, diffs)
+	if err != nil && !errors.Is(err, helm.ErrChangesPending) {
+		return err
+	}
+	pending := err
+	targets := make([]verifyTarget, 0, len(envs))
+	for _, env := range envs {
+		targets = append(targets, verifyTarget{appMeta: env.appMeta, inputs: env.inputs, valuesFile: envValuesFile(env.name)})
+	}
+	err = finish(conf, targets, report, inputErrs)
+	if err != nil {
+		return err
+	}
+	return pending
+}
+
+// objectKey - identifies the same object in different environments.
+func objectKey(appMeta helmify.AppMetadata, source helmify.Source) string {
+	return source.Kind + "/" + appMeta.TrimName(source.Name)
+}
+
+// pickBase - returns object from the first environment containing it.
+func pickBase(envs []envObjects, key string) (processedObject, int) {
+	for i, env := range envs {
+		if o, ok := env.objects[key]; ok {
+			return o, i
+		}
+	}
+	return processedObject{}, -1
+}
+
+// checkTemplateDiff - returns ErrEnvTemplateDiff if environment template differs from the base environment one.
+// Only values differences can be kept in a single chart, other differences would be lost.
+func checkTemplateDiff(baseEnv, env string, base, o processedObject) error {
+	baseBuf, buf := bytes.Buffer{}, bytes.Buffer{}
+	err := base.template.Write(&baseBuf)
+	if err != nil {
+		return err
+	}
+	err = o.template.Write(&buf)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(baseBuf.Bytes(), buf.Bytes()) {
+		return nil
+	}
+	logrus.WithFields(logrus.Fields{
+		"Env":  env,
+		"Kind": o.source.Kind,
+		"Name": o.source.Name,
+	}).Error("template differs from base environment:\n" + diff.Unified(baseEnv, env, baseBuf.String(), buf.String(), 3))
+	return fmt.Errorf("%w: %s %s in %s environment differs from %s environment", ErrEnvTemplateDiff, o.source.Kind, o.source.Name, env, baseEnv)
+}
+
+// toggleValuePath - values path enabling object which is missing in some environments.
+func toggleValuePath(appMeta helmify.AppMetadata, source helmify.Source) []string {
+	return []string{strcase.ToLowerCamel(appMeta.TrimName(source.Name)), strcase.ToLowerCamel(source.Kind), "enabled"}
+}
+
+// toggledTemplate - template rendered only if enabled in values.
+type toggledTemplate struct {
+	helmify.Template
+	values helmify.Values
+	path   []string
+}
+
+func newToggledTemplate(appMeta helmify.AppMetadata, o processedObject, enabled bool) (*toggledTemplate, error) {
+	path := toggleValuePath(appMeta, o.source)
+	values := helmify.Values{}
+	err := unstructured.SetNestedField(values, enabled, path...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to set %v value", err, path)
+	}
+	err = values.Merge(copyValues(o.template.Values()))
+	if err != nil {
+		return nil, err
+	}
+	return &toggledTemplate{Template: o.template, values: values, path: path}, nil
+}
+
+func (t *toggledTemplate) Values() helmify.Values {
+	return t.values
+}
+
+func (t *toggledTemplate) Write(writer io.Writer) error {
+	_, err := fmt.Fprintf(writer, "{{- if .Values.%s }}\n", strings.Join(t.path, "."))
+	if err != nil {
+		return err
+	}
+	err = t.Template.Write(writer)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write([]byte("\n{{- end }}"))
+	return err
+}
+
+// copyValues - deep copies values, because merge reuses nested maps of merged values.
+func copyValues(values helmify.Values) helmify.Values {
+	return copyValue(map[string]interface{}(values)).(map[string]interface{})
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			res[k] = copyValue(val)
+		}
+		return res
+	case helmify.Values:
+		return copyValue(map[string]interface{}(v))
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, val := range v {
+			res[i] = copyValue(val)
+		}
+		return res
+	}
+	return value
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/conversion"
+	"github.com/arttor/helmify/pkg/decoder"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/arttor/helmify/pkg/verify"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ErrVerifyFailed - returned if generated chart does not pass helm lint or,
+// if report is requested, rendered chart does not reproduce input objects.
+var ErrVerifyFailed = errors.New("chart verification failed")
+
+// verifyTarget - input objects of the chart and values file to render the chart with.
+type verifyTarget struct {
+	appMeta    *metadata.Service
+	inputs     []*unstructured.Unstructured
+	valuesFile string
+}
+
+// finish - verifies generated chart if requested and writes conversion report if requested.
+// Conversion report is written even if verification fails.
+func finish(conf config.Config, targets []verifyTarget, objects []conversion.Object, inputErrs decoder.Errors) error {
+	var report verify.Report
+	var err error
+	if conf.Verify {
+		report, err = verifyCharts(conf, targets)
+	}
+	if conf.ConversionReport == "" {
+		return err
+	}
+	if reportErr := writeConversionReport(conf, objects, inputErrs, report); reportErr != nil {
+		return reportErr
+	}
+	return err
+}
+
+// verifyCharts - lints generated chart, renders it and logs input object fields lost or changed by the conversion.
+// Writes verification report if requested. Returns verification results of all targets.
+func verifyCharts(conf config.Config, targets []verifyTarget) (verify.Report, error) {
+	chartPath := filepath.Join(conf.ChartDir, conf.ChartName)
+	var report verify.Report
+	for _, t := range targets {
+		res, err := verify.Chart(verifyOptions(conf, chartPath, t), t.inputs)
+		if err != nil {
+			return report, fmt.Errorf("%w: %v", ErrVerifyFailed, err)
+		}
+		logResult(res)
+		report = append(report, res)
+	}
+	if conf.Report != "" {
+		err := writeReport(conf, report)
+		if err != nil {
+			return report, err
+		}
+		if !report.OK() {
+			return report, ErrVerifyFailed
+		}
+		return report, nil
+	}
+	for _, res := range report {
+		if len(res.LintErrors) != 0 {
+			return report, ErrVerifyFailed
+		}
+	}
+	return report, nil
+}
+
+// verifyOptions - renders chart with release name and namespace reproducing input object names and namespace.
+func verifyOptions(conf config.Config, chartPath string, t verifyTarget) verify.Options {
+	opts := verify.Options{
+		ChartPath:     chartPath,
+		Namespace:     t.appMeta.Namespace(),
+		ValuesFile:    t.valuesFile,
+		TrimName:      t.appMeta.TrimName,
+		IgnoreMarkers: []string{conf.HookMarker},
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	if prefix := t.appMeta.CommonPrefix(); prefix != "" && !conf.OriginalName {
+		opts.Release = prefix
+		opts.Values = map[string]interface{}{"fullnameOverride": prefix}
+	}
+	return opts
+}
+
+func logResult(res verify.Result) {
+	log := logrus.WithField("Chart", res.Chart)
+	if res.ValuesFile != "" {
+		log = log.WithField("Values", res.ValuesFile)
+	}
+	for _, msg := range res.LintWarnings {
+		log.Warn(msg)
+	}
+	for _, msg := range res.LintErrors {
+		log.Error(msg)
+	}
+	findings := 0
+	for _, f := range res.Findings() {
+		if f.Type == verify.Added {
+			log.WithFields(logrus.Fields{"Kind": f.Kind, "Name": f.Name, "Path": f.Path}).Debug(f.String())
+			continue
+		}
+		findings++
+		log.WithFields(logrus.Fields{"Kind": f.Kind, "Name": f.Name, "Path": f.Path}).Warn(f.String())
+	}
+	if findings == 0 && len(res.LintErrors) == 0 {
+		log.Info("chart verified: rendered objects match input")
+	}
+}
+
+func writeReport(conf config.Config, report verify.Report) error {
+	w, err := createReport(conf.Report)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if conf.ReportFormat == config.ReportJSON {
+		err = report.WriteJSON(w)
+	} else {
+		err = report.WriteText(w)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to write report", err)
+	}
+	return nil
+}
+
+// writeConversionReport - writes conversion report. Verification findings are added to warnings of input objects.
+func writeConversionReport(conf config.Config, objects []conversion.Object, inputErrs decoder.Errors, verifyReport verify.Report) error {
+	for _, res := range verifyReport {
+		for _, f := range res.Findings() {
+			if f.Type == verify.Added {
+				continue
+			}
+			for i, o := range objects {
+				if o.Kind == f.Kind && o.Name == f.Name && envValuesFile(o.Env) == res.ValuesFile &&
+					o.Status != conversion.StatusExcluded {
+					objects[i].Warnings = append(objects[i].Warnings, "verification: "+f.String())
+				}
+			}
+		}
+	}
+	report := conversion.New(conf.ChartName, objects)
+	for _, e := range inputErrs {
+		report.InputErrors = append(report.InputErrors, e.Error())
+	}
+	w, err := createReport(conf.ConversionReport)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if conf.ConversionReportFormat == config.ReportYAML {
+		err = report.WriteYAML(w)
+	} else {
+		err = report.WriteJSON(w)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to write conversion report", err)
+	}
+	return nil
+}
+
+// createReport - creates report file. "-" is stdout.
+func createReport(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopCloser{Writer: os.Stdout}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to create report file %s", err, path)
+	}
+	return file, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// envValuesFile - returns values file of the environment. Empty for default values.
+func envValuesFile(env string) string {
+	if env == "" {
+		return ""
+	}
+	return "values-" + env + ".yaml"
+}
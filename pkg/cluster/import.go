@@ -0,0 +1,369 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// errStopped - returned if import was interrupted with stop signal.
+var errStopped = errors.New("cluster import stopped")
+
+// skippedGroups - API groups of objects created by cluster components at runtime.
+var skippedGroups = map[string]bool{
+	"events.k8s.io":       true,
+	"metrics.k8s.io":      true,
+	"coordination.k8s.io": true,
+	"discovery.k8s.io":    true,
+}
+
+// skippedObjects - objects created by k8s in every namespace, keyed by kind/name.
+var skippedObjects = map[string]bool{
+	"ConfigMap/kube-root-ca.crt": true,
+	"ServiceAccount/default":     true,
+}
+
+// skippedSecretTypes - types of secrets created by controllers.
+var skippedSecretTypes = map[string]bool{
+	"kubernetes.io/service-account-token": true,
+	"helm.sh/release.v1":                  true,
+}
+
+// jobLabels - labels added by Job controller to pod template and selector.
+var jobLabels = []string{"controller-uid", "batch.kubernetes.io/controller-uid", "job-name", "batch.kubernetes.io/job-name"}
+
+var (
+	clusterRoleGR        = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}
+	clusterRoleBindingGR = schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}
+	crdGR                = schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}
+)
+
+// Clients - clients of the cluster to import objects from.
+type Clients struct {
+	Dynamic   dynamic.Interface
+	Discovery discovery.DiscoveryInterface
+}
+
+// Connect - returns clients for given kubeconfig and context. Returns namespace of the context.
+// Default kubeconfig loading rules are used if kubeconfig is empty.
+func Connect(kubeconfig, kubeContext string) (Clients, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return Clients{}, "", fmt.Errorf("%w: unable to load kubeconfig", err)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return Clients{}, "", fmt.Errorf("%w: unable to get kubeconfig namespace", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return Clients{}, "", fmt.Errorf("%w: unable to create cluster client", err)
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return Clients{}, "", fmt.Errorf("%w: unable to create cluster discovery client", err)
+	}
+	return Clients{Dynamic: dynamicClient, Discovery: discoveryClient}, namespace, nil
+}
+
+// Import - lists objects of all namespaced kinds in the namespace matching the selector and calls fn for every
+// object sorted by apiVersion, kind and name. Objects created by controllers, like ReplicaSets, Pods, Endpoints
+// and service account token Secrets, are skipped. Fields populated by the server are removed.
+// Related cluster scoped objects are imported if src.ClusterScoped is set.
+func Import(stop <-chan struct{}, clients Clients, src config.Cluster, fn func(obj *unstructured.Unstructured)) error {
+	resources, err := discovery.ServerPreferredResources(clients.Discovery)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return fmt.Errorf("%w: unable to discover cluster resources", err)
+		}
+		logrus.WithError(err).Warn("Some cluster resources are not discovered")
+	}
+	var objects []*unstructured.Unstructured
+	seen := map[types.UID]bool{}
+	clusterScoped := map[schema.GroupResource]schema.GroupVersionResource{}
+	for _, resourceList := range resources {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return fmt.Errorf("%w: unable to parse group version %s", err, resourceList.GroupVersion)
+		}
+		if skippedGroups[gv.Group] {
+			continue
+		}
+		for _, r := range resourceList.APIResources {
+			// subresources and kinds which can't be listed
+			if strings.Contains(r.Name, "/") || !hasVerb(r, "list") || gv.Group == "" && r.Kind == "Event" {
+				continue
+			}
+			gvr := gv.WithResource(r.Name)
+			if !r.Namespaced {
+				clusterScoped[gvr.GroupResource()] = gvr
+				continue
+			}
+			select {
+			case <-stop:
+				return errStopped
+			default:
+			}
+			items, err := list(clients.Dynamic.Resource(gvr).Namespace(src.Namespace), src.Selector, gv.WithKind(r.Kind))
+			if err != nil {
+				return err
+			}
+			for _, obj := range items {
+				if uid := obj.GetUID(); uid != "" {
+					if seen[uid] {
+						// same object served by several API groups
+						continue
+					}
+					seen[uid] = true
+				}
+				objects = append(objects, obj)
+			}
+		}
+	}
+	objects = skipGenerated(objects)
+	if src.ClusterScoped {
+		related, err := relatedClusterScoped(clients, clusterScoped, src.Namespace, objects)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, related...)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		a, b := objects[i], objects[j]
+		if a.GetAPIVersion() != b.GetAPIVersion() {
+			return a.GetAPIVersion() < b.GetAPIVersion()
+		}
+		if a.GetKind() != b.GetKind() {
+			return a.GetKind() < b.GetKind()
+		}
+		return a.GetName() < b.GetName()
+	})
+	for _, obj := range objects {
+		clean(obj)
+		fn(obj)
+	}
+	return nil
+}
+
+// list - returns objects of the resource. Resources not allowed to list are skipped with a warning.
+func list(client dynamic.ResourceInterface, selector string, gvk schema.GroupVersionKind) ([]*unstructured.Unstructured, error) {
+	res, err := client.List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+			logrus.WithError(err).Warnf("Skipping %s: unable to list", gvk.Kind)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: unable to list %s", err, gvk.Kind)
+	}
+	objects := make([]*unstructured.Unstructured, 0, len(res.Items))
+	for i := range res.Items {
+		obj := &res.Items[i]
+		obj.SetGroupVersionKind(gvk)
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// skipGenerated - returns objects without objects created by controllers.
+func skipGenerated(objects []*unstructured.Unstructured) []*unstructured.Unstructured {
+	// Endpoints are created by endpoints controller for services with selector only
+	services := map[string]bool{}
+	for _, obj := range objects {
+		if obj.GroupVersionKind().Group != "" || obj.GetKind() != "Service" {
+			continue
+		}
+		if selector, _, _ := unstructured.NestedMap(obj.Object, "spec", "selector"); len(selector) != 0 {
+			services[obj.GetName()] = true
+		}
+	}
+	res := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		if generated(obj, services) {
+			logrus.WithFields(logrus.Fields{
+				"ApiVersion": obj.GetAPIVersion(),
+				"Kind":       obj.GetKind(),
+				"Name":       obj.GetName(),
+			}).Debug("Skipping object created by controller")
+			continue
+		}
+		res = append(res, obj)
+	}
+	return res
+}
+
+func generated(obj *unstructured.Unstructured, services map[string]bool) bool {
+	if metav1.GetControllerOfNoCopy(obj) != nil || skippedObjects[obj.GetKind()+"/"+obj.GetName()] {
+		return true
+	}
+	if obj.GroupVersionKind().Group != "" {
+		return false
+	}
+	switch obj.GetKind() {
+	case "Endpoints":
+		// created by endpoints controller for services with selector
+		return services[obj.GetName()]
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return skippedSecretTypes[secretType]
+	}
+	return false
+}
+
+// relatedClusterScoped - returns ClusterRoleBindings bound to service accounts of the namespace, ClusterRoles
+// referenced by bindings and CustomResourceDefinitions of imported custom resources.
+func relatedClusterScoped(clients Clients, resources map[schema.GroupResource]schema.GroupVersionResource, namespace string, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	serviceAccounts := map[string]bool{}
+	roles := map[string]bool{}
+	kinds := map[schema.GroupKind]bool{}
+	for _, obj := range objects {
+		kinds[obj.GroupVersionKind().GroupKind()] = true
+		switch obj.GetKind() {
+		case "ServiceAccount":
+			serviceAccounts[obj.GetName()] = true
+		case "RoleBinding":
+			if kind, _, _ := unstructured.NestedString(obj.Object, "roleRef", "kind"); kind == "ClusterRole" {
+				name, _, _ := unstructured.NestedString(obj.Object, "roleRef", "name")
+				roles[name] = true
+			}
+		}
+	}
+	var res []*unstructured.Unstructured
+	if gvr, ok := resources[clusterRoleBindingGR]; ok {
+		bindings, err := list(clients.Dynamic.Resource(gvr), "", gvr.GroupVersion().WithKind("ClusterRoleBinding"))
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bindings {
+			if !bindsServiceAccount(b, namespace, serviceAccounts) {
+				continue
+			}
+			res = append(res, b)
+			name, _, _ := unstructured.NestedString(b.Object, "roleRef", "name")
+			roles[name] = true
+		}
+	}
+	if gvr, ok := resources[clusterRoleGR]; ok && len(roles) != 0 {
+		clusterRoles, err := list(clients.Dynamic.Resource(gvr), "", gvr.GroupVersion().WithKind("ClusterRole"))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range clusterRoles {
+			// default roles like admin, edit or view are created by API server
+			if roles[r.GetName()] && r.GetLabels()["kubernetes.io/bootstrapping"] != "rbac-defaults" {
+				res = append(res, r)
+			}
+		}
+	}
+	if gvr, ok := resources[crdGR]; ok {
+		crds, err := list(clients.Dynamic.Resource(gvr), "", gvr.GroupVersion().WithKind("CustomResourceDefinition"))
+		if err != nil {
+			return nil, err
+		}
+		for _, crd := range crds {
+			group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+			kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+			if kinds[schema.GroupKind{Group: group, Kind: kind}] {
+				res = append(res, crd)
+			}
+		}
+	}
+	return res, nil
+}
+
+// bindsServiceAccount - returns true if binding subjects contain imported service account of the namespace.
+func bindsServiceAccount(binding *unstructured.Unstructured, namespace string, serviceAccounts map[string]bool) bool {
+	subjects, _, _ := unstructured.NestedSlice(binding.Object, "subjects")
+	for _, s := range subjects {
+		subject, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if subject["kind"] == "ServiceAccount" && subject["namespace"] == namespace {
+			if name, _ := subject["name"].(string); serviceAccounts[name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clean - removes fields populated by k8s controllers for objects deployed to a cluster. Generic server fields,
+// status and kubectl annotations are removed by input normalization filters.
+func clean(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "metadata", "ownerReferences")
+	if obj.GroupVersionKind().Group == "batch" && obj.GetKind() == "Job" {
+		cleanJob(obj)
+	}
+	if obj.GroupVersionKind().Group != "" {
+		return
+	}
+	switch obj.GetKind() {
+	case "Service":
+		if clusterIP, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); clusterIP != "None" {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	case "ServiceAccount":
+		cleanServiceAccount(obj)
+	}
+}
+
+// cleanJob - removes selector and labels generated by Job controller unless selector was set manually.
+func cleanJob(obj *unstructured.Unstructured) {
+	if manual, _, _ := unstructured.NestedBool(obj.Object, "spec", "manualSelector"); manual {
+		return
+	}
+	unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+	for _, l := range jobLabels {
+		unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", l)
+	}
+}
+
+// cleanServiceAccount - removes references to token secrets generated for the service account.
+func cleanServiceAccount(obj *unstructured.Unstructured) {
+	secrets, _, _ := unstructured.NestedSlice(obj.Object, "secrets")
+	if len(secrets) == 0 {
+		return
+	}
+	res := make([]interface{}, 0, len(secrets))
+	for _, s := range secrets {
+		if ref, ok := s.(map[string]interface{}); ok {
+			if name, _ := ref["name"].(string); strings.HasPrefix(name, obj.GetName()+"-token-") {
+				continue
+			}
+		}
+		res = append(res, s)
+	}
+	if len(res) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "secrets")
+		return
+	}
+	_ = unstructured.SetNestedSlice(obj.Object, res, "secrets")
+}
+
+func hasVerb(r metav1.APIResource, verb string) bool {
+	for _, v := range r.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
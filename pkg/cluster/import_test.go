@@ -0,0 +1,282 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+const (
+	deploymentYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: my-ns
+  uid: 8a2c1f3e-1
+  resourceVersion: "42"
+  generation: 3
+  creationTimestamp: "2020-01-01T00:00:00Z"
+  labels:
+    app: web
+  annotations:
+    deployment.kubernetes.io/revision: "3"
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+status:
+  replicas: 1`
+	replicaSetYaml = `apiVersion: apps/v1
+kind: ReplicaSet
+metadata:
+  name: web-5d4f
+  namespace: my-ns
+  labels:
+    app: web
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: Deployment
+    name: web
+    uid: 8a2c1f3e-1
+    controller: true`
+	serviceYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: my-ns
+  labels:
+    app: web
+spec:
+  clusterIP: 10.0.0.12
+  clusterIPs:
+  - 10.0.0.12
+  selector:
+    app: web
+  ports:
+  - port: 80`
+	endpointsYaml = `apiVersion: v1
+kind: Endpoints
+metadata:
+  name: web
+  namespace: my-ns
+  labels:
+    app: web`
+	externalServiceYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: my-ns
+  labels:
+    app: web
+spec:
+  ports:
+  - port: 5432`
+	externalEndpointsYaml = `apiVersion: v1
+kind: Endpoints
+metadata:
+  name: db
+  namespace: my-ns
+  labels:
+    app: web
+subsets:
+- addresses:
+  - ip: 10.0.1.5
+  ports:
+  - port: 5432`
+	tokenSecretYaml = `apiVersion: v1
+kind: Secret
+metadata:
+  name: web-token-abcde
+  namespace: my-ns
+  labels:
+    app: web
+type: kubernetes.io/service-account-token`
+	serviceAccountYaml = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: my-ns
+  labels:
+    app: web
+secrets:
+- name: web-token-abcde`
+	otherAppYaml = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+  namespace: my-ns
+  labels:
+    app: other`
+	widgetYaml = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: web
+  namespace: my-ns
+  labels:
+    app: web`
+	clusterRoleBindingYaml = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: web
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: web
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: my-ns`
+	otherBindingYaml = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: other
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: other
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: other-ns`
+	clusterRoleYaml = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: web
+rules:
+- apiGroups: [""]
+  resources: [pods]
+  verbs: [get]`
+	crdYaml = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced`
+)
+
+func fakeClients(objects ...string) Clients {
+	listKinds := map[schema.GroupVersionResource]string{}
+	resources := map[string][]metav1.APIResource{}
+	for _, r := range []struct {
+		gvr        schema.GroupVersionResource
+		kind       string
+		namespaced bool
+	}{
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "services"}, kind: "Service", namespaced: true},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}, kind: "Endpoints", namespaced: true},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, kind: "Secret", namespaced: true},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, kind: "ConfigMap", namespaced: true},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, kind: "ServiceAccount", namespaced: true},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, kind: "Namespace"},
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, kind: "Deployment", namespaced: true},
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, kind: "ReplicaSet", namespaced: true},
+		{gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, kind: "ClusterRole"},
+		{gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, kind: "ClusterRoleBinding"},
+		{gvr: schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, kind: "CustomResourceDefinition"},
+		{gvr: schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}, kind: "Widget", namespaced: true},
+	} {
+		listKinds[r.gvr] = r.kind + "List"
+		gv := r.gvr.GroupVersion().String()
+		resources[gv] = append(resources[gv], metav1.APIResource{
+			Name:       r.gvr.Resource,
+			Kind:       r.kind,
+			Namespaced: r.namespaced,
+			Verbs:      metav1.Verbs{"get", "list"},
+		})
+	}
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	for gv, r := range resources {
+		disc.Resources = append(disc.Resources, &metav1.APIResourceList{GroupVersion: gv, APIResources: r})
+	}
+	objs := make([]runtime.Object, 0, len(objects))
+	for _, o := range objects {
+		objs = append(objs, internal.GenerateObj(o))
+	}
+	return Clients{
+		Dynamic:   fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...),
+		Discovery: disc,
+	}
+}
+
+func importNames(t *testing.T, clients Clients, src config.Cluster) ([]string, map[string]*unstructured.Unstructured) {
+	t.Helper()
+	var names []string
+	objects := map[string]*unstructured.Unstructured{}
+	err := Import(make(chan struct{}), clients, src, func(obj *unstructured.Unstructured) {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+		objects[obj.GetKind()+"/"+obj.GetName()] = obj
+	})
+	require.NoError(t, err)
+	return names, objects
+}
+
+func TestImport(t *testing.T) {
+	clients := fakeClients(deploymentYaml, replicaSetYaml, serviceYaml, endpointsYaml, tokenSecretYaml,
+		serviceAccountYaml, otherAppYaml, widgetYaml, clusterRoleBindingYaml, otherBindingYaml, clusterRoleYaml, crdYaml)
+
+	t.Run("namespace", func(t *testing.T) {
+		names, objects := importNames(t, clients, config.Cluster{Namespace: "my-ns"})
+		assert.Equal(t, []string{"Deployment/web", "Widget/web", "ConfigMap/other", "Service/web", "ServiceAccount/web"}, names)
+
+		// server fields and status are left to input normalization filters
+		depl := objects["Deployment/web"]
+		assert.EqualValues(t, "8a2c1f3e-1", depl.GetUID())
+		assert.Contains(t, depl.GetAnnotations(), "kubectl.kubernetes.io/last-applied-configuration")
+		assert.Contains(t, depl.Object, "status")
+
+		_, found, _ := unstructured.NestedFieldNoCopy(objects["Service/web"].Object, "spec", "clusterIP")
+		assert.False(t, found)
+		assert.NotContains(t, objects["ServiceAccount/web"].Object, "secrets")
+	})
+	t.Run("selector", func(t *testing.T) {
+		names, _ := importNames(t, clients, config.Cluster{Namespace: "my-ns", Selector: "app=web"})
+		assert.Equal(t, []string{"Deployment/web", "Widget/web", "Service/web", "ServiceAccount/web"}, names)
+	})
+	t.Run("cluster scoped", func(t *testing.T) {
+		names, _ := importNames(t, clients, config.Cluster{Namespace: "my-ns", Selector: "app=web", ClusterScoped: true})
+		assert.Equal(t, []string{
+			"CustomResourceDefinition/widgets.example.com",
+			"Deployment/web",
+			"Widget/web",
+			"ClusterRole/web",
+			"ClusterRoleBinding/web",
+			"Service/web",
+			"ServiceAccount/web",
+		}, names)
+	})
+	t.Run("service without selector", func(t *testing.T) {
+		names, _ := importNames(t, fakeClients(serviceYaml, endpointsYaml, externalServiceYaml, externalEndpointsYaml),
+			config.Cluster{Namespace: "my-ns"})
+		assert.Equal(t, []string{"Endpoints/db", "Service/db", "Service/web"}, names)
+	})
+	t.Run("stopped", func(t *testing.T) {
+		stop := make(chan struct{})
+		close(stop)
+		err := Import(stop, clients, config.Cluster{Namespace: "my-ns"}, func(*unstructured.Unstructured) {})
+		assert.ErrorIs(t, err, errStopped)
+	})
+}
@@ -2,14 +2,24 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // defaultChartName - default name for a helm chart directory.
 const defaultChartName = "chart"
 
+// Verification report formats.
+const (
+	ReportText = "text"
+	ReportJSON = "json"
+	ReportYAML = "yaml"
+)
+
 // Config for Helmify application.
 type Config struct {
 	// ChartName name of the Helm chart and its base directory where Chart.yaml is located.
@@ -25,8 +35,11 @@ type Config struct {
 	// ImagePullSecrets flag
 	ImagePullSecrets bool
 	// GenerateDefaults enables the generation of empty values placeholders for common customization options of helm chart
-	// current generated values: tolerances, node selectors, topology constraints
+	// current generated values: affinity, tolerations, node selectors, topology constraints, pod annotations and labels,
+	// priority class name, extra containers, env, volumes and volume mounts. Empty values are not rendered.
 	GenerateDefaults bool
+	// GenerateAutoscaling adds disabled by default HorizontalPodAutoscaler for Deployments and StatefulSets without one.
+	GenerateAutoscaling bool
 	// CertManagerAsSubchart enables the generation of a subchart for cert-manager
 	CertManagerAsSubchart bool
 	// CertManagerVersion sets cert-manager version in dependency
@@ -37,6 +50,13 @@ type Config struct {
 	Files []string
 	// FilesRecursively read Files recursively
 	FilesRecursively bool
+	// Kustomization - directory with kustomization to build k8s manifests from
+	Kustomization string
+	// Cluster - live cluster namespace to import objects from instead of manifests.
+	Cluster *Cluster
+	// Environments - manifests of the same app for different environments.
+	// Chart templates are generated from the first environment. Differing values are written into values-<env>.yaml.
+	Environments []Environment
 	// OriginalName retains Kubernetes resource's original name
 	OriginalName bool
 	// PreserveNs retains the namespaces on the Kubernetes manifests
@@ -45,6 +65,80 @@ type Config struct {
 	AddWebhookOption bool
 	// OptionalCRDs - Enable optional CRD installation through values.
 	OptionalCRDs bool
+	// GenerateSchema enables the generation of values.schema.json alongside values.yaml
+	GenerateSchema bool
+	// Merge preserves local changes of generated files with three-way merge instead of overwriting them
+	Merge bool
+	// DryRun builds chart in memory and prints its files to stdout instead of writing them into chart directory.
+	DryRun bool
+	// Diff prints unified diff of chart directory and generated chart instead of writing it. Enables DryRun.
+	Diff bool
+	// Verify lints generated chart, renders it with default values and compares rendered objects with input objects
+	Verify bool
+	// Report - file to write verification report to. "-" writes report to stdout. Enables Verify.
+	Report string
+	// ReportFormat - verification report format: text or json.
+	ReportFormat string
+	// ConversionReport - file to write conversion report to. "-" writes report to stdout.
+	ConversionReport string
+	// ConversionReportFormat - conversion report format: json or yaml.
+	ConversionReportFormat string
+	// ValuesDocs adds comments describing origin and meaning of each key to values.yaml
+	ValuesDocs bool
+	// HookMarker - annotation or label marking objects to be converted into Helm hooks.
+	// Default marker is used if empty.
+	HookMarker string
+	// Plugins - external processor plugin executables. Plugins process objects of claimed kinds ahead of
+	// built-in processors.
+	Plugins []string
+	// PluginDir - directory with external processor plugin executables.
+	PluginDir string
+	// PluginTimeout - time limit of a single plugin call. Default is used if not set.
+	PluginTimeout time.Duration
+	// Script - Starlark script with object transforms and processors.
+	Script string
+	// Rules - per-object conversion rules from project config file.
+	Rules []Rule
+	// Workloads - custom workload kinds with pod template at non-default path.
+	// Objects of any kind with pod template at spec.template are processed as workloads without configuration.
+	Workloads []Workload
+	// Strict fails the run if input documents can't be read, decoded or don't match schema of their kind.
+	// Invalid documents are reported and skipped otherwise.
+	Strict bool
+	// AllowUnknownFields - fields unknown to the supported Kubernetes API version don't fail the run in strict mode.
+	// Such fields may belong to a newer Kubernetes version.
+	AllowUnknownFields bool
+	// Include - filters of input objects to add to the chart. All objects are added if empty.
+	Include []Filter
+	// Exclude - filters of input objects to leave out of the chart. Exclude filters take precedence over Include.
+	Exclude []Filter
+	// SkipNormalize - names of input normalization filters to disable: server-fields, status, last-applied.
+	SkipNormalize []string
+	// RemoveDefaults - removes input fields equal to values defaulted by k8s API server.
+	RemoveDefaults bool
+}
+
+// Environment - labelled set of k8s manifests.
+type Environment struct {
+	// Name - environment name used in values file name: values-<name>.yaml
+	Name string
+	// Path - kustomization directory, directory or file with k8s manifests.
+	Path string
+}
+
+// Cluster - objects imported from a live cluster.
+type Cluster struct {
+	// Kubeconfig - path to kubeconfig file. Default loading rules are used if empty.
+	Kubeconfig string
+	// Context - kubeconfig context. Current context is used if empty.
+	Context string
+	// Namespace - namespace to import objects from. Namespace of kubeconfig context is used if empty.
+	Namespace string
+	// Selector - label selector of imported namespaced objects, e.g. "app=web".
+	Selector string
+	// ClusterScoped - also import ClusterRoles and ClusterRoleBindings bound to namespace service accounts
+	// and CustomResourceDefinitions of imported custom resources.
+	ClusterScoped bool
 }
 
 func (c *Config) Validate() error {
@@ -59,5 +153,63 @@ func (c *Config) Validate() error {
 		}
 		return fmt.Errorf("invalid chart name %s", c.ChartName)
 	}
+	switch c.ReportFormat {
+	case "":
+		c.ReportFormat = ReportText
+	case ReportText, ReportJSON:
+	default:
+		return fmt.Errorf("invalid report format %q: expected %s or %s", c.ReportFormat, ReportText, ReportJSON)
+	}
+	if c.Report != "" {
+		c.Verify = true
+	}
+	if c.Diff {
+		c.DryRun = true
+	}
+	if c.DryRun && c.Verify {
+		return fmt.Errorf("dry-run can't be used with chart verification")
+	}
+	switch c.ConversionReportFormat {
+	case "":
+		c.ConversionReportFormat = ReportJSON
+	case ReportJSON, ReportYAML:
+	default:
+		return fmt.Errorf("invalid conversion report format %q: expected %s or %s", c.ConversionReportFormat, ReportJSON, ReportYAML)
+	}
+	envs := map[string]struct{}{}
+	for _, env := range c.Environments {
+		if errs := validation.IsDNS1123Label(env.Name); len(errs) != 0 {
+			return fmt.Errorf("invalid environment name %q: %s", env.Name, strings.Join(errs, ", "))
+		}
+		if _, ok := envs[env.Name]; ok {
+			return fmt.Errorf("duplicate environment %q", env.Name)
+		}
+		envs[env.Name] = struct{}{}
+	}
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return fmt.Errorf("%w: rule %d", err, i)
+		}
+	}
+	if c.Cluster != nil && c.Cluster.Selector != "" {
+		if _, err := labels.Parse(c.Cluster.Selector); err != nil {
+			return fmt.Errorf("%w: invalid cluster selector", err)
+		}
+	}
+	for i := range c.Include {
+		if err := c.Include[i].validate(); err != nil {
+			return fmt.Errorf("%w: include filter %d", err, i)
+		}
+	}
+	for i := range c.Exclude {
+		if err := c.Exclude[i].validate(); err != nil {
+			return fmt.Errorf("%w: exclude filter %d", err, i)
+		}
+	}
+	for _, w := range c.Workloads {
+		if err := w.validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestConfig_Validate(t *testing.T) {
@@ -47,4 +48,139 @@ func TestConfig_Validate(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "test", c.ChartName)
 	})
+	t.Run("environments", func(t *testing.T) {
+		c := &Config{Environments: []Environment{{Name: "dev"}, {Name: "prod"}}}
+		assert.NoError(t, c.Validate())
+		c = &Config{Environments: []Environment{{Name: "dev"}, {Name: "dev"}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Environments: []Environment{{Name: "Dev/1"}}}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("report", func(t *testing.T) {
+		c := &Config{Report: "-"}
+		assert.NoError(t, c.Validate())
+		assert.True(t, c.Verify)
+		assert.Equal(t, ReportText, c.ReportFormat)
+		c = &Config{ReportFormat: "xml"}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("dry-run", func(t *testing.T) {
+		c := &Config{Diff: true}
+		assert.NoError(t, c.Validate())
+		assert.True(t, c.DryRun)
+		c = &Config{DryRun: true, Report: "-"}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("rules", func(t *testing.T) {
+		c := &Config{Rules: []Rule{{Kind: "Secret", Selector: "app=web", File: "secrets/web.yaml", ValuesPrefix: "web.secrets"}}}
+		assert.NoError(t, c.Validate())
+		c = &Config{Rules: []Rule{{Selector: "app in ("}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Rules: []Rule{{File: "../web.yaml"}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Rules: []Rule{{ValuesPrefix: "web..secrets"}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Rules: []Rule{{Values: []ValueRule{{Path: "spec.replicas"}}}}}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("cluster", func(t *testing.T) {
+		c := &Config{Cluster: &Cluster{Namespace: "web", Selector: "app=web"}}
+		assert.NoError(t, c.Validate())
+		c = &Config{Cluster: &Cluster{Selector: "app in ("}}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("filters", func(t *testing.T) {
+		c := &Config{Include: []Filter{{Kind: "*.apps", Name: "^web", Selector: "app in (web)", Namespace: "my-*"}}}
+		assert.NoError(t, c.Validate())
+		c = &Config{Include: []Filter{{Name: "web("}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Exclude: []Filter{{Kind: "[Secret"}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Exclude: []Filter{{Selector: "app in web"}}}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("workloads", func(t *testing.T) {
+		c := &Config{Workloads: []Workload{{Kind: "Rollout"}, {Kind: "CloneSet", Group: "apps.kruise.io", TemplatePath: "spec.workload.template"}}}
+		assert.NoError(t, c.Validate())
+		c = &Config{Workloads: []Workload{{Group: "argoproj.io"}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Workloads: []Workload{{Kind: "Rollout", TemplatePath: "template"}}}
+		assert.Error(t, c.Validate())
+		c = &Config{Workloads: []Workload{{Kind: "Rollout", TemplatePath: "spec..template"}}}
+		assert.Error(t, c.Validate())
+	})
+}
+
+func TestConfig_TemplatePath(t *testing.T) {
+	c := &Config{Workloads: []Workload{
+		{Kind: "CloneSet", Group: "apps.kruise.io", TemplatePath: "spec.workload.template"},
+		{Kind: "Rollout"},
+	}}
+	assert.Equal(t, []string{"spec", "workload", "template"}, c.TemplatePath("apps.kruise.io", "CloneSet"))
+	assert.Equal(t, []string{"spec", "template"}, c.TemplatePath("example.com", "CloneSet"))
+	assert.Equal(t, []string{"spec", "template"}, c.TemplatePath("argoproj.io", "Rollout"))
+}
+
+func TestConfig_Rule(t *testing.T) {
+	c := Config{Rules: []Rule{
+		{Kind: "Secret", PreserveNs: true, File: "secrets.yaml"},
+		{Name: "*-ca", Selector: "app=web", Skip: true},
+		{Kind: "Secret", Name: "web-*", File: "web.yaml", Values: []ValueRule{{Path: "type", Value: "web.type"}}},
+	}}
+	assert.Equal(t, Rule{PreserveNs: true, File: "secrets.yaml"}, c.Rule("Secret", "db", nil))
+	assert.Equal(t, Rule{PreserveNs: true, File: "secrets.yaml"}, c.Rule("Secret", "db-ca", map[string]string{"app": "db"}))
+	assert.Equal(t, Rule{PreserveNs: true, Skip: true, File: "web.yaml", Values: []ValueRule{{Path: "type", Value: "web.type"}}},
+		c.Rule("Secret", "web-ca", map[string]string{"app": "web"}))
+	assert.Equal(t, Rule{}, c.Rule("ConfigMap", "db", nil))
+	t.Run("validated", func(t *testing.T) {
+		assert.NoError(t, c.Validate())
+		assert.NotNil(t, c.Rules[1].selector)
+		assert.True(t, c.Rule("Secret", "web-ca", map[string]string{"app": "web"}).Skip)
+		assert.False(t, c.Rule("Secret", "db-ca", map[string]string{"app": "db"}).Skip)
+	})
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("kind=Secret;name=^dev-;selector=env in (dev,test),app=web;namespace=my-ns")
+	assert.NoError(t, err)
+	assert.Equal(t, Filter{Kind: "Secret", Name: "^dev-", Selector: "env in (dev,test),app=web", Namespace: "my-ns"}, f)
+	assert.Equal(t, "kind=Secret;name=^dev-;selector=env in (dev,test),app=web;namespace=my-ns", f.String())
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	assert.True(t, f.Matches(secret, "dev-creds", "my-ns", map[string]string{"env": "dev", "app": "web"}))
+	assert.False(t, f.Matches(secret, "prod-creds", "my-ns", map[string]string{"env": "dev", "app": "web"}))
+	assert.False(t, f.Matches(secret, "dev-creds", "my-ns", map[string]string{"env": "prod", "app": "web"}))
+	_, err = ParseFilter("type=Opaque")
+	assert.Error(t, err)
+	_, err = ParseFilter(";")
+	assert.Error(t, err)
+}
+
+func TestConfig_Excluded(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	certificate := schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+	c := &Config{Exclude: []Filter{{Kind: "Secret", Name: "^dev-"}, {Kind: "*.cert-manager.io"}, {Selector: "fixture"}}}
+	assert.NoError(t, c.Validate())
+	excluded, reason := c.Excluded(secret, "dev-creds", "my-ns", nil)
+	assert.True(t, excluded)
+	assert.Equal(t, `matched by exclude filter "kind=Secret;name=^dev-"`, reason)
+	excluded, _ = c.Excluded(secret, "creds", "my-ns", nil)
+	assert.False(t, excluded)
+	excluded, _ = c.Excluded(certificate, "web", "my-ns", nil)
+	assert.True(t, excluded)
+	excluded, _ = c.Excluded(deployment, "web", "my-ns", map[string]string{"fixture": "true"})
+	assert.True(t, excluded)
+
+	c = &Config{Include: []Filter{{Kind: "Deployment.v1.apps", Namespace: "my-*"}, {Kind: "Secret.v1"}}, Exclude: []Filter{{Name: "^test-"}}}
+	assert.NoError(t, c.Validate())
+	excluded, _ = c.Excluded(deployment, "web", "my-ns", nil)
+	assert.False(t, excluded)
+	excluded, _ = c.Excluded(secret, "creds", "", nil)
+	assert.False(t, excluded)
+	excluded, reason = c.Excluded(deployment, "web", "other", nil)
+	assert.True(t, excluded)
+	assert.Equal(t, "not matched by include filters", reason)
+	excluded, _ = c.Excluded(secret, "test-creds", "my-ns", nil)
+	assert.True(t, excluded)
 }
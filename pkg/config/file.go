@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// FileName - name of the project config file discovered next to the chart directory.
+	FileName = "helmify.yaml"
+	// FileAPIVersion - supported project config file version.
+	FileAPIVersion = "helmify.io/v1alpha1"
+	// FileKind - project config file kind.
+	FileKind = "Config"
+)
+
+// File - project config file. Example:
+//
+//	apiVersion: helmify.io/v1alpha1
+//	kind: Config
+//	options:
+//	  crd-dir: true
+//	  f: [./config/manifests]
+//	exclude:
+//	  - kind: Secret
+//	    name: ^dev-
+//	rules:
+//	  - kind: ClusterRole
+//	    name: manager-role
+//	    originalName: true
+type File struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Options - command-line flags by flag name. List options are set once per item.
+	// Flags passed on command line take precedence over options.
+	Options map[string]interface{} `json:"options,omitempty"`
+	// Include - filters of input objects to add to the chart.
+	Include []Filter `json:"include,omitempty"`
+	// Exclude - filters of input objects to leave out of the chart.
+	Exclude []Filter `json:"exclude,omitempty"`
+	// Rules - per-object conversion rules.
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// LoadFile - reads and validates project config file.
+func LoadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("%w: unable to read config file", err)
+	}
+	var res File
+	err = yaml.UnmarshalStrict(data, &res)
+	if err != nil {
+		return File{}, fmt.Errorf("%w: unable to parse config file %s", err, path)
+	}
+	if res.APIVersion != FileAPIVersion || res.Kind != FileKind {
+		return File{}, fmt.Errorf("unsupported config file %s: expected apiVersion %s and kind %s", path, FileAPIVersion, FileKind)
+	}
+	return res, nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), FileName)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	t.Run("valid", func(t *testing.T) {
+		file, err := LoadFile(write(t, `apiVersion: helmify.io/v1alpha1
+kind: Config
+options:
+  crd-dir: true
+  f: [a.yaml, b.yaml]
+exclude:
+  - kind: Secret
+    name: ^dev-
+rules:
+  - kind: ClusterRole
+    name: manager-role
+    originalName: true
+  - selector: app=web
+    valuesPrefix: web
+    values:
+      - path: spec.template.spec.containers[name=web].ports
+        value: web.ports
+`))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"crd-dir": true, "f": []interface{}{"a.yaml", "b.yaml"}}, file.Options)
+		assert.Equal(t, []Rule{
+			{Kind: "ClusterRole", Name: "manager-role", OriginalName: true},
+			{Selector: "app=web", ValuesPrefix: "web", Values: []ValueRule{{Path: "spec.template.spec.containers[name=web].ports", Value: "web.ports"}}},
+		}, file.Rules)
+		assert.Equal(t, []Filter{{Kind: "Secret", Name: "^dev-"}}, file.Exclude)
+	})
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := LoadFile(write(t, "apiVersion: helmify.io/v2\nkind: Config\n"))
+		assert.Error(t, err)
+	})
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := LoadFile(write(t, "apiVersion: helmify.io/v1alpha1\nkind: Config\nrules:\n- kind: Secret\n  skipped: true\n"))
+		assert.Error(t, err)
+	})
+	t.Run("not found", func(t *testing.T) {
+		_, err := LoadFile(filepath.Join(t.TempDir(), FileName))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Filter - selects input objects by kind, name, labels and namespace. Empty fields match any object.
+// Name and selector are compiled once by Config.Validate, unvalidated filters compile them on each match.
+type Filter struct {
+	// Kind - glob of object kind with optional API version and group: <Kind>[.<version>][.<group>],
+	// e.g. "Secret", "*.cert-manager.io" or "Deployment.v1.apps".
+	Kind string `json:"kind,omitempty"`
+	// Name - regular expression matching object name, e.g. "^test-".
+	Name string `json:"name,omitempty"`
+	// Selector - label selector, e.g. "env in (dev,test)".
+	Selector string `json:"selector,omitempty"`
+	// Namespace - glob of object namespace. Cluster-scoped objects have empty namespace.
+	Namespace string `json:"namespace,omitempty"`
+
+	name     *regexp.Regexp
+	selector labels.Selector
+}
+
+// ParseFilter - parses filter from semicolon separated key=value pairs, e.g. "kind=Secret;name=^dev-".
+func ParseFilter(s string) (Filter, error) {
+	var res Filter
+	for _, part := range strings.Split(s, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		switch strings.TrimSpace(key) {
+		case "kind":
+			res.Kind = value
+		case "name":
+			res.Name = value
+		case "selector":
+			res.Selector = value
+		case "namespace":
+			res.Namespace = value
+		default:
+			return Filter{}, fmt.Errorf("invalid filter %q: unknown key %q, expected kind, name, selector or namespace", s, key)
+		}
+	}
+	if res.Kind == "" && res.Name == "" && res.Selector == "" && res.Namespace == "" {
+		return Filter{}, fmt.Errorf("invalid filter %q: at least one of kind, name, selector or namespace is required", s)
+	}
+	return res, nil
+}
+
+// Matches returns true if object with given GVK, name, namespace and labels is selected by the filter.
+func (f Filter) Matches(gvk schema.GroupVersionKind, name, namespace string, objLabels map[string]string) bool {
+	if f.Kind != "" && !matchesKind(f.Kind, gvk) {
+		return false
+	}
+	if f.Name != "" {
+		nameRe := f.name
+		if nameRe == nil {
+			// filter is not validated
+			var err error
+			if nameRe, err = regexp.Compile(f.Name); err != nil {
+				return false
+			}
+		}
+		if !nameRe.MatchString(name) {
+			return false
+		}
+	}
+	if f.Selector != "" {
+		selector := f.selector
+		if selector == nil {
+			// filter is not validated
+			var err error
+			if selector, err = labels.Parse(f.Selector); err != nil {
+				return false
+			}
+		}
+		if !selector.Matches(labels.Set(objLabels)) {
+			return false
+		}
+	}
+	if f.Namespace != "" {
+		if ok, _ := path.Match(f.Namespace, namespace); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String - returns filter in the format accepted by ParseFilter.
+func (f Filter) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"kind", f.Kind}, {"name", f.Name}, {"selector", f.Selector}, {"namespace", f.Namespace}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+kv[1])
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// matchesKind - matches kind pattern against object kind, kind with group and kind with version and group.
+func matchesKind(pattern string, gvk schema.GroupVersionKind) bool {
+	candidates := []string{gvk.Kind, strings.TrimSuffix(gvk.Kind+"."+gvk.Version+"."+gvk.Group, ".")}
+	if gvk.Group != "" {
+		candidates = append(candidates, gvk.Kind+"."+gvk.Group)
+	}
+	for _, c := range candidates {
+		if ok, _ := path.Match(pattern, c); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validate - checks filter patterns and compiles name and selector used by Matches.
+func (f *Filter) validate() error {
+	var err error
+	if _, err = path.Match(f.Kind, ""); err != nil {
+		return fmt.Errorf("%w: invalid kind pattern %q", err, f.Kind)
+	}
+	if f.name, err = regexp.Compile(f.Name); err != nil {
+		return fmt.Errorf("%w: invalid name pattern %q", err, f.Name)
+	}
+	if f.selector, err = labels.Parse(f.Selector); err != nil {
+		return fmt.Errorf("%w: invalid selector %q", err, f.Selector)
+	}
+	if _, err := path.Match(f.Namespace, ""); err != nil {
+		return fmt.Errorf("%w: invalid namespace pattern %q", err, f.Namespace)
+	}
+	return nil
+}
+
+// Excluded - returns true and the reason if object is not selected by include filters or selected by an
+// exclude filter. All objects are included if there are no include filters.
+func (c Config) Excluded(gvk schema.GroupVersionKind, name, namespace string, objLabels map[string]string) (bool, string) {
+	if len(c.Include) != 0 {
+		included := false
+		for _, f := range c.Include {
+			if f.Matches(gvk, name, namespace, objLabels) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true, "not matched by include filters"
+		}
+	}
+	for _, f := range c.Exclude {
+		if f.Matches(gvk, name, namespace, objLabels) {
+			return true, fmt.Sprintf("matched by exclude filter %q", f.String())
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Rule - conversion options for objects matching its kind, name and label selector.
+// Empty kind, name or selector match any object.
+type Rule struct {
+	// Kind - object kind, e.g. Deployment.
+	Kind string `json:"kind,omitempty"`
+	// Name - object name or glob pattern, e.g. "*-webhook".
+	Name string `json:"name,omitempty"`
+	// Selector - label selector, e.g. "app=web,tier!=cache".
+	Selector string `json:"selector,omitempty"`
+
+	// Skip - do not add the object to the chart.
+	Skip bool `json:"skip,omitempty"`
+	// OriginalName - keep object name instead of adding release name prefix.
+	OriginalName bool `json:"originalName,omitempty"`
+	// PreserveNs - keep object namespace.
+	PreserveNs bool `json:"preserveNs,omitempty"`
+	// File - template file path relative to chart templates directory.
+	File string `json:"file,omitempty"`
+	// ValuesPrefix - dot separated values path to nest object values under, e.g. "backend".
+	ValuesPrefix string `json:"valuesPrefix,omitempty"`
+	// Values - object fields to extract into values in addition to fields extracted by processor.
+	Values []ValueRule `json:"values,omitempty"`
+
+	selector labels.Selector
+}
+
+// ValueRule - object field extracted into values. Field is replaced with values reference after the object
+// is processed, so it can parameterize fields of any kind including kinds without a processor.
+type ValueRule struct {
+	// Path - JSONPath-like field path. List items are selected by index, by name or with wildcard,
+	// e.g. "spec.ports[0].nodePort", "spec.template.spec.containers[?(@.name=="web")].ports"
+	// or "spec.template.spec.containers[*].ports".
+	Path string `json:"path"`
+	// Value - dot separated values path, e.g. "web.ports". Supports variables: {{name}} - object name used by
+	// processors as values key, {{kind}} - object kind and {{<item>}} - name or index of the list item
+	// matched by wildcard, where <item> is singular list key, e.g. {{container}} for containers[*].
+	Value string `json:"value"`
+}
+
+// Matches returns true if object with given kind, name and labels is selected by the rule.
+func (r Rule) Matches(kind, name string, objLabels map[string]string) bool {
+	if r.Kind != "" && r.Kind != kind {
+		return false
+	}
+	if r.Name != "" {
+		if ok, _ := path.Match(r.Name, name); !ok {
+			return false
+		}
+	}
+	if r.Selector != "" {
+		selector := r.selector
+		if selector == nil {
+			// rule is not validated
+			var err error
+			if selector, err = labels.Parse(r.Selector); err != nil {
+				return false
+			}
+		}
+		if !selector.Matches(labels.Set(objLabels)) {
+			return false
+		}
+	}
+	return true
+}
+
+// merge - overrides rule options with options set in other rule.
+func (r Rule) merge(other Rule) Rule {
+	r.Skip = r.Skip || other.Skip
+	r.OriginalName = r.OriginalName || other.OriginalName
+	r.PreserveNs = r.PreserveNs || other.PreserveNs
+	if other.File != "" {
+		r.File = other.File
+	}
+	if other.ValuesPrefix != "" {
+		r.ValuesPrefix = other.ValuesPrefix
+	}
+	r.Values = append(r.Values, other.Values...)
+	return r
+}
+
+// validate - checks rule options and compiles selector used by Matches.
+func (r *Rule) validate() error {
+	var err error
+	if _, err = path.Match(r.Name, ""); err != nil {
+		return fmt.Errorf("%w: invalid name pattern %q", err, r.Name)
+	}
+	if r.selector, err = labels.Parse(r.Selector); err != nil {
+		return fmt.Errorf("%w: invalid selector %q", err, r.Selector)
+	}
+	if r.File != "" && (filepath.IsAbs(r.File) || strings.HasPrefix(filepath.Clean(r.File), "..")) {
+		return fmt.Errorf("file %q must be relative to chart templates directory", r.File)
+	}
+	if r.ValuesPrefix != "" && !validValuesPath(r.ValuesPrefix) {
+		return fmt.Errorf("invalid values prefix %q", r.ValuesPrefix)
+	}
+	for _, v := range r.Values {
+		if v.Path == "" || !validValuesPath(v.Value) {
+			return fmt.Errorf("invalid value rule %q: path and value are required", v.Path)
+		}
+	}
+	return nil
+}
+
+func validValuesPath(p string) bool {
+	for _, part := range strings.Split(p, ".") {
+		if part == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Rule - returns options of all rules matching the object. Later rules take precedence.
+func (c Config) Rule(kind, name string, objLabels map[string]string) Rule {
+	var res Rule
+	for _, r := range c.Rules {
+		if r.Matches(kind, name, objLabels) {
+			res = res.merge(r)
+		}
+	}
+	return res
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Workload - custom workload kind with pod template processed like Deployment pod template.
+type Workload struct {
+	// Kind - workload kind, e.g. CloneSet.
+	Kind string
+	// Group - workload API group, e.g. apps.kruise.io. Empty group matches any group.
+	Group string
+	// TemplatePath - dot separated path to pod template under spec, e.g. spec.workload.template.
+	// Default is spec.template.
+	TemplatePath string
+}
+
+func (w Workload) validate() error {
+	if w.Kind == "" {
+		return fmt.Errorf("workload kind is not set")
+	}
+	if w.TemplatePath == "" {
+		return nil
+	}
+	path := strings.Split(w.TemplatePath, ".")
+	if len(path) < 2 || path[0] != "spec" {
+		return fmt.Errorf("invalid workload %s template path %q: expected path under spec", w.Kind, w.TemplatePath)
+	}
+	for _, p := range path {
+		if p == "" {
+			return fmt.Errorf("invalid workload %s template path %q", w.Kind, w.TemplatePath)
+		}
+	}
+	return nil
+}
+
+// TemplatePath returns path to pod template of workload objects with given group and kind.
+// Returns spec.template if workload is not configured.
+func (c Config) TemplatePath(group, kind string) []string {
+	for _, w := range c.Workloads {
+		if w.Kind != kind || w.Group != "" && w.Group != group {
+			continue
+		}
+		if w.TemplatePath != "" {
+			return strings.Split(w.TemplatePath, ".")
+		}
+		break
+	}
+	return []string{"spec", "template"}
+}
@@ -0,0 +1,154 @@
+// Package conversion describes how input objects were converted into the chart: processor claimed each object,
+// generated template file and values, warnings and per-kind coverage.
+package conversion
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Status - conversion status of an input object.
+type Status string
+
+const (
+	// StatusConverted - object is converted by a processor supporting its kind.
+	StatusConverted Status = "converted"
+	// StatusDefault - object kind is not supported, object is copied into template by default processor.
+	StatusDefault Status = "default"
+	// StatusSkipped - object is not added to the chart by processor or because there is no processor for its kind.
+	StatusSkipped Status = "skipped"
+	// StatusExcluded - object is left out of the chart by input filters or config rules.
+	StatusExcluded Status = "excluded"
+)
+
+// Report - conversion results of all input objects.
+type Report struct {
+	Chart   string   `json:"chart"`
+	Objects []Object `json:"objects"`
+	// Coverage - conversion summary by object kind.
+	Coverage []Coverage `json:"coverage"`
+	// UnsupportedKinds - kinds of objects converted by default processor or skipped without processor
+	// in <Kind>.<group> format.
+	UnsupportedKinds []string `json:"unsupportedKinds"`
+	// InputErrors - input documents which can't be read, decoded or don't match schema of their kind.
+	InputErrors []string `json:"inputErrors,omitempty"`
+}
+
+// Object - conversion result of an input object.
+type Object struct {
+	// Env - environment of the object if chart is generated from multiple environments.
+	Env string `json:"env,omitempty"`
+	// File - input file name. Empty for stdin and cluster objects.
+	File       string `json:"file,omitempty"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	Status     Status `json:"status"`
+	// Processor - name of the processor claimed the object.
+	Processor string `json:"processor,omitempty"`
+	// Template - path of generated template file relative to chart directory.
+	Template string `json:"template,omitempty"`
+	// Values - dot separated paths of values.yaml keys contributed by the object.
+	Values   []string `json:"values,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Coverage - number of objects of a kind by conversion status.
+type Coverage struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Objects    int    `json:"objects"`
+	Converted  int    `json:"converted"`
+	Default    int    `json:"default"`
+	Skipped    int    `json:"skipped"`
+	Excluded   int    `json:"excluded"`
+}
+
+// New - returns report of the chart with coverage summary of given objects.
+func New(chart string, objects []Object) Report {
+	res := Report{Chart: chart, Objects: objects, Coverage: []Coverage{}, UnsupportedKinds: []string{}}
+	if res.Objects == nil {
+		res.Objects = []Object{}
+	}
+	coverage := map[[2]string]*Coverage{}
+	unsupported := map[string]struct{}{}
+	for _, o := range objects {
+		key := [2]string{o.APIVersion, o.Kind}
+		c, ok := coverage[key]
+		if !ok {
+			c = &Coverage{APIVersion: o.APIVersion, Kind: o.Kind}
+			coverage[key] = c
+		}
+		c.Objects++
+		switch o.Status {
+		case StatusConverted:
+			c.Converted++
+		case StatusDefault:
+			c.Default++
+		case StatusSkipped:
+			c.Skipped++
+		case StatusExcluded:
+			c.Excluded++
+		}
+		if o.Status == StatusDefault || o.Status == StatusSkipped && o.Processor == "" {
+			unsupported[schema.FromAPIVersionAndKind(o.APIVersion, o.Kind).GroupKind().String()] = struct{}{}
+		}
+	}
+	for _, c := range coverage {
+		res.Coverage = append(res.Coverage, *c)
+	}
+	sort.Slice(res.Coverage, func(i, j int) bool {
+		if res.Coverage[i].APIVersion != res.Coverage[j].APIVersion {
+			return res.Coverage[i].APIVersion < res.Coverage[j].APIVersion
+		}
+		return res.Coverage[i].Kind < res.Coverage[j].Kind
+	})
+	for k := range unsupported {
+		res.UnsupportedKinds = append(res.UnsupportedKinds, k)
+	}
+	sort.Strings(res.UnsupportedKinds)
+	return res
+}
+
+// WriteJSON - writes report in JSON format.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteYAML - writes report in YAML format.
+func (r Report) WriteYAML(w io.Writer) error {
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ValuesPaths - returns sorted dot separated paths of values leaves. Lists are leaves.
+func ValuesPaths(values helmify.Values) []string {
+	var res []string
+	var walk func(prefix []string, m map[string]interface{})
+	walk = func(prefix []string, m map[string]interface{}) {
+		for k, v := range m {
+			path := append(append([]string{}, prefix...), k)
+			if nested, ok := v.(map[string]interface{}); ok && len(nested) != 0 {
+				walk(path, nested)
+				continue
+			}
+			res = append(res, strings.Join(path, "."))
+		}
+	}
+	walk(nil, values)
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,44 @@
+package conversion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	res := New("my-chart", []Object{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Status: StatusConverted, Processor: "deployment.deployment"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "dev", Status: StatusExcluded},
+		{APIVersion: "example.com/v1", Kind: "Widget", Name: "w", Status: StatusDefault, Processor: "default"},
+		{APIVersion: "v1", Kind: "Pod", Name: "p", Status: StatusSkipped},
+		{APIVersion: "v1", Kind: "Secret", Name: "s", Status: StatusSkipped, Processor: "secret.secret"},
+	})
+	assert.Equal(t, []Coverage{
+		{APIVersion: "apps/v1", Kind: "Deployment", Objects: 2, Converted: 1, Excluded: 1},
+		{APIVersion: "example.com/v1", Kind: "Widget", Objects: 1, Default: 1},
+		{APIVersion: "v1", Kind: "Pod", Objects: 1, Skipped: 1},
+		{APIVersion: "v1", Kind: "Secret", Objects: 1, Skipped: 1},
+	}, res.Coverage)
+	assert.Equal(t, []string{"Pod", "Widget.example.com"}, res.UnsupportedKinds)
+
+	empty := New("my-chart", nil)
+	var buf bytes.Buffer
+	assert.NoError(t, empty.WriteYAML(&buf))
+	assert.Equal(t, "chart: my-chart\ncoverage: []\nobjects: []\nunsupportedKinds: []\n", buf.String())
+}
+
+func TestValuesPaths(t *testing.T) {
+	values := helmify.Values{
+		"web": map[string]interface{}{
+			"replicas": 1,
+			"image":    map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+			"ports":    []interface{}{map[string]interface{}{"port": 80}},
+			"env":      map[string]interface{}{},
+		},
+	}
+	assert.Equal(t, []string{"web.env", "web.image.repository", "web.image.tag", "web.ports", "web.replicas"}, ValuesPaths(values))
+	assert.Empty(t, ValuesPaths(nil))
+}
@@ -1,64 +1,248 @@
 package decoder
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
-	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 )
 
 const (
-	yamlDecoderBufferSize          = 100
 	decoderResultChannelBufferSize = 1
+	separator                      = "---"
 )
 
-// Decode - reads bytes stream of k8s yaml manifests and decodes it to k8s unstructured objects.
+// Error - input document which can't be decoded or doesn't match schema of its kind.
+type Error struct {
+	// File - input file path. Empty for stdin.
+	File string
+	// Document - 1-based index of the document in the file. Zero if error is not related to a document.
+	Document int
+	// Line - 1-based line of the error or of the document start. Zero if unknown.
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	file := e.File
+	if file == "" {
+		file = "<stdin>"
+	}
+	switch {
+	case e.Document == 0:
+		return fmt.Sprintf("%s: %v", file, e.Err)
+	case e.Line == 0:
+		return fmt.Sprintf("%s: document %d: %v", file, e.Document, e.Err)
+	default:
+		return fmt.Sprintf("%s:%d: document %d: %v", file, e.Line, e.Document, e.Err)
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors - input errors reported together.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d invalid input documents: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// SplitUnknownFields - returns errors of objects with unknown fields separately from other input errors.
+func (e Errors) SplitUnknownFields() (unknown, other Errors) {
+	for _, err := range e {
+		if errors.Is(err, ErrUnknownFields) {
+			unknown = append(unknown, err)
+		} else {
+			other = append(other, err)
+		}
+	}
+	return unknown, other
+}
+
+// Unwrap - returns all input errors.
+func (e Errors) Unwrap() []error {
+	res := make([]error, 0, len(e))
+	for _, err := range e {
+		res = append(res, err)
+	}
+	return res
+}
+
+// document - raw input document and its position in the input.
+type document struct {
+	data []byte
+	// index - 1-based document index.
+	index int
+	// start - 1-based line of the first document byte.
+	start int
+	// line - 1-based line of the first document line which is not empty or comment.
+	line int
+}
+
+// Decode - reads bytes stream of k8s yaml or json manifests and decodes it to k8s unstructured objects.
 // Non-blocking function. Sends results into buffered channel. Closes channel on io.EOF.
-func Decode(stop <-chan struct{}, reader io.Reader) <-chan *unstructured.Unstructured {
-	decoder := yamlutil.NewYAMLOrJSONDecoder(reader, yamlDecoderBufferSize)
+// Documents which can't be decoded are skipped. Their errors and schema errors of decoded objects are passed to onErr
+// from decoding goroutine before the channel is closed. Errors are logged if onErr is nil.
+func Decode(stop <-chan struct{}, reader io.Reader, onErr func(*Error)) <-chan *unstructured.Unstructured {
+	if onErr == nil {
+		onErr = func(err *Error) {
+			logrus.WithError(err).Error("invalid input document")
+		}
+	}
 	res := make(chan *unstructured.Unstructured, decoderResultChannelBufferSize)
 	go func() {
 		defer close(res)
 		logrus.Debug("Start processing...")
-		for {
+		docs, err := split(reader)
+		var docErr *Error
+		if errors.As(err, &docErr) {
+			onErr(docErr)
+		} else if err != nil {
+			onErr(&Error{Err: fmt.Errorf("%w: unable to read input", err)})
+		}
+		for _, doc := range docs {
 			select {
 			case <-stop:
 				logrus.Debug("Exiting: received stop signal")
 				return
 			default:
 			}
-			var rawObj runtime.RawExtension
-			err := decoder.Decode(&rawObj)
-			if errors.Is(err, io.EOF) {
-				logrus.Debug("EOF received. Finishing input objects decoding.")
-				return
-			}
-			if err != nil {
-				logrus.WithError(err).Error("unable to decode yaml from input")
-				continue
-			}
-			obj, _, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(rawObj.Raw, nil, nil)
+			object, err := decode(doc.data)
 			if err != nil {
-				logrus.WithError(err).Error("unable to decode yaml")
+				onErr(&Error{Document: doc.index, Line: errorLine(doc, err), Err: err})
 				continue
 			}
-			unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
-			if err != nil {
-				logrus.WithError(err).Error("unable to map yaml to k8s unstructured")
-				continue
-			}
-			object := &unstructured.Unstructured{Object: unstructuredMap}
 			logrus.WithFields(logrus.Fields{
 				"ApiVersion": object.GetAPIVersion(),
 				"Kind":       object.GetKind(),
 				"Name":       object.GetName(),
 			}).Debug("decoded")
+			if err = Validate(object); err != nil {
+				onErr(&Error{Document: doc.index, Line: doc.line, Err: err})
+			}
 			res <- object
 		}
+		logrus.Debug("EOF received. Finishing input objects decoding.")
 	}()
 	return res
 }
+
+// decode - decodes yaml or json document.
+func decode(data []byte) (*unstructured.Unstructured, error) {
+	obj, _, err := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to decode yaml", err)
+	}
+	unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to map yaml to k8s unstructured", err)
+	}
+	return &unstructured.Unstructured{Object: unstructuredMap}, nil
+}
+
+// isEmpty - returns true if yaml document contains only comments.
+func isEmpty(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// split - reads input and splits it into yaml documents or json objects.
+func split(reader io.Reader) ([]document, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimLeftFunc(data, unicode.IsSpace); len(trimmed) != 0 && trimmed[0] == '{' {
+		return splitJSON(data)
+	}
+	return splitYAML(data), nil
+}
+
+// splitYAML - splits yaml stream by document separator lines.
+func splitYAML(data []byte) []document {
+	var res []document
+	doc := document{index: 1, start: 1}
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, separator) && strings.TrimRightFunc(text[len(separator):], unicode.IsSpace) == "" {
+			if doc.data = append([]byte(nil), buf.Bytes()...); !isEmpty(doc.data) {
+				res = append(res, doc)
+				doc.index++
+			}
+			buf.Reset()
+			doc.start, doc.line = line+1, 0
+			continue
+		}
+		if doc.line == 0 && !isEmpty([]byte(text)) {
+			doc.line = line
+		}
+		buf.WriteString(text)
+		buf.WriteByte('\n')
+	}
+	if doc.data = buf.Bytes(); !isEmpty(doc.data) {
+		res = append(res, doc)
+	}
+	return res
+}
+
+// splitJSON - splits stream of json objects.
+func splitJSON(data []byte) ([]document, error) {
+	var res []document
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for i := 1; ; i++ {
+		start := decoder.InputOffset()
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return res, &Error{Document: i, Line: lineAt(data, decoder.InputOffset()), Err: fmt.Errorf("%w: unable to decode json", err)}
+		}
+		start += int64(len(data[start:]) - len(bytes.TrimLeftFunc(data[start:], unicode.IsSpace)))
+		line := lineAt(data, start)
+		res = append(res, document{data: raw, index: i, start: line, line: line})
+	}
+}
+
+// lineAt - returns 1-based line of the byte offset.
+func lineAt(data []byte, offset int64) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+var yamlErrLine = regexp.MustCompile(`yaml: line (\d+):`)
+
+// errorLine - returns input line of yaml syntax error or document start line.
+func errorLine(doc document, err error) int {
+	if m := yamlErrLine.FindStringSubmatch(err.Error()); m != nil {
+		if n, convErr := strconv.Atoi(m[1]); convErr == nil {
+			return doc.start + n - 1
+		}
+	}
+	return doc.line
+}
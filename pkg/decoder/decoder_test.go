@@ -68,7 +68,7 @@ metadata:
 func TestDecodeOk(t *testing.T) {
 	reader := strings.NewReader(validObjects2)
 	stop := make(chan struct{})
-	objects := Decode(stop, reader)
+	objects := Decode(stop, reader, nil)
 	i := 0
 	for range objects {
 		i++
@@ -79,7 +79,7 @@ func TestDecodeOk(t *testing.T) {
 func TestDecodeEmptyObj(t *testing.T) {
 	reader := strings.NewReader(validObjects0)
 	stop := make(chan struct{})
-	objects := Decode(stop, reader)
+	objects := Decode(stop, reader, nil)
 	i := 0
 	for range objects {
 		i++
@@ -90,10 +90,113 @@ func TestDecodeEmptyObj(t *testing.T) {
 func TestDecodeInvalidObj(t *testing.T) {
 	reader := strings.NewReader(validObjects2withInvalid)
 	stop := make(chan struct{})
-	objects := Decode(stop, reader)
+	objects := Decode(stop, reader, nil)
 	i := 0
 	for range objects {
 		i++
 	}
 	assert.Equal(t, 2, i, "decoded 2 valid objects")
 }
+
+func TestDecodeErrors(t *testing.T) {
+	reader := strings.NewReader(validObjects2withInvalid)
+	stop := make(chan struct{})
+	var errs Errors
+	objects := Decode(stop, reader, func(err *Error) {
+		errs = append(errs, err)
+	})
+	i := 0
+	for range objects {
+		i++
+	}
+	assert.Equal(t, 2, i, "decoded 2 valid objects")
+	if assert.Len(t, errs, 3) {
+		assert.Equal(t, 1, errs[0].Document)
+		assert.Equal(t, 1, errs[0].Line)
+		assert.Equal(t, 3, errs[1].Document)
+		assert.Equal(t, 18, errs[1].Line)
+		assert.Equal(t, 5, errs[2].Document)
+		assert.Equal(t, 29, errs[2].Line)
+		assert.Contains(t, errs[2].Error(), "<stdin>:29: document 5:")
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	reader := strings.NewReader(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "b"}, "dta": {}}
+{"apiVersion": "v1",`)
+	stop := make(chan struct{})
+	var errs Errors
+	objects := Decode(stop, reader, func(err *Error) {
+		errs = append(errs, err)
+	})
+	i := 0
+	for range objects {
+		i++
+	}
+	assert.Equal(t, 2, i, "decoded 2 objects")
+	if assert.Len(t, errs, 2) {
+		assert.Equal(t, 3, errs[0].Document)
+		assert.Equal(t, 2, errs[1].Document)
+		assert.Equal(t, 2, errs[1].Line)
+		assert.ErrorIs(t, errs[1], ErrUnknownFields)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.Regexp(t, `^1\.\d+$`, APIVersion, "derived from k8s.io/api module version")
+	reader := strings.NewReader(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replica: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        ports:
+        - containerPort: "80"
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: web
+spec:
+  anything: true
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: proxy
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: proxy
+        image: envoy
+        restartPolicy: Always
+`)
+	stop := make(chan struct{})
+	var errs Errors
+	objects := Decode(stop, reader, func(err *Error) {
+		errs = append(errs, err)
+	})
+	i := 0
+	for range objects {
+		i++
+	}
+	assert.Equal(t, 3, i, "objects with schema errors are decoded")
+	if assert.Len(t, errs, 2) {
+		assert.ErrorIs(t, errs[0], ErrSchema)
+		assert.Equal(t, 1, errs[0].Line)
+		// field added in newer k8s version
+		assert.ErrorIs(t, errs[1], ErrUnknownFields)
+		assert.NotErrorIs(t, errs[1], ErrSchema)
+		assert.Contains(t, errs[1].Error(), `unknown field "spec.template.spec.initContainers[0].restartPolicy"`)
+		unknown, other := errs.SplitUnknownFields()
+		assert.Equal(t, Errors{errs[1]}, unknown)
+		assert.Equal(t, Errors{errs[0]}, other)
+	}
+}
@@ -0,0 +1,62 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// APIVersion - Kubernetes version of API schemas used to validate built-in kinds.
+// Derived from the k8s.io/api module version helmify is built with.
+var APIVersion = apiVersion()
+
+var (
+	// ErrSchema - object of known kind has fields of wrong type.
+	ErrSchema = errors.New("object does not match schema")
+	// ErrUnknownFields - object of known kind has fields missing in its API schema. Fields may be a typo or
+	// belong to a Kubernetes version newer than APIVersion.
+	ErrUnknownFields = errors.New("object has fields unknown to Kubernetes " + APIVersion + " API schema")
+)
+
+// apiVersion - returns Kubernetes version of the k8s.io/api module from build info,
+// k8s.io/api v0.<minor>.<patch> is released with Kubernetes 1.<minor>.<patch>.
+func apiVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != "k8s.io/api" {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if v := strings.Split(strings.TrimPrefix(dep.Version, "v"), "."); len(v) > 1 && v[0] == "0" {
+			return "1." + v[1]
+		}
+	}
+	return "unknown"
+}
+
+// Validate - checks object of built-in Kubernetes kind against its API schema. Objects of unknown kinds are not checked.
+// Returns ErrUnknownFields if object has fields missing in the schema and no fields of wrong type.
+func Validate(obj *unstructured.Unstructured) error {
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return nil
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(obj.Object, typed, true)
+	if runtime.IsStrictDecodingError(err) {
+		return fmt.Errorf("%w: %s %s: %w", ErrUnknownFields, obj.GetKind(), obj.GetName(), err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: %w", ErrSchema, obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package diff contains line based diff and three-way merge of text files.
+package diff
+
+import "strings"
+
+// maxEditDistance - limits diff computation for completely different files.
+// If exceeded, files considered to have only common prefix and suffix.
+const maxEditDistance = 4096
+
+// Lines - splits text into lines keeping line endings.
+func Lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matches - returns index pairs of equal lines in a and b forming the longest common subsequence.
+// Result is sorted by both indexes.
+func matches(a, b []string) [][2]int {
+	var res [][2]int
+	// trim common prefix and suffix to speed up the most common case of small changes.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		res = append(res, [2]int{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, m := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		res = append(res, [2]int{m[0] + prefix, m[1] + prefix})
+	}
+	for i := suffix; i > 0; i-- {
+		res = append(res, [2]int{len(a) - i, len(b) - i})
+	}
+	return res
+}
+
+// myers - Myers' O(ND) difference algorithm. Returns matched line indexes.
+// Trace keeps only diagonals reachable on each step, so memory is O(D^2) where D is the edit distance.
+func myers(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+	max := n + m
+	if max > maxEditDistance {
+		max = maxEditDistance
+	}
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		// step d reads diagonals from -d-1 to d+1 only
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack - restores matched lines from myers trace.
+// Trace item d holds diagonals from -d-1 to d+1.
+func backtrack(trace [][]int, x, y int) [][2]int {
+	var res [][2]int
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		offset := d + 1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			res = append(res, [2]int{x, y})
+		}
+		x, y = prevX, prevY
+	}
+	// reverse to ascending order
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
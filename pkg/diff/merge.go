@@ -0,0 +1,110 @@
+package diff
+
+import "strings"
+
+const (
+	conflictLocal     = "<<<<<<< local\n"
+	conflictSeparator = "=======\n"
+	conflictGenerated = ">>>>>>> generated\n"
+)
+
+// Merge - performs three-way merge of local and generated files changes made since base version.
+// Non-overlapping changes are combined. Overlapping changes are marked with git-style conflict markers.
+// Returns merged text and true if merge has conflicts.
+func Merge(base, local, generated string) (string, bool) {
+	o, a, b := Lines(base), Lines(local), Lines(generated)
+	ma := matchIndex(matches(o, a))
+	mb := matchIndex(matches(o, b))
+
+	// lines in base which are unchanged in both local and generated
+	var stable []int
+	for n := range o {
+		_, inA := ma[n]
+		_, inB := mb[n]
+		if inA && inB {
+			stable = append(stable, n)
+		}
+	}
+
+	res := strings.Builder{}
+	conflict := false
+	i, j, k := 0, 0, 0
+	for _, next := range stable {
+		if next == i && ma[i] == j && mb[i] == k {
+			res.WriteString(o[i])
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+		conflict = mergeChunk(&res, o[i:next], a[j:ma[next]], b[k:mb[next]]) || conflict
+		res.WriteString(o[next])
+		i, j, k = next+1, ma[next]+1, mb[next]+1
+	}
+	conflict = mergeChunk(&res, o[i:], a[j:], b[k:]) || conflict
+	return res.String(), conflict
+}
+
+// MergeTwoWay - merges local and generated files without a common base version.
+// Common lines of both files are used as a base, so lines added on one side are kept
+// and only chunks changed on both sides are marked as conflicts.
+// Returns merged text and true if merge has conflicts.
+func MergeTwoWay(local, generated string) (string, bool) {
+	a := Lines(local)
+	base := strings.Builder{}
+	for _, m := range matches(a, Lines(generated)) {
+		base.WriteString(a[m[0]])
+	}
+	return Merge(base.String(), local, generated)
+}
+
+func matchIndex(m [][2]int) map[int]int {
+	res := make(map[int]int, len(m))
+	for _, pair := range m {
+		res[pair[0]] = pair[1]
+	}
+	return res
+}
+
+// mergeChunk - writes unstable chunk into result. Returns true on conflict.
+func mergeChunk(res *strings.Builder, base, local, generated []string) bool {
+	switch {
+	case equal(local, generated), equal(base, generated):
+		writeLines(res, local)
+		return false
+	case equal(base, local):
+		writeLines(res, generated)
+		return false
+	}
+	res.WriteString(conflictLocal)
+	writeLines(res, local)
+	terminateLine(res)
+	res.WriteString(conflictSeparator)
+	writeLines(res, generated)
+	terminateLine(res)
+	res.WriteString(conflictGenerated)
+	return true
+}
+
+func writeLines(res *strings.Builder, lines []string) {
+	for _, l := range lines {
+		res.WriteString(l)
+	}
+}
+
+func terminateLine(res *strings.Builder) {
+	str := res.String()
+	if str != "" && !strings.HasSuffix(str, "\n") {
+		res.WriteString("\n")
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := "a: 1\nb: 2\nc: 3\nd: 4\n"
+	tests := []struct {
+		name         string
+		local        string
+		generated    string
+		want         string
+		wantConflict bool
+	}{
+		{
+			name:      "no changes",
+			local:     base,
+			generated: base,
+			want:      base,
+		},
+		{
+			name:      "only generated changed",
+			local:     base,
+			generated: "a: 1\nb: 20\nc: 3\nd: 4\n",
+			want:      "a: 1\nb: 20\nc: 3\nd: 4\n",
+		},
+		{
+			name:      "only local changed",
+			local:     "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\n",
+			generated: base,
+			want:      "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\n",
+		},
+		{
+			name:      "non-overlapping changes",
+			local:     "a: 10\nb: 2\nc: 3\nd: 4\n",
+			generated: "a: 1\nb: 2\nc: 3\nd: 40\n",
+			want:      "a: 10\nb: 2\nc: 3\nd: 40\n",
+		},
+		{
+			name:      "same change",
+			local:     "a: 1\nb: 5\nc: 3\nd: 4\n",
+			generated: "a: 1\nb: 5\nc: 3\nd: 4\n",
+			want:      "a: 1\nb: 5\nc: 3\nd: 4\n",
+		},
+		{
+			name:         "conflict",
+			local:        "a: 1\nb: local\nc: 3\nd: 4\n",
+			generated:    "a: 1\nb: generated\nc: 3\nd: 4\n",
+			want:         "a: 1\n<<<<<<< local\nb: local\n=======\nb: generated\n>>>>>>> generated\nc: 3\nd: 4\n",
+			wantConflict: true,
+		},
+		{
+			name:         "conflict without trailing newline",
+			local:        "a: 1\nb: 2\nc: 3\nd: local",
+			generated:    "a: 1\nb: 2\nc: 3\nd: generated",
+			want:         "a: 1\nb: 2\nc: 3\n<<<<<<< local\nd: local\n=======\nd: generated\n>>>>>>> generated\n",
+			wantConflict: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflict := Merge(base, tt.local, tt.generated)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantConflict, conflict)
+		})
+	}
+	t.Run("empty base", func(t *testing.T) {
+		got, conflict := Merge("", "a: 1\n", "a: 1\n")
+		assert.Equal(t, "a: 1\n", got)
+		assert.False(t, conflict)
+		_, conflict = Merge("", "a: 1\n", "a: 2\n")
+		assert.True(t, conflict)
+	})
+}
+
+func TestMergeTwoWay(t *testing.T) {
+	t.Run("additions on both sides", func(t *testing.T) {
+		got, conflict := MergeTwoWay("a: 1\nlocal: 1\nb: 2\n", "a: 1\nb: 2\ngenerated: 1\n")
+		assert.Equal(t, "a: 1\nlocal: 1\nb: 2\ngenerated: 1\n", got)
+		assert.False(t, conflict)
+	})
+	t.Run("only changed lines conflict", func(t *testing.T) {
+		got, conflict := MergeTwoWay("a: 1\nb: local\nc: 3\n", "a: 1\nb: generated\nc: 3\n")
+		assert.Equal(t, "a: 1\n<<<<<<< local\nb: local\n=======\nb: generated\n>>>>>>> generated\nc: 3\n", got)
+		assert.True(t, conflict)
+	})
+}
+
+func Test_matches(t *testing.T) {
+	a := Lines("a\nb\nc\nd\ne\n")
+	b := Lines("a\nx\nc\ne\ny\n")
+	assert.Equal(t, [][2]int{{0, 0}, {2, 2}, {4, 3}}, matches(a, b))
+}
+
+func Test_myers(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 300; i++ {
+		a = append(a, fmt.Sprintf("line %d\n", i))
+		if i%3 != 0 {
+			b = append(b, fmt.Sprintf("line %d\n", i))
+		}
+		if i%5 == 0 {
+			b = append(b, fmt.Sprintf("new %d\n", i))
+		}
+	}
+	got := myers(a, b)
+	assert.Len(t, got, 200)
+	for _, m := range got {
+		assert.Equal(t, a[m[0]], b[m[1]])
+	}
+}
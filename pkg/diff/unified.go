@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// edit - line of edit script: ' ' unchanged, '-' removed from a, '+' added from b.
+type edit struct {
+	op   byte
+	line string
+	// a, b - 0-based indexes of the edit in a and b.
+	a, b int
+}
+
+// Unified - returns unified diff of texts a and b named from and to with context lines around changes.
+// Returns empty string if texts are equal.
+func Unified(from, to, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	edits := editScript(Lines(a), Lines(b))
+	res := strings.Builder{}
+	fmt.Fprintf(&res, "--- %s\n+++ %s\n", from, to)
+	for start := 0; start < len(edits); {
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		// extend hunk while unchanged lines between changes fit into context of both changes.
+		end := first
+		for i := first; i < len(edits); i++ {
+			if edits[i].op != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*context {
+				break
+			}
+		}
+		hunk := edits[max(first-context, start):min(end+context, len(edits))]
+		writeHunk(&res, hunk)
+		start = min(end+context, len(edits))
+	}
+	return res.String()
+}
+
+// editScript - returns edits transforming a into b.
+func editScript(a, b []string) []edit {
+	var res []edit
+	i, j := 0, 0
+	for _, m := range append(matches(a, b), [2]int{len(a), len(b)}) {
+		for ; i < m[0]; i++ {
+			res = append(res, edit{op: '-', line: a[i], a: i, b: j})
+		}
+		for ; j < m[1]; j++ {
+			res = append(res, edit{op: '+', line: b[j], a: i, b: j})
+		}
+		if i < len(a) && j < len(b) {
+			res = append(res, edit{op: ' ', line: a[i], a: i, b: j})
+			i, j = i+1, j+1
+		}
+	}
+	return res
+}
+
+func writeHunk(res *strings.Builder, hunk []edit) {
+	countA, countB := 0, 0
+	for _, e := range hunk {
+		if e.op != '+' {
+			countA++
+		}
+		if e.op != '-' {
+			countB++
+		}
+	}
+	fmt.Fprintf(res, "@@ -%s +%s @@\n", hunkRange(hunk[0].a, countA), hunkRange(hunk[0].b, countB))
+	for _, e := range hunk {
+		res.WriteByte(e.op)
+		res.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			res.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange - formats 1-based hunk range. Empty range starts at the line preceding the hunk.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
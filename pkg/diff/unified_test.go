@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a: 1\n",
+			b:    "a: 1\n",
+			want: "",
+		},
+		{
+			name: "new file",
+			a:    "",
+			b:    "a: 1\nb: 2\n",
+			want: "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a: 1\n+b: 2\n",
+		},
+		{
+			name: "removed file",
+			a:    "a: 1\n",
+			b:    "",
+			want: "--- from\n+++ to\n@@ -1 +0,0 @@\n-a: 1\n",
+		},
+		{
+			name: "changes in separate hunks",
+			a:    "a: 1\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 7\n",
+			b:    "a: 10\nb: 2\nc: 3\nd: 4\ne: 5\nf: 6\ng: 70\n",
+			want: "--- from\n+++ to\n@@ -1,2 +1,2 @@\n-a: 1\n+a: 10\n b: 2\n@@ -6,2 +6,2 @@\n f: 6\n-g: 7\n+g: 70\n",
+		},
+		{
+			name: "close changes in one hunk",
+			a:    "a: 1\nb: 2\nc: 3\nd: 4\n",
+			b:    "a: 10\nb: 2\nc: 3\nd: 40\n",
+			want: "--- from\n+++ to\n@@ -1,4 +1,4 @@\n-a: 1\n+a: 10\n b: 2\n c: 3\n-d: 4\n+d: 40\n",
+		},
+		{
+			name: "no newline at end of file",
+			a:    "a: 1",
+			b:    "a: 1\n",
+			want: "--- from\n+++ to\n@@ -1 +1 @@\n-a: 1\n\\ No newline at end of file\n+a: 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Unified("from", "to", tt.a, tt.b, 1))
+		})
+	}
+}
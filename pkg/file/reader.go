@@ -1,87 +1,70 @@
 package file
 
 import (
-	"github.com/sirupsen/logrus"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
-)
 
-func Walk(paths []string, recursively bool, walkFunc func(filename string, r io.Reader)) {
+	"github.com/sirupsen/logrus"
+)
 
+// Walk - calls walkFunc for each file from paths. Directories are read non-recursively unless recursively is set.
+// Paths which can't be read are passed to onErr.
+func Walk(paths []string, recursively bool, walkFunc func(path string, r io.Reader), onErr func(path string, err error)) {
 	for _, path := range paths {
 		info, err := os.Stat(path)
 		if err != nil {
-			logrus.Warnf("no such file or directory %q: %v", path, err)
+			onErr(path, err)
 			continue
 		}
 		// handle single file file:
 		if !info.IsDir() {
-			file, err := os.Open(path)
-			if err != nil {
-				logrus.Warnf("unable to open file %q: %v", file.Name(), err)
-				continue
-			}
-			walkFunc(info.Name(), file)
-			err = file.Close()
-			if err != nil {
-				logrus.Warnf("unable to close file %q: %v", file.Name(), err)
-			}
+			readFile(path, walkFunc, onErr)
 			continue
 		}
 		// handle directory non-recursively:
 		if !recursively {
-			dir, err := os.Open(path)
-			if err != nil {
-				logrus.Warnf("unable to open directory %q: %v", dir.Name(), err)
-				continue
-			}
-			files, err := dir.ReadDir(0)
+			files, err := os.ReadDir(path)
 			if err != nil {
-				logrus.Warnf("unable to read directory %q: %v", dir.Name(), err)
+				onErr(path, err)
 				continue
 			}
 			for _, f := range files {
 				if f.IsDir() {
 					continue
 				}
-				file, err := os.Open(filepath.Join(path, f.Name()))
-				if err != nil {
-					logrus.Warnf("unable to open file %q: %v", file.Name(), err)
-					continue
-				}
-				walkFunc(f.Name(), file)
-				err = file.Close()
-				if err != nil {
-					logrus.Warnf("unable to close file %q: %v", file.Name(), err)
-				}
-				continue
+				readFile(filepath.Join(path, f.Name()), walkFunc, onErr)
 			}
 			continue
 		}
 		// handle directory recursively:
 		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
-				return err
+				onErr(path, err)
+				return nil
 			}
 			if d.IsDir() {
 				return nil
 			}
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			walkFunc(d.Name(), file)
-			err = file.Close()
-			if err != nil {
-				logrus.Warnf("unable to close file %q: %v", file.Name(), err)
-			}
+			readFile(path, walkFunc, onErr)
 			return nil
 		})
 		if err != nil {
-			logrus.Warnf("unable to open %q: %v", info.Name(), err)
-			continue
+			onErr(path, err)
 		}
 	}
 }
+
+func readFile(path string, walkFunc func(path string, r io.Reader), onErr func(path string, err error)) {
+	file, err := os.Open(path)
+	if err != nil {
+		onErr(path, err)
+		return
+	}
+	walkFunc(path, file)
+	err = file.Close()
+	if err != nil {
+		logrus.Warnf("unable to close file %q: %v", path, err)
+	}
+}
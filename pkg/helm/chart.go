@@ -1,13 +1,16 @@
 package helm
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/arttor/helmify/pkg/cluster"
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/schema"
 
 	"github.com/sirupsen/logrus"
 
@@ -27,12 +30,22 @@ type output struct{}
 //	├── .helmignore   	# Contains patterns to ignore when packaging Helm charts.
 //	├── Chart.yaml    	# Information about your chart
 //	├── values.yaml   	# The default values for your templates
+//	├── values.schema.json	# Optional JSON schema for values.yaml
+//	├── values-<env>.yaml	# Optional per-environment values overrides
 //	└── templates/    	# The template files
 //	    └── _helpers.tp   # Helm default template partials
 //
 // Overwrites existing values.yaml and templates in templates dir on every run.
-func (o output) Create(chartDir, chartName string, crd bool, certManagerAsSubchart bool, certManagerVersion string, certManagerInstallCRD bool, templates []helmify.Template, filenames []string) error {
-	err := initChartDir(chartDir, chartName, crd, certManagerAsSubchart, certManagerVersion)
+// In merge mode local changes made since the previous run are preserved with three-way merge.
+// In dry-run mode chart files are printed to stdout or compared with chart directory instead of writing.
+func (o output) Create(conf config.Config, templates []helmify.Template, filenames []string, sources []helmify.Source, envValues []helmify.EnvValues) error {
+	w := &chartWriter{chartDir: filepath.Join(conf.ChartDir, conf.ChartName), merge: conf.Merge, dryRun: conf.DryRun}
+	var err error
+	if conf.DryRun {
+		err = w.initChart(conf.ChartName, conf.CertManagerAsSubchart, conf.CertManagerVersion)
+	} else {
+		err = initChartDir(conf.ChartDir, conf.ChartName, conf.Crd, conf.CertManagerAsSubchart, conf.CertManagerVersion)
+	}
 	if err != nil {
 		return err
 	}
@@ -49,65 +62,93 @@ func (o output) Create(chartDir, chartName string, crd bool, certManagerAsSubcha
 			return err
 		}
 	}
-	cDir := filepath.Join(chartDir, chartName)
+	if conf.Merge && !conf.DryRun {
+		err = ignoreGeneratedDir(w.chartDir)
+		if err != nil {
+			return err
+		}
+	}
 	for filename, tpls := range files {
-		err = overwriteTemplateFile(filename, cDir, crd, tpls)
+		err = overwriteTemplateFile(filename, w, conf.Crd, tpls)
 		if err != nil {
 			return err
 		}
 	}
-	err = overwriteValuesFile(cDir, values, certManagerAsSubchart, certManagerInstallCRD)
+	var docs *valuesDocs
+	if conf.ValuesDocs {
+		docs = newValuesDocs(templates, sources)
+	}
+	err = overwriteValuesFile(w, values, docs, conf.CertManagerAsSubchart, conf.CertManagerInstallCRD)
 	if err != nil {
 		return err
 	}
+	for _, env := range envValues {
+		err = overwriteEnvValuesFile(w, env)
+		if err != nil {
+			return err
+		}
+	}
+	if conf.GenerateSchema {
+		err = overwriteSchemaFile(w, values, templates)
+		if err != nil {
+			return err
+		}
+	}
+	changed := false
+	if conf.DryRun {
+		changed, err = w.print(os.Stdout, conf.Diff)
+		if err != nil {
+			return err
+		}
+	}
+	if len(w.conflicts) != 0 {
+		return fmt.Errorf("%w: %s", ErrMergeConflict, strings.Join(w.conflicts, ", "))
+	}
+	if changed {
+		return ErrChangesPending
+	}
 	return nil
 }
 
-func overwriteTemplateFile(filename, chartDir string, crd bool, templates []helmify.Template) error {
+// TemplatePath - returns path of chart template file relative to chart directory.
+// CRD files are placed into crds directory if crdDir is set.
+func TemplatePath(crdDir bool, filename string) string {
+	if strings.Contains(filename, "crd") && crdDir {
+		return filepath.Join("crds", filename)
+	}
+	return filepath.Join("templates", filename)
+}
+
+func overwriteTemplateFile(filename string, w *chartWriter, crd bool, templates []helmify.Template) error {
+	file := TemplatePath(crd, filename)
 	// pull in crd-dir setting and siphon crds into folder
-	var subdir string
-	if strings.Contains(filename, "crd") && crd {
-		subdir = "crds"
+	if strings.HasPrefix(file, "crds"+string(filepath.Separator)) && !w.dryRun {
 		// create "crds" if not exists
-		if _, err := os.Stat(filepath.Join(chartDir, "crds")); os.IsNotExist(err) {
-			err = os.MkdirAll(filepath.Join(chartDir, "crds"), 0750)
+		if _, err := os.Stat(filepath.Join(w.chartDir, "crds")); os.IsNotExist(err) {
+			err = os.MkdirAll(filepath.Join(w.chartDir, "crds"), 0750)
 			if err != nil {
 				return fmt.Errorf("%w: unable create crds dir", err)
 			}
 		}
-	} else {
-		subdir = "templates"
-	}
-	file := filepath.Join(chartDir, subdir, filename)
-	f, err := os.OpenFile(file, os.O_APPEND|os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("%w: unable to open %s", err, file)
 	}
-	defer f.Close()
+	buf := bytes.Buffer{}
 	for i, t := range templates {
 		logrus.WithField("file", file).Debug("writing a template into")
-		err = t.Write(f)
+		err := t.Write(&buf)
 		if err != nil {
 			return fmt.Errorf("%w: unable to write into %s", err, file)
 		}
 		if i != len(templates)-1 {
-			_, err = f.Write([]byte("\n---\n"))
-			if err != nil {
-				return fmt.Errorf("%w: unable to write into %s", err, file)
-			}
+			buf.WriteString("\n---\n")
 		}
 	}
 	if len(templates) != 0 {
-		_, err = f.Write([]byte("\n"))
-		if err != nil {
-			return fmt.Errorf("%w: unable to write newline into %s", err, file)
-		}
+		buf.WriteString("\n")
 	}
-	logrus.WithField("file", file).Info("overwritten")
-	return nil
+	return w.write(file, buf.Bytes())
 }
 
-func overwriteValuesFile(chartDir string, values helmify.Values, certManagerAsSubchart bool, certManagerInstallCRD bool) error {
+func overwriteValuesFile(w *chartWriter, values helmify.Values, docs *valuesDocs, certManagerAsSubchart bool, certManagerInstallCRD bool) error {
 	if certManagerAsSubchart {
 		_, err := values.Add(certManagerInstallCRD, "certmanager", "installCRDs")
 		if err != nil {
@@ -119,16 +160,46 @@ func overwriteValuesFile(chartDir string, values helmify.Values, certManagerAsSu
 			return fmt.Errorf("%w: unable to add cert-manager.enabled", err)
 		}
 	}
-	res, err := yaml.Marshal(values)
+	var res []byte
+	var err error
+	if docs != nil {
+		res, err = marshalValuesWithDocs(values, docs)
+	} else {
+		res, err = yaml.Marshal(values)
+	}
 	if err != nil {
 		return fmt.Errorf("%w: unable to write marshal values.yaml", err)
 	}
+	return w.write("values.yaml", res)
+}
+
+func overwriteEnvValuesFile(w *chartWriter, env helmify.EnvValues) error {
+	filename := fmt.Sprintf("values-%s.yaml", env.Env)
+	res := []byte(fmt.Sprintf("# Values for %[1]s environment. Usage: helm install -f %[2]s\n", env.Env, filename))
+	if len(env.Values) != 0 {
+		values, err := yaml.Marshal(env.Values)
+		if err != nil {
+			return fmt.Errorf("%w: unable to marshal %s", err, filename)
+		}
+		res = append(res, values...)
+	}
+	return w.write(filename, res)
+}
 
-	file := filepath.Join(chartDir, "values.yaml")
-	err = os.WriteFile(file, res, 0600)
+func overwriteSchemaFile(w *chartWriter, values helmify.Values, templates []helmify.Template) error {
+	// helpers reference values missing in values.yaml, e.g. nameOverride
+	rendered := []string{defaultHelpers}
+	for _, t := range templates {
+		buf := bytes.Buffer{}
+		err := t.Write(&buf)
+		if err != nil {
+			return fmt.Errorf("%w: unable to render template", err)
+		}
+		rendered = append(rendered, buf.String())
+	}
+	res, err := schema.Generate(values, schema.RequiredValues(rendered...), schema.ReferencedValues(rendered...))
 	if err != nil {
-		return fmt.Errorf("%w: unable to write values.yaml", err)
+		return err
 	}
-	logrus.WithField("file", file).Info("overwritten")
-	return nil
+	return w.write("values.schema.json", res)
 }
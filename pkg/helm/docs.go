@@ -0,0 +1,157 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/cluster"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/iancoleman/strcase"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+)
+
+// specDocs - OpenAPI descriptions of k8s objects spec fields.
+var specDocs = map[string]map[string]string{
+	"Deployment":            appsv1.DeploymentSpec{}.SwaggerDoc(),
+	"StatefulSet":           appsv1.StatefulSetSpec{}.SwaggerDoc(),
+	"DaemonSet":             appsv1.DaemonSetSpec{}.SwaggerDoc(),
+	"Job":                   batchv1.JobSpec{}.SwaggerDoc(),
+	"CronJob":               batchv1.CronJobSpec{}.SwaggerDoc(),
+	"Service":               corev1.ServiceSpec{}.SwaggerDoc(),
+	"PersistentVolumeClaim": corev1.PersistentVolumeClaimSpec{}.SwaggerDoc(),
+	"PodDisruptionBudget":   policyv1.PodDisruptionBudgetSpec{}.SwaggerDoc(),
+}
+
+var (
+	podDocs       = corev1.PodSpec{}.SwaggerDoc()
+	containerDocs = corev1.Container{}.SwaggerDoc()
+	hpaDocs       = autoscalingv2.HorizontalPodAutoscalerSpec{}.SwaggerDoc()
+)
+
+// globalDocs - descriptions of values which are not related to a particular k8s object.
+var globalDocs = map[string]string{
+	cluster.DomainKey:         "Kubernetes cluster domain used by the chart workloads",
+	"imagePullSecrets":        podDocs["imagePullSecrets"],
+	"certmanager.enabled":     "Install cert-manager subchart",
+	"certmanager.installCRDs": "Install cert-manager CRDs with cert-manager subchart",
+}
+
+// valuesDocs - describes values keys with their origin and k8s field descriptions.
+type valuesDocs struct {
+	// sources - k8s object which created the value. Key is dot separated values path.
+	sources map[string]helmify.Source
+}
+
+func newValuesDocs(templates []helmify.Template, sources []helmify.Source) *valuesDocs {
+	res := &valuesDocs{sources: map[string]helmify.Source{}}
+	for i, t := range templates {
+		if i >= len(sources) {
+			break
+		}
+		for key := range t.Values() {
+			if _, ok := res.sources[key]; !ok {
+				res.sources[key] = sources[i]
+			}
+		}
+	}
+	return res
+}
+
+// comment - returns comment for values key and true if key descendants are covered by this comment.
+func (d *valuesDocs) comment(path []string, isLeaf bool) (string, bool) {
+	if doc, ok := globalDocs[strings.Join(path, ".")]; ok {
+		return "-- " + firstSentence(doc), true
+	}
+	src, ok := d.sources[path[0]]
+	if !ok {
+		return "", false
+	}
+	if len(path) == 1 {
+		return fmt.Sprintf("%s %s", src.Kind, src.Name), false
+	}
+	if path[1] == "autoscaling" {
+		return autoscalingDoc(path[2:])
+	}
+	field, doc, documentsChildren := fieldDoc(src, path[1:])
+	if len(path) == 2 && field == "" {
+		// container key
+		return fmt.Sprintf("%s container of %s %s", path[1], src.Kind, src.Name), false
+	}
+	if !isLeaf && !documentsChildren {
+		return "", false
+	}
+	origin := fmt.Sprintf("Generated from %s %s `%s`.", src.Kind, src.Name, field)
+	if doc == "" {
+		return "-- " + origin, documentsChildren
+	}
+	return "-- " + firstSentence(doc) + "\n" + origin, documentsChildren
+}
+
+// fieldDoc - converts values path relative to the object key into k8s object field path and its description.
+// Returns true if description covers nested values as well.
+func fieldDoc(src helmify.Source, path []string) (string, string, bool) {
+	podPrefix := "spec.template.spec"
+	if src.Kind == "CronJob" {
+		podPrefix = "spec.jobTemplate.spec.template.spec"
+	}
+	for _, c := range src.Containers {
+		if strcase.ToLowerCamel(c) != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return "", "", false
+		}
+		containerPrefix := fmt.Sprintf("%s.containers[%s]", podPrefix, c)
+		switch path[1] {
+		case "image":
+			return containerPrefix + ".image", "Container image " + strings.Join(path[2:], "."), false
+		case "env":
+			return containerPrefix + ".env", "Value of container environment variable " + strings.Join(path[2:], "."), false
+		case "containerSecurityContext":
+			return containerPrefix + ".securityContext", containerDocs["securityContext"], true
+		}
+		doc, ok := containerDocs[path[1]]
+		return containerPrefix + "." + strings.Join(path[1:], "."), doc, ok
+	}
+	if path[0] == "podSecurityContext" {
+		return podPrefix + ".securityContext", podDocs["securityContext"], true
+	}
+	if doc, ok := podDocs[path[0]]; ok {
+		return podPrefix + "." + strings.Join(path, "."), doc, true
+	}
+	switch src.Kind {
+	case "ConfigMap", "Secret":
+		return "data." + strings.Join(path, "."), "", false
+	}
+	doc, ok := specDocs[src.Kind][path[0]]
+	return "spec." + strings.Join(path, "."), doc, ok && len(path) == 1
+}
+
+// autoscalingDoc - describes workload autoscaling values which are shared by the workload and its HorizontalPodAutoscaler.
+func autoscalingDoc(path []string) (string, bool) {
+	if len(path) == 0 {
+		return "", false
+	}
+	if path[0] == "enabled" {
+		return "-- Enable HorizontalPodAutoscaler. Workload replicas are omitted if enabled.", true
+	}
+	origin := fmt.Sprintf("Generated from HorizontalPodAutoscaler `spec.%s`.", path[0])
+	doc, ok := hpaDocs[path[0]]
+	if !ok {
+		return "-- " + origin, true
+	}
+	return "-- " + firstSentence(doc) + "\n" + origin, true
+}
+
+// firstSentence - shortens long OpenAPI descriptions.
+func firstSentence(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i > 0 {
+		return doc[:i+1]
+	}
+	return doc
+}
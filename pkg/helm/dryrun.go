@@ -0,0 +1,150 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/diff"
+)
+
+// ErrChangesPending - returned in diff mode if generated chart differs from the chart directory.
+var ErrChangesPending = errors.New("generated chart differs from chart directory")
+
+// diffContext - number of unchanged lines around changes in diff output.
+const diffContext = 3
+
+// initChart - adds chart skeleton files to dry-run output if chart does not exist.
+func (w *chartWriter) initChart(chartName string, certManagerAsSubchart bool, certManagerVersion string) error {
+	if err := validateChartName(chartName); err != nil {
+		return err
+	}
+	_, err := os.Stat(filepath.Join(w.chartDir, "Chart.yaml"))
+	if !os.IsNotExist(err) {
+		return err
+	}
+	for path, content := range map[string][]byte{
+		"Chart.yaml":  chartYAML(chartName, certManagerAsSubchart, certManagerVersion),
+		".helmignore": []byte(helmIgnore),
+		filepath.Join("templates", "_helpers.tpl"): helpersYAML(chartName),
+	} {
+		if err = w.write(path, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// print - prints files generated in dry-run mode or their unified diff with chart directory.
+// Returns true if diff is requested and generated files differ from chart directory.
+func (w *chartWriter) print(out io.Writer, showDiff bool) (bool, error) {
+	if !showDiff {
+		return false, w.printFiles(out)
+	}
+	return w.printDiff(out)
+}
+
+// printFiles - prints generated files in path order, each one preceded by its path.
+func (w *chartWriter) printFiles(out io.Writer) error {
+	name := filepath.Base(w.chartDir)
+	for _, path := range sortedKeys(w.files) {
+		content := string(w.files[path])
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		_, err := fmt.Fprintf(out, "---\n# Source: %s\n%s", filepath.ToSlash(filepath.Join(name, path)), content)
+		if err != nil {
+			return fmt.Errorf("%w: unable to print %s", err, path)
+		}
+	}
+	return nil
+}
+
+// printDiff - prints unified diff of chart directory files and generated files. Template files missing
+// in generated chart are shown as removed. Returns true if there are differences.
+func (w *chartWriter) printDiff(out io.Writer) (bool, error) {
+	local, err := w.localTemplates()
+	if err != nil {
+		return false, err
+	}
+	paths := sortedKeys(w.files)
+	for _, path := range local {
+		if _, ok := w.files[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	name := filepath.Base(w.chartDir)
+	changed := false
+	for _, path := range paths {
+		file := filepath.ToSlash(filepath.Join(name, path))
+		from, to := "a/"+file, "b/"+file
+		current, err := os.ReadFile(filepath.Join(w.chartDir, path))
+		switch {
+		case os.IsNotExist(err):
+			from = "/dev/null"
+		case err != nil:
+			return false, fmt.Errorf("%w: unable to read %s", err, path)
+		}
+		generated, ok := w.files[path]
+		if !ok {
+			to = "/dev/null"
+		}
+		res := diff.Unified(from, to, string(current), string(generated), diffContext)
+		if res == "" && (from == "/dev/null") == (to == "/dev/null") {
+			continue
+		}
+		if res == "" {
+			// empty file added or removed.
+			res = fmt.Sprintf("--- %s\n+++ %s\n", from, to)
+		}
+		changed = true
+		if _, err = io.WriteString(out, res); err != nil {
+			return false, fmt.Errorf("%w: unable to print diff of %s", err, path)
+		}
+	}
+	return changed, nil
+}
+
+// localTemplates - returns paths of template and CRD files in chart directory relative to it.
+// Template partials and NOTES.txt are never generated and ignored.
+func (w *chartWriter) localTemplates() ([]string, error) {
+	var res []string
+	for _, dir := range []string{"templates", "crds"} {
+		err := filepath.WalkDir(filepath.Join(w.chartDir, dir), func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || strings.HasPrefix(d.Name(), "_") || d.Name() == "NOTES.txt" {
+				return nil
+			}
+			rel, err := filepath.Rel(w.chartDir, path)
+			if err != nil {
+				return err
+			}
+			res = append(res, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read chart %s dir", err, dir)
+		}
+	}
+	return res, nil
+}
+
+func sortedKeys(files map[string][]byte) []string {
+	res := make([]string, 0, len(files))
+	for path := range files {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,80 @@
+package helm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_chartWriter_printDiff(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my-chart")
+	for path, content := range map[string]string{
+		"values.yaml":                  "a: 1\nb: 2\n",
+		"templates/_helpers.tpl":       "{{/* helpers */}}\n",
+		"templates/NOTES.txt":          "notes\n",
+		"templates/deployment.yaml":    "kind: Deployment\n",
+		"templates/old-configmap.yaml": "kind: ConfigMap\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0600))
+	}
+	w := &chartWriter{chartDir: dir, dryRun: true}
+
+	t.Run("no changes", func(t *testing.T) {
+		require.NoError(t, w.write("values.yaml", []byte("a: 1\nb: 2\n")))
+		require.NoError(t, w.write("templates/deployment.yaml", []byte("kind: Deployment\n")))
+		require.NoError(t, w.write("templates/old-configmap.yaml", []byte("kind: ConfigMap\n")))
+		out := bytes.Buffer{}
+		changed, err := w.printDiff(&out)
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.Empty(t, out.String())
+	})
+	t.Run("changed, new and removed files", func(t *testing.T) {
+		w.files = nil
+		require.NoError(t, w.write("values.yaml", []byte("a: 1\nb: 3\n")))
+		require.NoError(t, w.write("templates/deployment.yaml", []byte("kind: Deployment\n")))
+		require.NoError(t, w.write("templates/service.yaml", []byte("kind: Service\n")))
+		out := bytes.Buffer{}
+		changed, err := w.printDiff(&out)
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, `--- a/my-chart/templates/old-configmap.yaml
++++ /dev/null
+@@ -1 +0,0 @@
+-kind: ConfigMap
+--- /dev/null
++++ b/my-chart/templates/service.yaml
+@@ -0,0 +1 @@
++kind: Service
+--- a/my-chart/values.yaml
++++ b/my-chart/values.yaml
+@@ -1,2 +1,2 @@
+ a: 1
+-b: 2
++b: 3
+`, out.String())
+	})
+	t.Run("files are not written", func(t *testing.T) {
+		assert.NoFileExists(t, filepath.Join(dir, "templates", "service.yaml"))
+		content, err := os.ReadFile(filepath.Join(dir, "values.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "a: 1\nb: 2\n", string(content))
+	})
+}
+
+func Test_chartWriter_printFiles(t *testing.T) {
+	w := &chartWriter{chartDir: filepath.Join(t.TempDir(), "my-chart"), dryRun: true}
+	require.NoError(t, w.initChart("my-chart", false, ""))
+	require.NoError(t, w.write("values.yaml", []byte("a: 1")))
+	assert.NoDirExists(t, w.chartDir)
+	assert.Contains(t, w.files, filepath.Join("templates", "_helpers.tpl"))
+	out := bytes.Buffer{}
+	require.NoError(t, w.printFiles(&out))
+	assert.Contains(t, out.String(), "---\n# Source: my-chart/Chart.yaml\napiVersion: v2\nname: my-chart\n")
+	assert.Contains(t, out.String(), "---\n# Source: my-chart/values.yaml\na: 1\n")
+}
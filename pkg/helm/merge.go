@@ -0,0 +1,148 @@
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/diff"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrMergeConflict - returned when generated chart files conflict with local changes.
+var ErrMergeConflict = errors.New("merge conflicts between generated and locally changed files")
+
+// generatedDir - directory inside the chart where files generated on the previous run are stored.
+// Used as a base for three-way merge of local changes and newly generated files.
+const generatedDir = ".helmify"
+
+// chartWriter writes generated files into chart directory.
+type chartWriter struct {
+	chartDir string
+	// merge - preserve local changes of generated files with three-way merge.
+	merge bool
+	// conflicts - files written with merge conflict markers.
+	conflicts []string
+	// dryRun - keep files in memory instead of writing them into chart directory.
+	dryRun bool
+	// files - file contents generated in dry-run mode by path relative to the chart directory.
+	files map[string][]byte
+}
+
+// write - writes generated file content. Path is relative to the chart directory.
+// In dry-run mode content is stored in memory.
+func (w *chartWriter) write(path string, generated []byte) error {
+	file := filepath.Join(w.chartDir, path)
+	content := generated
+	if w.merge {
+		merged, skip, err := w.mergeLocal(path, generated)
+		if err != nil {
+			return err
+		}
+		if !w.dryRun {
+			if err = w.saveGenerated(path, generated); err != nil {
+				return err
+			}
+		}
+		if skip {
+			logrus.WithField("file", file).Info("skipped: removed locally")
+			return nil
+		}
+		content = merged
+	}
+	if w.dryRun {
+		if w.files == nil {
+			w.files = map[string][]byte{}
+		}
+		w.files[path] = content
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(file), 0750)
+	if err != nil {
+		return fmt.Errorf("%w: unable create %s dir", err, filepath.Dir(file))
+	}
+	err = os.WriteFile(file, content, 0600)
+	if err != nil {
+		return fmt.Errorf("%w: unable to write %s", err, file)
+	}
+	logrus.WithField("file", file).Info("overwritten")
+	return nil
+}
+
+// mergeLocal - merges generated content with local file using previously generated file as a base.
+// Returns skip=true if file was removed locally and generated content was not changed since the previous run.
+func (w *chartWriter) mergeLocal(path string, generated []byte) (merged []byte, skip bool, err error) {
+	file := filepath.Join(w.chartDir, path)
+	local, err := os.ReadFile(file)
+	localExists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("%w: unable to read %s", err, file)
+	}
+	base, err := os.ReadFile(filepath.Join(w.chartDir, generatedDir, path))
+	baseExists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, false, fmt.Errorf("%w: unable to read previously generated %s", err, path)
+	}
+	switch {
+	case !localExists && baseExists:
+		return generated, bytes.Equal(base, generated), nil
+	case !localExists, bytes.Equal(local, generated):
+		return generated, false, nil
+	}
+	var res string
+	var conflict bool
+	if baseExists {
+		res, conflict = diff.Merge(string(base), string(local), string(generated))
+	} else {
+		logrus.WithField("file", file).Warn("no previously generated version found: merging without base")
+		res, conflict = diff.MergeTwoWay(string(local), string(generated))
+	}
+	if conflict {
+		logrus.WithField("file", file).Warn("merge conflict")
+		w.conflicts = append(w.conflicts, path)
+	}
+	return []byte(res), false, nil
+}
+
+// saveGenerated - stores generated file as a base for the next run.
+func (w *chartWriter) saveGenerated(path string, generated []byte) error {
+	file := filepath.Join(w.chartDir, generatedDir, path)
+	err := os.MkdirAll(filepath.Dir(file), 0750)
+	if err != nil {
+		return fmt.Errorf("%w: unable create %s dir", err, generatedDir)
+	}
+	err = os.WriteFile(file, generated, 0600)
+	if err != nil {
+		return fmt.Errorf("%w: unable to write %s", err, file)
+	}
+	return nil
+}
+
+// ignoreGeneratedDir - adds generated files dir to chart .helmignore if missing. Used in merge mode only.
+func ignoreGeneratedDir(chartDir string) error {
+	file := filepath.Join(chartDir, ".helmignore")
+	content, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: unable to read %s", err, file)
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == generatedDir+"/" {
+			return nil
+		}
+	}
+	if len(content) != 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(generatedDir+"/\n")...)
+	err = os.WriteFile(file, content, 0640)
+	if err != nil {
+		return fmt.Errorf("%w: unable to write %s", err, file)
+	}
+	return nil
+}
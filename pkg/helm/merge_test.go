@@ -0,0 +1,61 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_chartWriter_write(t *testing.T) {
+	dir := t.TempDir()
+	read := func(path string) string {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		require.NoError(t, err)
+		return string(content)
+	}
+	w := &chartWriter{chartDir: dir, merge: true}
+
+	t.Run("first run writes generated file", func(t *testing.T) {
+		require.NoError(t, w.write("values.yaml", []byte("a: 1\nb: 2\nc: 3\n")))
+		assert.Equal(t, "a: 1\nb: 2\nc: 3\n", read("values.yaml"))
+		assert.Equal(t, "a: 1\nb: 2\nc: 3\n", read(filepath.Join(generatedDir, "values.yaml")))
+	})
+	t.Run("local changes preserved", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("a: local\nb: 2\nc: 3\n"), 0600))
+		require.NoError(t, w.write("values.yaml", []byte("a: 1\nb: 2\nc: 30\n")))
+		assert.Equal(t, "a: local\nb: 2\nc: 30\n", read("values.yaml"))
+		assert.Equal(t, "a: 1\nb: 2\nc: 30\n", read(filepath.Join(generatedDir, "values.yaml")))
+		assert.Empty(t, w.conflicts)
+	})
+	t.Run("conflicts reported", func(t *testing.T) {
+		require.NoError(t, w.write("values.yaml", []byte("a: 10\nb: 2\nc: 30\n")))
+		assert.Contains(t, read("values.yaml"), "<<<<<<< local\na: local\n=======\na: 10\n>>>>>>> generated\n")
+		assert.Equal(t, []string{"values.yaml"}, w.conflicts)
+	})
+	t.Run("locally removed file is not recreated", func(t *testing.T) {
+		require.NoError(t, w.write("removed.yaml", []byte("a: 1\n")))
+		require.NoError(t, os.Remove(filepath.Join(dir, "removed.yaml")))
+		require.NoError(t, w.write("removed.yaml", []byte("a: 1\n")))
+		assert.NoFileExists(t, filepath.Join(dir, "removed.yaml"))
+	})
+	t.Run("file without base merged two-way", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "nobase.yaml"), []byte("a: 1\nlocal: 1\nb: 2\n"), 0600))
+		require.NoError(t, w.write("nobase.yaml", []byte("a: 1\nb: 2\ngenerated: 1\n")))
+		assert.Equal(t, "a: 1\nlocal: 1\nb: 2\ngenerated: 1\n", read("nobase.yaml"))
+		assert.Equal(t, []string{"values.yaml"}, w.conflicts)
+	})
+}
+
+func Test_ignoreGeneratedDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, ".helmignore")
+	require.NoError(t, os.WriteFile(file, []byte(".git/"), 0600))
+	require.NoError(t, ignoreGeneratedDir(dir))
+	require.NoError(t, ignoreGeneratedDir(dir))
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, ".git/\n.helmify/\n", string(content))
+}
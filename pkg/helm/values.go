@@ -0,0 +1,74 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"gopkg.in/yaml.v3"
+)
+
+// marshalValuesWithDocs - marshals values.yaml with comments describing origin of each key.
+// Descriptions are formatted for https://github.com/norwoodj/helm-docs.
+func marshalValuesWithDocs(values helmify.Values, docs *valuesDocs) ([]byte, error) {
+	root, err := docNode(nil, map[string]interface{}(values), docs)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.Buffer{}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(root)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal values.yaml", err)
+	}
+	err = enc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal values.yaml", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// docNode - converts value into yaml node. Map keys are sorted and commented if docs is not nil.
+func docNode(path []string, value interface{}, docs *valuesDocs) (*yaml.Node, error) {
+	obj, isMap := value.(map[string]interface{})
+	if !isMap {
+		if v, ok := value.(helmify.Values); ok {
+			obj, isMap = v, true
+		}
+	}
+	if !isMap {
+		node := &yaml.Node{}
+		err := node.Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to encode value %v", err, path)
+		}
+		return node, nil
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		keyPath := append(path[:len(path):len(path)], k)
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+		childDocs := docs
+		if docs != nil {
+			_, childIsMap := obj[k].(map[string]interface{})
+			comment, coversChildren := docs.comment(keyPath, !childIsMap)
+			keyNode.HeadComment = comment
+			if coversChildren {
+				childDocs = nil
+			}
+		}
+		valNode, err := docNode(keyPath, obj[k], childDocs)
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, keyNode, valNode)
+	}
+	return node, nil
+}
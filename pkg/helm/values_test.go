@@ -0,0 +1,57 @@
+package helm
+
+import (
+	"io"
+	"testing"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTemplate struct {
+	values helmify.Values
+}
+
+func (t testTemplate) Filename() string             { return "test.yaml" }
+func (t testTemplate) Values() helmify.Values       { return t.values }
+func (t testTemplate) Write(writer io.Writer) error { return nil }
+
+func Test_marshalValuesWithDocs(t *testing.T) {
+	values := helmify.Values{
+		"kubernetesClusterDomain": "cluster.local",
+		"myapp": map[string]interface{}{
+			"replicas": int64(1),
+			"app": map[string]interface{}{
+				"env": map[string]interface{}{"logLevel": "debug"},
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{"cpu": "100m"},
+				},
+			},
+		},
+	}
+	templates := []helmify.Template{testTemplate{values: helmify.Values{"myapp": values["myapp"]}}}
+	sources := []helmify.Source{{APIVersion: "apps/v1", Kind: "Deployment", Name: "my-myapp", Containers: []string{"app"}}}
+
+	res, err := marshalValuesWithDocs(values, newValuesDocs(templates, sources))
+	require.NoError(t, err)
+	assert.Equal(t, `# -- Kubernetes cluster domain used by the chart workloads
+kubernetesClusterDomain: cluster.local
+# Deployment my-myapp
+myapp:
+  # app container of Deployment my-myapp
+  app:
+    env:
+      # -- Value of container environment variable logLevel
+      # Generated from Deployment my-myapp `+"`spec.template.spec.containers[app].env`"+`.
+      logLevel: debug
+    # -- Compute Resources required by this container.
+    # Generated from Deployment my-myapp `+"`spec.template.spec.containers[app].resources`"+`.
+    resources:
+      limits:
+        cpu: 100m
+  # -- Number of desired pods.
+  # Generated from Deployment my-myapp `+"`spec.replicas`"+`.
+  replicas: 1
+`, string(res))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/arttor/helmify/pkg/config"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -28,7 +29,24 @@ type Template interface {
 
 // Output - converts Template into helm chart on disk.
 type Output interface {
-	Create(chartName, chartDir string, Crd bool, certManagerAsSubchart bool, certManagerVersion string, certManagerInstallCRD bool, templates []Template, filenames []string) error
+	// Create - writes templates into chart files. filenames and sources are set for each template.
+	// envValues are written into additional per-environment values files.
+	Create(conf config.Config, templates []Template, filenames []string, sources []Source, envValues []EnvValues) error
+}
+
+// EnvValues - values overriding values.yaml for a particular environment.
+type EnvValues struct {
+	Env    string
+	Values Values
+}
+
+// Source - describes k8s object a Template was generated from.
+type Source struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	// Containers - names of pod template containers and init containers for workload objects.
+	Containers []string
 }
 
 // AppMetadata handle common information about K8s objects in the chart.
@@ -42,11 +60,20 @@ type AppMetadata interface {
 	//				"my-app-secret"		-> "{{ include "chart.fullname" . }}-secret"
 	//				etc...
 	TemplatedName(objName string) string
+	// TemplatedNames returns templated names of all app objects by their original names.
+	TemplatedNames() map[string]string
 	// TemplatedString converts a string to templated string with chart name.
 	TemplatedString(str string) string
 	// TrimName trims common prefix from object name if exists.
 	// We trim common prefix because helm already using release for this purpose.
 	TrimName(objName string) string
+	// Autoscaled returns true if workload with given kind and name is a HorizontalPodAutoscaler target.
+	Autoscaled(kind, name string) bool
+	// Selects returns true if label selector matches labels of any app object of given kind.
+	// Pod kind matches pod template labels of app workloads.
+	Selects(kind string, selector *metav1.LabelSelector) bool
+	// Rule returns project config rule options for the object. Returns empty rule if no rule matches the object.
+	Rule(obj *unstructured.Unstructured) config.Rule
 
 	Config() config.Config
 }
@@ -3,6 +3,7 @@ package helmify
 import (
 	"dario.cat/mergo"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -85,6 +86,55 @@ func (v *Values) AddSecret(toBase64 bool, name ...string) (string, error) {
 	return res + " | quote }}", err
 }
 
+// Diff - returns values which are set in given values and differ from current instance.
+// Nested maps are compared key by key. Lists and scalars are compared as a whole.
+// Nested keys missing in given values are returned as nil, so they are removed from current values
+// when the result is used as helm values override. Missing top-level keys belong to objects
+// absent in given values and are skipped.
+func (v Values) Diff(values Values) Values {
+	return diff(v, values, false)
+}
+
+func diff(base, values map[string]interface{}, removeMissing bool) Values {
+	res := Values{}
+	for k, val := range values {
+		baseVal, exists := base[k]
+		if !exists {
+			res[k] = val
+			continue
+		}
+		baseMap, baseIsMap := toMap(baseVal)
+		valMap, valIsMap := toMap(val)
+		if baseIsMap && valIsMap {
+			if d := diff(baseMap, valMap, true); len(d) != 0 {
+				res[k] = map[string]interface{}(d)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(baseVal, val) {
+			res[k] = val
+		}
+	}
+	if removeMissing {
+		for k := range base {
+			if _, exists := values[k]; !exists {
+				res[k] = nil
+			}
+		}
+	}
+	return res
+}
+
+func toMap(val interface{}) (map[string]interface{}, bool) {
+	switch m := val.(type) {
+	case map[string]interface{}:
+		return m, true
+	case Values:
+		return m, true
+	}
+	return nil, false
+}
+
 func toCamelCase(name []string) []string {
 	for i, n := range name {
 		camelCase := strcase.ToLowerCamel(n)
@@ -82,3 +82,45 @@ func TestValues_AddSecret(t *testing.T) {
 		assert.NotContains(t, res, "b64enc")
 	})
 }
+
+func TestValues_Diff(t *testing.T) {
+	base := Values{
+		"app": map[string]interface{}{
+			"replicas": int64(1),
+			"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+			"args":     []interface{}{"a", "b"},
+		},
+		"domain": "cluster.local",
+	}
+	env := Values{
+		"app": map[string]interface{}{
+			"replicas": int64(3),
+			"image":    map[string]interface{}{"repository": "nginx", "tag": "1.0"},
+			"args":     []interface{}{"a"},
+		},
+		"domain": "cluster.local",
+		"extra":  true,
+	}
+	assert.Equal(t, Values{
+		"app": map[string]interface{}{
+			"replicas": int64(3),
+			"args":     []interface{}{"a"},
+		},
+		"extra": true,
+	}, base.Diff(env))
+	assert.Empty(t, base.Diff(base))
+	t.Run("removed keys", func(t *testing.T) {
+		env := Values{
+			"app": map[string]interface{}{
+				"replicas": int64(1),
+				"image":    map[string]interface{}{"repository": "nginx"},
+			},
+		}
+		assert.Equal(t, Values{
+			"app": map[string]interface{}{
+				"image": map[string]interface{}{"tag": nil},
+				"args":  nil,
+			},
+		}, base.Diff(env))
+	})
+}
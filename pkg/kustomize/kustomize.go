@@ -0,0 +1,155 @@
+// Package kustomize builds k8s manifests from kustomization directory.
+package kustomize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// originAnnotation - added by kustomize to track file where the resource was declared.
+const originAnnotation = "config.kubernetes.io/origin"
+
+// Build - builds kustomization in the given directory and calls fn for every resulting object.
+// filename is the path of the file declaring the object relative to kustomization directory.
+// It is empty for objects created by generators.
+func Build(dir string, fn func(obj *unstructured.Unstructured, filename string)) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("%w: unable to resolve kustomization dir %s", err, dir)
+	}
+	fs := originFS{FileSystem: filesys.MakeFsOnDisk(), root: absDir}
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, absDir)
+	if err != nil {
+		return fmt.Errorf("%w: unable to build kustomization %s", err, dir)
+	}
+	origins := make([]string, 0, len(resources.Resources()))
+	for _, res := range resources.Resources() {
+		origin, err := res.GetOrigin()
+		if err != nil {
+			return fmt.Errorf("%w: unable to read origin of %s", err, res.CurId())
+		}
+		path := ""
+		if origin != nil && origin.Repo == "" && origin.Path != "" {
+			path = origin.Path
+		}
+		origins = append(origins, path)
+	}
+	filenames := originPaths(origins)
+	for i, res := range resources.Resources() {
+		data, err := res.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("%w: unable to marshal %s", err, res.CurId())
+		}
+		u := &unstructured.Unstructured{}
+		err = u.UnmarshalJSON(data)
+		if err != nil {
+			return fmt.Errorf("%w: unable to decode %s", err, res.CurId())
+		}
+		annotations := u.GetAnnotations()
+		delete(annotations, originAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		u.SetAnnotations(annotations)
+		fn(u, filenames[origins[i]])
+	}
+	return nil
+}
+
+// outsidePrefix - marks each parent directory level of bases located outside kustomization dir
+// if their paths collide with other origin paths.
+const outsidePrefix = "_base/"
+
+// originPaths - makes paths of resource origins relative to kustomization dir.
+// Bases located outside the dir are placed alongside. If such path collides with another origin,
+// each parent directory level is replaced with "_base/" prefix, e.g. "../common/x.yaml" -> "_base/common/x.yaml".
+func originPaths(origins []string) map[string]string {
+	res := map[string]string{"": ""}
+	used := map[string]map[string]struct{}{}
+	for _, o := range origins {
+		if o == "" {
+			continue
+		}
+		path, _ := originPath(o)
+		if used[path] == nil {
+			used[path] = map[string]struct{}{}
+		}
+		used[path][filepath.Clean(o)] = struct{}{}
+	}
+	for _, o := range origins {
+		if o == "" {
+			continue
+		}
+		path, depth := originPath(o)
+		if len(used[path]) > 1 {
+			path = strings.Repeat(outsidePrefix, depth) + path
+		}
+		res[o] = path
+	}
+	return res
+}
+
+// originPath - strips parent directory elements from origin path. Returns the path and number of stripped elements.
+func originPath(path string) (string, int) {
+	path = filepath.ToSlash(filepath.Clean(path))
+	depth := 0
+	for strings.HasPrefix(path, "../") {
+		path = strings.TrimPrefix(path, "../")
+		depth++
+	}
+	return path, depth
+}
+
+// originFS - enables kustomize origin annotations for the root kustomization without changing it on disk.
+type originFS struct {
+	filesys.FileSystem
+	root string
+}
+
+func (f originFS) ReadFile(path string) ([]byte, error) {
+	content, err := f.FileSystem.ReadFile(path)
+	if err != nil || filepath.Dir(path) != f.root || !isKustomizationFile(filepath.Base(path)) {
+		return content, err
+	}
+	kustomization := map[string]interface{}{}
+	err = yaml.Unmarshal(content, &kustomization)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to parse %s", err, path)
+	}
+	buildMetadata, _ := kustomization["buildMetadata"].([]interface{})
+	for _, m := range buildMetadata {
+		if m == types.OriginAnnotations {
+			return content, nil
+		}
+	}
+	kustomization["buildMetadata"] = append(buildMetadata, types.OriginAnnotations)
+	return yaml.Marshal(kustomization)
+}
+
+// IsKustomization - returns true if given path is a directory containing kustomization file.
+func IsKustomization(path string) bool {
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		if info, err := os.Stat(filepath.Join(path, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+func isKustomizationFile(name string) bool {
+	for _, n := range konfig.RecognizedKustomizationFileNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
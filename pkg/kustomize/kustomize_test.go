@@ -0,0 +1,66 @@
+package kustomize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuild(t *testing.T) {
+	files := map[string]string{}
+	err := Build("../../test_data/kustomize/overlays/prod", func(obj *unstructured.Unstructured, filename string) {
+		files[obj.GetKind()+"/"+obj.GetName()] = filename
+		assert.NotContains(t, obj.GetAnnotations(), originAnnotation)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Deployment/my-app-web":          "base/deployment.yaml",
+		"Service/my-app-web":             "base/service.yaml",
+		"PodDisruptionBudget/my-app-web": "pdb.yaml",
+		"ConfigMap/my-app-settings":      "",
+	}, files)
+}
+
+func TestBuild_NotExists(t *testing.T) {
+	err := Build("../../test_data/kustomize/not-exists", func(*unstructured.Unstructured, string) {})
+	assert.Error(t, err)
+}
+
+func TestBuild_OutsideBaseCollision(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"common/kustomization.yaml": "resources:\n- x.yaml\n",
+		"common/x.yaml":             "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: shared\n",
+		"app/kustomization.yaml":    "resources:\n- ../common\n- common/x.yaml\n",
+		"app/common/x.yaml":         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: local\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(content), 0600))
+	}
+	files := map[string]string{}
+	err := Build(filepath.Join(dir, "app"), func(obj *unstructured.Unstructured, filename string) {
+		files[obj.GetName()] = filename
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"shared": "_base/common/x.yaml", "local": "common/x.yaml"}, files)
+}
+
+func Test_originPaths(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"":                           "",
+		"../../base/deployment.yaml": "base/deployment.yaml",
+		"./pdb.yaml":                 "pdb.yaml",
+	}, originPaths([]string{"../../base/deployment.yaml", "./pdb.yaml", "", "./pdb.yaml"}))
+
+	assert.Equal(t, map[string]string{
+		"":                    "",
+		"../common/x.yaml":    "_base/common/x.yaml",
+		"../../common/x.yaml": "_base/_base/common/x.yaml",
+		"common/x.yaml":       "common/x.yaml",
+		"./common/x.yaml":     "common/x.yaml",
+	}, originPaths([]string{"../common/x.yaml", "common/x.yaml", "./common/x.yaml", "../../common/x.yaml"}))
+}
@@ -7,7 +7,9 @@ import (
 
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -19,6 +21,12 @@ var nsGVK = schema.GroupVersionKind{
 	Kind:    "Namespace",
 }
 
+var hpaGVK = schema.GroupVersionKind{
+	Group:   "autoscaling",
+	Version: "v2",
+	Kind:    "HorizontalPodAutoscaler",
+}
+
 var crdGVK = schema.GroupVersionKind{
 	Group:   "apiextensions.k8s.io",
 	Version: "v1",
@@ -26,14 +34,25 @@ var crdGVK = schema.GroupVersionKind{
 }
 
 func New(conf config.Config) *Service {
-	return &Service{names: make(map[string]struct{}), conf: conf}
+	return &Service{
+		names:         make(map[string]struct{}),
+		originalNames: make(map[string]struct{}),
+		autoscaled:    make(map[string]struct{}),
+		labels:        make(map[string][]labels.Set),
+		conf:          conf,
+	}
 }
 
 type Service struct {
 	commonPrefix string
 	namespace    string
 	names        map[string]struct{}
-	conf         config.Config
+	// originalNames - names of objects with original-name rule.
+	originalNames map[string]struct{}
+	autoscaled    map[string]struct{}
+	// labels - labels of app objects by kind. Pod template labels of workloads are stored as Pod labels.
+	labels map[string][]labels.Set
+	conf   config.Config
 }
 
 func (a *Service) Config() config.Config {
@@ -52,13 +71,32 @@ func (a *Service) TrimName(objName string) string {
 	return trimmed
 }
 
+// CommonPrefix - returns detected app objects names common prefix without trailing separators.
+func (a *Service) CommonPrefix() string {
+	return strings.TrimRight(a.commonPrefix, "-./_ ")
+}
+
 var _ helmify.AppMetadata = &Service{}
 
 // Load processed objects one-by-one before actual processing to define app namespace, name common prefix and
 // other app meta information.
 func (a *Service) Load(obj *unstructured.Unstructured) {
 	a.names[obj.GetName()] = struct{}{}
+	if a.Rule(obj).OriginalName {
+		a.originalNames[obj.GetName()] = struct{}{}
+	}
 	a.commonPrefix = detectCommonPrefix(obj, a.commonPrefix)
+	if obj.GroupVersionKind() == hpaGVK {
+		kind, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name")
+		a.autoscaled[kind+"/"+name] = struct{}{}
+	}
+	if l := obj.GetLabels(); len(l) != 0 {
+		a.labels[obj.GetKind()] = append(a.labels[obj.GetKind()], l)
+	}
+	if l := a.podTemplateLabels(obj); len(l) != 0 {
+		a.labels["Pod"] = append(a.labels["Pod"], l)
+	}
 	objNs := extractAppNamespace(obj)
 	if objNs == "" {
 		return
@@ -69,6 +107,45 @@ func (a *Service) Load(obj *unstructured.Unstructured) {
 	a.namespace = objNs
 }
 
+// Autoscaled returns true if loaded HorizontalPodAutoscaler targets the workload.
+func (a *Service) Autoscaled(kind, name string) bool {
+	_, ok := a.autoscaled[kind+"/"+name]
+	return ok
+}
+
+// Selects returns true if label selector matches labels of any loaded object of given kind.
+// Pod kind matches pod template labels of loaded workloads. Empty and invalid selectors never match.
+func (a *Service) Selects(kind string, selector *metav1.LabelSelector) bool {
+	if selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0) {
+		return false
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	for _, l := range a.labels[kind] {
+		if s.Matches(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// podTemplateLabels - returns pod template labels of workload or CronJob objects.
+func (a *Service) podTemplateLabels(obj *unstructured.Unstructured) labels.Set {
+	path := a.conf.TemplatePath(obj.GroupVersionKind().Group, obj.GetKind())
+	l, found, _ := unstructured.NestedStringMap(obj.Object, append(path, "metadata", "labels")...)
+	if !found {
+		l, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "jobTemplate", "spec", "template", "metadata", "labels")
+	}
+	return l
+}
+
+// Rule returns project config rule options for the object.
+func (a *Service) Rule(obj *unstructured.Unstructured) config.Rule {
+	return a.conf.Rule(obj.GetKind(), obj.GetName(), obj.GetLabels())
+}
+
 // Namespace returns detected app namespace.
 func (a *Service) Namespace() string {
 	return a.namespace
@@ -85,6 +162,9 @@ func (a *Service) TemplatedName(name string) string {
 	if a.conf.OriginalName {
 		return name
 	}
+	if _, ok := a.originalNames[name]; ok {
+		return name
+	}
 	_, contains := a.names[name]
 	if !contains {
 		// template only app objects
@@ -94,6 +174,15 @@ func (a *Service) TemplatedName(name string) string {
 	return fmt.Sprintf(nameTeml, a.conf.ChartName, name)
 }
 
+// TemplatedNames - returns templated names of all app objects by their original names.
+func (a *Service) TemplatedNames() map[string]string {
+	res := make(map[string]string, len(a.names))
+	for name := range a.names {
+		res[name] = a.TemplatedName(name)
+	}
+	return res
+}
+
 func (a *Service) TemplatedString(str string) string {
 	name := a.TrimName(str)
 	return fmt.Sprintf(nameTeml, a.conf.ChartName, name)
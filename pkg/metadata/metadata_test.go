@@ -7,6 +7,7 @@ import (
 
 	"github.com/arttor/helmify/internal"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -115,6 +116,42 @@ func Test_Service(t *testing.T) {
 		assert.Equal(t, "qwe", testSvc.TemplatedName("qwe"))
 		assert.NotEqual(t, "abc", testSvc.TemplatedName("abc"))
 	})
+	t.Run("template name: original name rule", func(t *testing.T) {
+		testSvc := New(config.Config{ChartName: "chart-name", Rules: []config.Rule{{Name: "abc-*", OriginalName: true}}})
+		testSvc.Load(createRes("abc-name", "ns"))
+		testSvc.Load(createRes("qwe", "ns"))
+		assert.True(t, testSvc.Rule(createRes("abc-name", "ns")).OriginalName)
+		assert.Equal(t, "abc-name", testSvc.TemplatedName("abc-name"))
+		assert.Equal(t, `{{ include "chart-name.fullname" . }}-qwe`, testSvc.TemplatedName("qwe"))
+	})
+	t.Run("selects", func(t *testing.T) {
+		testSvc := New(config.Config{})
+		testSvc.Load(internal.GenerateObj(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend`))
+		assert.True(t, testSvc.Selects("Pod", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}))
+		assert.True(t, testSvc.Selects("Pod", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"frontend", "backend"}},
+		}}))
+		assert.False(t, testSvc.Selects("Pod", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}))
+		assert.False(t, testSvc.Selects("Pod", &metav1.LabelSelector{}))
+		assert.False(t, testSvc.Selects("Pod", nil))
+		assert.False(t, testSvc.Selects("Service", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}))
+		testSvc.Load(internal.GenerateObj(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  labels:
+    app: web`))
+		assert.True(t, testSvc.Selects("Service", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}))
+	})
 }
 
 func createRes(name, ns string) *unstructured.Unstructured {
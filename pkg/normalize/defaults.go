@@ -0,0 +1,224 @@
+package normalize
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fieldDefault - value set by API server to the field omitted in the object.
+type fieldDefault struct {
+	path  []string
+	value interface{}
+}
+
+var (
+	rollingUpdate = map[string]interface{}{
+		"type":          "RollingUpdate",
+		"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+	}
+	dsRollingUpdate = map[string]interface{}{
+		"type":          "RollingUpdate",
+		"rollingUpdate": map[string]interface{}{"maxSurge": int64(0), "maxUnavailable": int64(1)},
+	}
+	stsRollingUpdate = map[string]interface{}{
+		"type":          "RollingUpdate",
+		"rollingUpdate": map[string]interface{}{"partition": int64(0)},
+	}
+)
+
+// kindDefaults - defaults of top level fields by object kind.
+var kindDefaults = map[string][]fieldDefault{
+	"apps/Deployment": {
+		{path: []string{"spec", "progressDeadlineSeconds"}, value: int64(600)},
+		{path: []string{"spec", "revisionHistoryLimit"}, value: int64(10)},
+		{path: []string{"spec", "strategy"}, value: rollingUpdate},
+	},
+	"apps/StatefulSet": {
+		{path: []string{"spec", "podManagementPolicy"}, value: "OrderedReady"},
+		{path: []string{"spec", "revisionHistoryLimit"}, value: int64(10)},
+		{path: []string{"spec", "updateStrategy"}, value: stsRollingUpdate},
+		{path: []string{"spec", "persistentVolumeClaimRetentionPolicy"}, value: map[string]interface{}{
+			"whenDeleted": "Retain", "whenScaled": "Retain",
+		}},
+	},
+	"apps/DaemonSet": {
+		{path: []string{"spec", "revisionHistoryLimit"}, value: int64(10)},
+		{path: []string{"spec", "updateStrategy"}, value: dsRollingUpdate},
+	},
+	"batch/Job": {
+		{path: []string{"spec", "backoffLimit"}, value: int64(6)},
+		{path: []string{"spec", "completionMode"}, value: "NonIndexed"},
+		{path: []string{"spec", "completions"}, value: int64(1)},
+		{path: []string{"spec", "parallelism"}, value: int64(1)},
+		{path: []string{"spec", "suspend"}, value: false},
+	},
+	"batch/CronJob": {
+		{path: []string{"spec", "concurrencyPolicy"}, value: "Allow"},
+		{path: []string{"spec", "failedJobsHistoryLimit"}, value: int64(1)},
+		{path: []string{"spec", "successfulJobsHistoryLimit"}, value: int64(3)},
+		{path: []string{"spec", "suspend"}, value: false},
+		{path: []string{"spec", "jobTemplate", "spec", "backoffLimit"}, value: int64(6)},
+		{path: []string{"spec", "jobTemplate", "spec", "completionMode"}, value: "NonIndexed"},
+		{path: []string{"spec", "jobTemplate", "spec", "suspend"}, value: false},
+	},
+	"/Service": {
+		{path: []string{"spec", "sessionAffinity"}, value: "None"},
+		{path: []string{"spec", "internalTrafficPolicy"}, value: "Cluster"},
+		{path: []string{"spec", "ipFamilyPolicy"}, value: "SingleStack"},
+		{path: []string{"spec", "ipFamilies"}, value: []interface{}{"IPv4"}},
+	},
+	"/PersistentVolumeClaim": {
+		{path: []string{"spec", "volumeMode"}, value: "Filesystem"},
+	},
+}
+
+// podSpecPaths - paths to pod specs of built-in kinds.
+var podSpecPaths = map[string][]string{
+	"/Pod":             {"spec"},
+	"apps/Deployment":  {"spec", "template", "spec"},
+	"apps/StatefulSet": {"spec", "template", "spec"},
+	"apps/DaemonSet":   {"spec", "template", "spec"},
+	"apps/ReplicaSet":  {"spec", "template", "spec"},
+	"batch/Job":        {"spec", "template", "spec"},
+	"batch/CronJob":    {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+var podDefaults = []fieldDefault{
+	{path: []string{"dnsPolicy"}, value: "ClusterFirst"},
+	{path: []string{"restartPolicy"}, value: "Always"},
+	{path: []string{"schedulerName"}, value: "default-scheduler"},
+	{path: []string{"securityContext"}, value: map[string]interface{}{}},
+	{path: []string{"terminationGracePeriodSeconds"}, value: int64(30)},
+}
+
+var containerDefaults = []fieldDefault{
+	{path: []string{"imagePullPolicy"}, value: "IfNotPresent"},
+	{path: []string{"terminationMessagePath"}, value: "/dev/termination-log"},
+	{path: []string{"terminationMessagePolicy"}, value: "File"},
+}
+
+var probeDefaults = []fieldDefault{
+	{path: []string{"timeoutSeconds"}, value: int64(1)},
+	{path: []string{"periodSeconds"}, value: int64(10)},
+	{path: []string{"successThreshold"}, value: int64(1)},
+	{path: []string{"failureThreshold"}, value: int64(3)},
+}
+
+// RemoveDefaults - removes fields equal to values set by API server when the fields are omitted.
+// Container imagePullPolicy is kept for images without tag or with latest tag as their default differs.
+func RemoveDefaults(obj *unstructured.Unstructured) {
+	kind := obj.GroupVersionKind().Group + "/" + obj.GetKind()
+	removeDefaults(obj.Object, kindDefaults[kind])
+	if kind == "/Service" {
+		removeServicePortDefaults(obj.Object)
+	}
+	path, ok := podSpecPaths[kind]
+	if !ok {
+		return
+	}
+	podSpec, ok, _ := unstructured.NestedMap(obj.Object, path...)
+	if !ok {
+		return
+	}
+	removePodDefaults(podSpec)
+	_ = unstructured.SetNestedMap(obj.Object, podSpec, path...)
+}
+
+func removePodDefaults(podSpec map[string]interface{}) {
+	removeDefaults(podSpec, podDefaults)
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := podSpec[field].([]interface{})
+		for _, c := range containers {
+			if container, ok := c.(map[string]interface{}); ok {
+				removeContainerDefaults(container)
+			}
+		}
+	}
+	volumes, _ := podSpec["volumes"].([]interface{})
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, source := range []string{"configMap", "secret", "projected", "downwardAPI"} {
+			removeDefaults(volume, []fieldDefault{{path: []string{source, "defaultMode"}, value: int64(420)}})
+		}
+		items, _, _ := unstructured.NestedFieldNoCopy(volume, "downwardAPI", "items")
+		list, _ := items.([]interface{})
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				removeDefaults(m, []fieldDefault{{path: []string{"fieldRef", "apiVersion"}, value: "v1"}})
+			}
+		}
+	}
+}
+
+func removeContainerDefaults(container map[string]interface{}) {
+	if image, _ := container["image"].(string); !hasLatestTag(image) {
+		removeDefaults(container, containerDefaults[:1])
+	}
+	removeDefaults(container, containerDefaults[1:])
+	for _, probe := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+		if p, ok := container[probe].(map[string]interface{}); ok {
+			removeDefaults(p, probeDefaults)
+		}
+	}
+	ports, _ := container["ports"].([]interface{})
+	for _, p := range ports {
+		if port, ok := p.(map[string]interface{}); ok {
+			removeDefaults(port, []fieldDefault{{path: []string{"protocol"}, value: "TCP"}})
+		}
+	}
+	env, _ := container["env"].([]interface{})
+	for _, e := range env {
+		if m, ok := e.(map[string]interface{}); ok {
+			removeDefaults(m, []fieldDefault{{path: []string{"valueFrom", "fieldRef", "apiVersion"}, value: "v1"}})
+		}
+	}
+}
+
+func removeServicePortDefaults(obj map[string]interface{}) {
+	ports, _, _ := unstructured.NestedSlice(obj, "spec", "ports")
+	if len(ports) == 0 {
+		return
+	}
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		removeDefaults(port, []fieldDefault{{path: []string{"protocol"}, value: "TCP"}})
+		if port["targetPort"] == port["port"] {
+			delete(port, "targetPort")
+		}
+	}
+	_ = unstructured.SetNestedSlice(obj, ports, "spec", "ports")
+}
+
+// hasLatestTag - returns true if API server defaults imagePullPolicy of the image to Always.
+func hasLatestTag(image string) bool {
+	if image == "" {
+		return false
+	}
+	for i := len(image) - 1; i >= 0; i-- {
+		switch image[i] {
+		case '@':
+			return false
+		case ':':
+			return image[i+1:] == "latest"
+		case '/':
+			return true
+		}
+	}
+	return true
+}
+
+func removeDefaults(obj map[string]interface{}, defaults []fieldDefault) {
+	for _, d := range defaults {
+		val, found, _ := unstructured.NestedFieldNoCopy(obj, d.path...)
+		if found && reflect.DeepEqual(val, d.value) {
+			unstructured.RemoveNestedField(obj, d.path...)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package normalize strips server-side and tooling noise from input objects before they are processed:
+// fields populated by k8s API server, object status, kubectl annotations and optionally API server defaults.
+package normalize
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Names of built-in filters.
+const (
+	// ServerFields - metadata populated by API server and controllers and annotations set by k8s components.
+	ServerFields = "server-fields"
+	// Status - object status.
+	Status = "status"
+	// LastApplied - kubectl last-applied-configuration annotation.
+	LastApplied = "last-applied"
+	// Defaults - fields equal to values defaulted by API server. Disabled by default.
+	Defaults = "defaults"
+)
+
+// Filter - normalization step modifying input object in place.
+type Filter interface {
+	Name() string
+	Normalize(obj *unstructured.Unstructured)
+}
+
+// Pipeline - filters applied to every input object in order.
+type Pipeline []Filter
+
+// Normalize - applies all pipeline filters to the object.
+func (p Pipeline) Normalize(obj *unstructured.Unstructured) {
+	for _, f := range p {
+		f.Normalize(obj)
+	}
+}
+
+// New - returns pipeline of built-in filters. Filters with names from skip are disabled.
+// Defaults filter is added only if removeDefaults is set.
+func New(skip []string, removeDefaults bool) (Pipeline, error) {
+	disabled := map[string]bool{}
+	for _, name := range skip {
+		if _, ok := builtin[name]; !ok {
+			return nil, fmt.Errorf("unknown normalization filter %q: expected one of %s", name, strings.Join(names, ", "))
+		}
+		disabled[name] = true
+	}
+	disabled[Defaults] = disabled[Defaults] || !removeDefaults
+	var res Pipeline
+	for _, name := range names {
+		if !disabled[name] {
+			res = append(res, builtin[name])
+		}
+	}
+	return res, nil
+}
+
+// names - built-in filter names in order of application.
+var names = []string{ServerFields, Status, LastApplied, Defaults}
+
+var builtin = map[string]Filter{
+	ServerFields: filterFunc{name: ServerFields, fn: RemoveServerFields},
+	Status:       filterFunc{name: Status, fn: RemoveStatus},
+	LastApplied:  filterFunc{name: LastApplied, fn: RemoveLastApplied},
+	Defaults:     filterFunc{name: Defaults, fn: RemoveDefaults},
+}
+
+type filterFunc struct {
+	name string
+	fn   func(obj *unstructured.Unstructured)
+}
+
+func (f filterFunc) Name() string {
+	return f.name
+}
+
+func (f filterFunc) Normalize(obj *unstructured.Unstructured) {
+	f.fn(obj)
+}
+
+// serverMetadata - object metadata fields populated by k8s API server.
+var serverMetadata = []string{
+	"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp",
+	"deletionGracePeriodSeconds", "managedFields", "selfLink",
+}
+
+// serverAnnotations - annotations set by k8s controllers.
+var serverAnnotations = []string{
+	"deployment.kubernetes.io/revision",
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+}
+
+// lastAppliedAnnotation - annotation with previous configuration stored by kubectl apply.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// templateMetadata - paths to metadata of nested object templates.
+var templateMetadata = [][]string{
+	{"spec", "template", "metadata"},
+	{"spec", "jobTemplate", "metadata"},
+	{"spec", "jobTemplate", "spec", "template", "metadata"},
+}
+
+// RemoveServerFields - removes metadata fields populated by API server, annotations set by controllers
+// and creation timestamps of nested templates.
+func RemoveServerFields(obj *unstructured.Unstructured) {
+	for _, field := range serverMetadata {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	removeAnnotations(obj, serverAnnotations...)
+	for _, path := range templateMetadata {
+		unstructured.RemoveNestedField(obj.Object, append(path, "creationTimestamp")...)
+	}
+	claims, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+	for _, c := range claims {
+		if claim, ok := c.(map[string]interface{}); ok {
+			unstructured.RemoveNestedField(claim, "metadata", "creationTimestamp")
+		}
+	}
+	if len(claims) != 0 {
+		_ = unstructured.SetNestedSlice(obj.Object, claims, "spec", "volumeClaimTemplates")
+	}
+}
+
+// RemoveStatus - removes object status and status of StatefulSet volume claim templates.
+func RemoveStatus(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "status")
+	claims, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+	for _, c := range claims {
+		if claim, ok := c.(map[string]interface{}); ok {
+			delete(claim, "status")
+		}
+	}
+	if len(claims) != 0 {
+		_ = unstructured.SetNestedSlice(obj.Object, claims, "spec", "volumeClaimTemplates")
+	}
+}
+
+// RemoveLastApplied - removes kubectl last-applied-configuration annotation.
+func RemoveLastApplied(obj *unstructured.Unstructured) {
+	removeAnnotations(obj, lastAppliedAnnotation)
+}
+
+func removeAnnotations(obj *unstructured.Unstructured, keys ...string) {
+	annotations := obj.GetAnnotations()
+	if len(annotations) == 0 {
+		return
+	}
+	for _, k := range keys {
+		delete(annotations, k)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,205 @@
+package normalize
+
+import (
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const deploymentYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: my-ns
+  uid: 8a2c1f3e-1
+  resourceVersion: "42"
+  generation: 3
+  creationTimestamp: "2020-01-01T00:00:00Z"
+  managedFields:
+  - manager: kubectl
+    operation: Update
+  annotations:
+    deployment.kubernetes.io/revision: "3"
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+spec:
+  progressDeadlineSeconds: 600
+  revisionHistoryLimit: 5
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 25%
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: web
+    spec:
+      dnsPolicy: ClusterFirst
+      restartPolicy: Always
+      schedulerName: default-scheduler
+      securityContext: {}
+      terminationGracePeriodSeconds: 60
+      containers:
+      - name: web
+        image: nginx:1.25
+        imagePullPolicy: IfNotPresent
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File
+        ports:
+        - containerPort: 80
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 80
+          periodSeconds: 10
+          timeoutSeconds: 5
+      - name: sidecar
+        image: busybox
+        imagePullPolicy: IfNotPresent
+      volumes:
+      - name: config
+        configMap:
+          name: web
+          defaultMode: 420
+status:
+  replicas: 1`
+
+func TestNew(t *testing.T) {
+	names := func(p Pipeline) []string {
+		var res []string
+		for _, f := range p {
+			res = append(res, f.Name())
+		}
+		return res
+	}
+	p, err := New(nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ServerFields, Status, LastApplied}, names(p))
+
+	p, err = New([]string{Status, LastApplied}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ServerFields, Defaults}, names(p))
+
+	_, err = New([]string{"unknown"}, false)
+	assert.Error(t, err)
+}
+
+func TestPipeline_Normalize(t *testing.T) {
+	t.Run("default filters", func(t *testing.T) {
+		p, err := New(nil, false)
+		require.NoError(t, err)
+		obj := internal.GenerateObj(deploymentYaml)
+		p.Normalize(obj)
+		assert.Equal(t, map[string]interface{}{"name": "web", "namespace": "my-ns"}, obj.Object["metadata"])
+		assert.NotContains(t, obj.Object, "status")
+		_, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "template", "metadata", "creationTimestamp")
+		assert.False(t, found)
+		_, found, _ = unstructured.NestedFieldNoCopy(obj.Object, "spec", "progressDeadlineSeconds")
+		assert.True(t, found)
+	})
+	t.Run("skipped filters", func(t *testing.T) {
+		p, err := New([]string{Status, LastApplied}, false)
+		require.NoError(t, err)
+		obj := internal.GenerateObj(deploymentYaml)
+		p.Normalize(obj)
+		assert.Equal(t, map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}, obj.GetAnnotations())
+		assert.Contains(t, obj.Object, "status")
+	})
+	t.Run("statefulset claim templates", func(t *testing.T) {
+		p, err := New(nil, false)
+		require.NoError(t, err)
+		obj := internal.GenerateObj(`apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+      creationTimestamp: null
+    spec:
+      resources:
+        requests:
+          storage: 1Gi
+    status:
+      phase: Pending`)
+		p.Normalize(obj)
+		claims, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "data"},
+			"spec": map[string]interface{}{"resources": map[string]interface{}{
+				"requests": map[string]interface{}{"storage": "1Gi"},
+			}},
+		}}, claims)
+	})
+}
+
+func TestRemoveDefaults(t *testing.T) {
+	obj := internal.GenerateObj(deploymentYaml)
+	RemoveDefaults(obj)
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	assert.NotContains(t, spec, "progressDeadlineSeconds")
+	assert.NotContains(t, spec, "strategy")
+	assert.Equal(t, int64(5), spec["revisionHistoryLimit"])
+
+	podSpec, _, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+	assert.Equal(t, map[string]interface{}{
+		"terminationGracePeriodSeconds": int64(60),
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":  "web",
+				"image": "nginx:1.25",
+				"ports": []interface{}{map[string]interface{}{"containerPort": int64(80)}},
+				"readinessProbe": map[string]interface{}{
+					"httpGet":        map[string]interface{}{"path": "/", "port": int64(80)},
+					"timeoutSeconds": int64(5),
+				},
+			},
+			map[string]interface{}{
+				"name":            "sidecar",
+				"image":           "busybox",
+				"imagePullPolicy": "IfNotPresent",
+			},
+		},
+		"volumes": []interface{}{map[string]interface{}{
+			"name":      "config",
+			"configMap": map[string]interface{}{"name": "web"},
+		}},
+	}, podSpec)
+
+	svc := internal.GenerateObj(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: ClusterIP
+  sessionAffinity: None
+  ipFamilies:
+  - IPv4
+  ipFamilyPolicy: SingleStack
+  ports:
+  - port: 80
+    targetPort: 80
+    protocol: TCP
+  - name: metrics
+    port: 9090
+    targetPort: metrics
+    protocol: UDP`)
+	RemoveDefaults(svc)
+	svcSpec, _, _ := unstructured.NestedMap(svc.Object, "spec")
+	assert.Equal(t, map[string]interface{}{
+		"type": "ClusterIP",
+		"ports": []interface{}{
+			map[string]interface{}{"port": int64(80)},
+			map[string]interface{}{"name": "metrics", "port": int64(9090), "targetPort": "metrics", "protocol": "UDP"},
+		},
+	}, svcSpec)
+}
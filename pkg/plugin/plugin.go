@@ -0,0 +1,244 @@
+// Package plugin runs external processor plugins. Plugin is an executable reading a JSON request from stdin and
+// writing a JSON response to stdout. Helmify first sends "describe" request to get kinds claimed by the plugin and
+// then sends "process" request for each object of claimed kinds.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// APIVersion - plugin protocol version sent with each request.
+const APIVersion = "helmify.io/v1alpha1"
+
+// DefaultTimeout - default time limit of a single plugin call.
+const DefaultTimeout = 30 * time.Second
+
+// Plugin operations.
+const (
+	OperationDescribe = "describe"
+	OperationProcess  = "process"
+)
+
+// Request - plugin input.
+type Request struct {
+	APIVersion string `json:"apiVersion"`
+	Operation  string `json:"operation"`
+	// Object - k8s object to process. Set for process operation.
+	Object map[string]interface{} `json:"object,omitempty"`
+	// Metadata - app metadata. Set for process operation.
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Metadata - app metadata of the processed object.
+type Metadata struct {
+	ChartName string `json:"chartName"`
+	// Namespace - detected app namespace.
+	Namespace string `json:"namespace"`
+	// Name - object name without app common prefix.
+	Name string `json:"name"`
+	// ValuesKey - values key used by processors for the object, e.g. "myWidget".
+	ValuesKey string `json:"valuesKey"`
+	// TemplatedName - object name Helm template.
+	TemplatedName string `json:"templatedName"`
+	// TemplatedNames - name Helm templates of all app objects by their original names.
+	// Used to template references to other objects.
+	TemplatedNames map[string]string `json:"templatedNames"`
+	// TemplatedMeta - apiVersion, kind and metadata of the object as Helm template.
+	TemplatedMeta string `json:"templatedMeta"`
+}
+
+// Kind - group and kind claimed by the plugin. Empty version matches any version.
+type Kind struct {
+	Group   string `json:"group"`
+	Version string `json:"version,omitempty"`
+	Kind    string `json:"kind"`
+}
+
+// Response - plugin output.
+type Response struct {
+	// Kinds - kinds claimed by the plugin. Returned for describe operation.
+	Kinds []Kind `json:"kinds,omitempty"`
+	// Template - Helm template body. Object is passed to other processors if template is empty and skip is false.
+	Template string `json:"template,omitempty"`
+	// Values - values fragment merged into values.yaml.
+	Values map[string]interface{} `json:"values,omitempty"`
+	// Filename - template file path relative to chart templates directory. Default is <name>.yaml.
+	Filename string `json:"filename,omitempty"`
+	// Skip - do not add the object to the chart.
+	Skip bool `json:"skip,omitempty"`
+}
+
+// Find - returns plugin executables from the directory followed by given plugin paths.
+func Find(dir string, paths []string) ([]string, error) {
+	var res []string
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read plugin dir", err)
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to read plugin dir", err)
+			}
+			if info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0 {
+				res = append(res, filepath.Join(dir, e.Name()))
+			}
+		}
+		sort.Strings(res)
+	}
+	return append(res, paths...), nil
+}
+
+// New - returns processor running the plugin executable. Plugin calls are killed when ctx is done or
+// after timeout. DefaultTimeout is used if timeout is not positive.
+func New(ctx context.Context, path string, timeout time.Duration) helmify.Processor {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &plugin{ctx: ctx, path: path, timeout: timeout}
+}
+
+type plugin struct {
+	ctx      context.Context
+	path     string
+	timeout  time.Duration
+	describe sync.Once
+	kinds    []Kind
+	err      error
+}
+
+// Name - returns plugin executable path.
+func (p *plugin) Name() string {
+	return "plugin:" + p.path
+}
+
+// Process - sends object to the plugin if its kind is claimed by the plugin.
+func (p *plugin) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	p.describe.Do(func() {
+		var resp Response
+		resp, p.err = p.run(Request{APIVersion: APIVersion, Operation: OperationDescribe})
+		p.kinds = resp.Kinds
+		logrus.WithFields(logrus.Fields{"Plugin": p.path, "Kinds": p.kinds}).Debug("plugin loaded")
+	})
+	if p.err != nil {
+		return true, nil, p.err
+	}
+	if !p.claims(obj) {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj.DeepCopy())
+	if err != nil {
+		return true, nil, err
+	}
+	name := appMeta.TrimName(obj.GetName())
+	resp, err := p.run(Request{
+		APIVersion: APIVersion,
+		Operation:  OperationProcess,
+		Object:     obj.Object,
+		Metadata: &Metadata{
+			ChartName:      appMeta.ChartName(),
+			Namespace:      appMeta.Namespace(),
+			Name:           name,
+			ValuesKey:      strcase.ToLowerCamel(name),
+			TemplatedName:  appMeta.TemplatedName(obj.GetName()),
+			TemplatedNames: appMeta.TemplatedNames(),
+			TemplatedMeta:  meta,
+		},
+	})
+	if err != nil {
+		return true, nil, err
+	}
+	if resp.Skip {
+		return true, nil, nil
+	}
+	if resp.Template == "" {
+		return false, nil, nil
+	}
+	if resp.Filename == "" {
+		resp.Filename = name + ".yaml"
+	}
+	if filepath.IsAbs(resp.Filename) || strings.HasPrefix(filepath.Clean(resp.Filename), "..") {
+		return true, nil, fmt.Errorf("plugin %s returned file %q: must be relative to chart templates directory", p.path, resp.Filename)
+	}
+	return true, &result{data: []byte(resp.Template), values: resp.Values, filename: resp.Filename}, nil
+}
+
+func (p *plugin) claims(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	for _, k := range p.kinds {
+		if k.Group == gvk.Group && k.Kind == gvk.Kind && (k.Version == "" || k.Version == gvk.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+// run - executes plugin with request on stdin. Plugin stderr is passed through.
+func (p *plugin) run(req Request) (Response, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, fmt.Errorf("%w: unable to marshal plugin request", err)
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+	out := bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return Response{}, fmt.Errorf("%w: plugin %s %s interrupted", ctx.Err(), p.path, req.Operation)
+	}
+	if err != nil {
+		return Response{}, fmt.Errorf("%w: plugin %s %s failed", err, p.path, req.Operation)
+	}
+	var resp Response
+	// numbers are decoded as int64 if possible
+	err = utiljson.Unmarshal(out.Bytes(), &resp)
+	if err != nil {
+		return Response{}, fmt.Errorf("%w: invalid plugin %s %s response", err, p.path, req.Operation)
+	}
+	return resp, nil
+}
+
+type result struct {
+	data     []byte
+	values   helmify.Values
+	filename string
+}
+
+func (r *result) Filename() string {
+	return r.filename
+}
+
+func (r *result) Values() helmify.Values {
+	if r.values == nil {
+		return helmify.Values{}
+	}
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write(r.data)
+	return err
+}
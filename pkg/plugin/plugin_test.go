@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	strWidget = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-app-widget
+  namespace: my-ns
+spec:
+  size: 3`
+	strGadget = `apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: my-app-gadget
+  namespace: my-ns`
+
+	// script - plugin claiming Widget kind. Saves process request to request.json next to the script.
+	script = `#!/bin/sh
+req=$(cat)
+case "$req" in
+*'"operation":"describe"'*)
+  echo '{"kinds":[{"group":"example.com","kind":"Widget"}]}' ;;
+*)
+  printf "%s" "$req" > "$(dirname "$0")/request.json"
+  printf '%s\n' '{"template":"kind: Widget\nspec:\n  size: {{ .Values.widget.size }}","values":{"widget":{"size":3}},"filename":"widgets/widget.yaml"}' ;;
+esac
+`
+)
+
+func writePlugin(t *testing.T, content string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugins are not supported on windows")
+	}
+	path := filepath.Join(t.TempDir(), "widget-plugin")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o700))
+	return path
+}
+
+func TestPlugin_Process(t *testing.T) {
+	path := writePlugin(t, script)
+	appMeta := metadata.New(config.Config{ChartName: "chart"})
+	widget, gadget := internal.GenerateObj(strWidget), internal.GenerateObj(strGadget)
+	appMeta.Load(widget)
+	appMeta.Load(gadget)
+	p := New(context.Background(), path, 0)
+
+	processed, _, err := p.Process(appMeta, gadget)
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	processed, tmpl, err := p.Process(appMeta, widget)
+	require.NoError(t, err)
+	require.True(t, processed)
+	assert.Equal(t, "widgets/widget.yaml", tmpl.Filename())
+	assert.Equal(t, helmify.Values{"widget": map[string]interface{}{"size": int64(3)}}, tmpl.Values())
+	buf := bytes.Buffer{}
+	require.NoError(t, tmpl.Write(&buf))
+	assert.Equal(t, "kind: Widget\nspec:\n  size: {{ .Values.widget.size }}", buf.String())
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "request.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "apiVersion": "helmify.io/v1alpha1",
+  "operation": "process",
+  "object": {"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "my-app-widget", "namespace": "my-ns"}, "spec": {"size": 3}},
+  "metadata": {
+    "chartName": "chart",
+    "namespace": "my-ns",
+    "name": "widget",
+    "valuesKey": "widget",
+    "templatedName": "{{ include \"chart.fullname\" . }}-widget",
+    "templatedNames": {
+      "my-app-gadget": "{{ include \"chart.fullname\" . }}-gadget",
+      "my-app-widget": "{{ include \"chart.fullname\" . }}-widget"
+    },
+    "templatedMeta": "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: {{ include \"chart.fullname\" . }}-widget\n  labels:\n  {{- include \"chart.labels\" . | nindent 4 }}"
+  }
+}`, string(data))
+}
+
+func TestPlugin_Failed(t *testing.T) {
+	p := New(context.Background(), writePlugin(t, "#!/bin/sh\necho not json\n"), 0)
+	processed, _, err := p.Process(metadata.New(config.Config{}), internal.GenerateObj(strWidget))
+	assert.True(t, processed)
+	assert.Error(t, err)
+}
+
+func TestPlugin_InvalidFilename(t *testing.T) {
+	for _, filename := range []string{"../widget.yaml", "widgets/../../widget.yaml", "/tmp/widget.yaml"} {
+		t.Run(filename, func(t *testing.T) {
+			p := New(context.Background(), writePlugin(t, strings.Replace(script, "widgets/widget.yaml", filename, 1)), 0)
+			processed, _, err := p.Process(metadata.New(config.Config{}), internal.GenerateObj(strWidget))
+			assert.True(t, processed)
+			assert.ErrorContains(t, err, "must be relative to chart templates directory")
+		})
+	}
+}
+
+func TestPlugin_Timeout(t *testing.T) {
+	p := New(context.Background(), writePlugin(t, "#!/bin/sh\nexec sleep 10\n"), 100*time.Millisecond)
+	start := time.Now()
+	processed, _, err := p.Process(metadata.New(config.Config{}), internal.GenerateObj(strWidget))
+	assert.True(t, processed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPlugin_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := New(ctx, writePlugin(t, script), 0)
+	processed, _, err := p.Process(metadata.New(config.Config{}), internal.GenerateObj(strWidget))
+	assert.True(t, processed)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b"), nil, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), nil, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), nil, 0o600))
+	plugins, err := Find(dir, []string{"/bin/c"})
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, []string{filepath.Join(dir, "a"), filepath.Join(dir, "b"), "/bin/c"}, plugins)
+	}
+	_, err = Find(filepath.Join(dir, "missing"), nil)
+	assert.Error(t, err)
+}
@@ -82,23 +82,33 @@ func (d daemonset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstru
 	selector = strings.Trim(selector, " \n")
 	selector = string(yamlformat.Indent([]byte(selector), 4))
 
-	podLabels, err := yamlformat.Marshal(dae.Spec.Template.ObjectMeta.Labels, 8)
+	nameCamel := strcase.ToLowerCamel(name)
+	podMeta, _, err := unstructured.NestedMap(obj.Object, "spec", "template", "metadata")
 	if err != nil {
 		return true, nil, err
 	}
+	podMeta, err = pod.ProcessTemplateMetaDefaults(nameCamel, appMeta, podMeta, values)
+	if err != nil {
+		return true, nil, err
+	}
+
+	podLabels, err := yamlformat.Marshal(podMeta["labels"], 8)
+	if err != nil {
+		return true, nil, err
+	}
+	podLabels = pod.ExpandDefaults(podLabels)
 	podLabels += fmt.Sprintf("\n      {{- include \"%s.selectorLabels\" . | nindent 8 }}", appMeta.ChartName())
 
 	podAnnotations := ""
-	if len(dae.Spec.Template.ObjectMeta.Annotations) != 0 {
-		podAnnotations, err = yamlformat.Marshal(map[string]interface{}{"annotations": dae.Spec.Template.ObjectMeta.Annotations}, 6)
+	if len(dae.Spec.Template.ObjectMeta.Annotations) != 0 || appMeta.Config().GenerateDefaults {
+		podAnnotations, err = yamlformat.Marshal(map[string]interface{}{"annotations": podMeta["annotations"]}, 6)
 		if err != nil {
 			return true, nil, err
 		}
 
-		podAnnotations = "\n" + podAnnotations
+		podAnnotations = "\n" + pod.ExpandDefaults(podAnnotations)
 	}
 
-	nameCamel := strcase.ToLowerCamel(name)
 	specMap, podValues, err := pod.ProcessSpec(nameCamel, appMeta, dae.Spec.Template.Spec, 0)
 	if err != nil {
 		return true, nil, err
@@ -113,6 +123,7 @@ func (d daemonset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstru
 		return true, nil, err
 	}
 	spec = strings.ReplaceAll(spec, "'", "")
+	spec = pod.ExpandDefaults(spec)
 
 	return true, &result{
 		values: values,
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/arttor/helmify/pkg/processor/hpa"
 	"github.com/arttor/helmify/pkg/processor/pod"
 
 	"github.com/arttor/helmify/pkg/helmify"
@@ -46,7 +47,10 @@ spec:
 {{ .PodLabels }}
 {{- .PodAnnotations }}
     spec:
-{{ .Spec }}`)
+{{ .Spec }}
+{{- if .Autoscaler }}
+{{ .Autoscaler }}
+{{- end }}`)
 
 const selectorTempl = `%[1]s
 {{- include "%[2]s.selectorLabels" . | nindent 6 }}
@@ -82,6 +86,13 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 	if err != nil {
 		return true, nil, err
 	}
+	autoscaledReplicas, autoscaler, err := hpa.ProcessWorkload(appMeta, obj, &values)
+	if err != nil {
+		return true, nil, err
+	}
+	if autoscaledReplicas != "" {
+		replicas = autoscaledReplicas
+	}
 
 	revisionHistoryLimit, err := processRevisionHistoryLimit(name, &depl, &values)
 	if err != nil {
@@ -108,23 +119,33 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 	selector = strings.Trim(selector, " \n")
 	selector = string(yamlformat.Indent([]byte(selector), 4))
 
-	podLabels, err := yamlformat.Marshal(depl.Spec.Template.ObjectMeta.Labels, 8)
+	nameCamel := strcase.ToLowerCamel(name)
+	podMeta, _, err := unstructured.NestedMap(obj.Object, "spec", "template", "metadata")
+	if err != nil {
+		return true, nil, err
+	}
+	podMeta, err = pod.ProcessTemplateMetaDefaults(nameCamel, appMeta, podMeta, values)
 	if err != nil {
 		return true, nil, err
 	}
+
+	podLabels, err := yamlformat.Marshal(podMeta["labels"], 8)
+	if err != nil {
+		return true, nil, err
+	}
+	podLabels = pod.ExpandDefaults(podLabels)
 	podLabels += fmt.Sprintf("\n      {{- include \"%s.selectorLabels\" . | nindent 8 }}", appMeta.ChartName())
 
 	podAnnotations := ""
-	if len(depl.Spec.Template.ObjectMeta.Annotations) != 0 {
-		podAnnotations, err = yamlformat.Marshal(map[string]interface{}{"annotations": depl.Spec.Template.ObjectMeta.Annotations}, 6)
+	if len(depl.Spec.Template.ObjectMeta.Annotations) != 0 || appMeta.Config().GenerateDefaults {
+		podAnnotations, err = yamlformat.Marshal(map[string]interface{}{"annotations": podMeta["annotations"]}, 6)
 		if err != nil {
 			return true, nil, err
 		}
 
-		podAnnotations = "\n" + podAnnotations
+		podAnnotations = "\n" + pod.ExpandDefaults(podAnnotations)
 	}
 
-	nameCamel := strcase.ToLowerCamel(name)
 	specMap, podValues, err := pod.ProcessSpec(nameCamel, appMeta, depl.Spec.Template.Spec, 0)
 	if err != nil {
 		return true, nil, err
@@ -142,7 +163,8 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 		spec = addWebhookOption(spec)
 	}
 
-	spec = replaceSingleQuotes(spec)
+	spec = pod.ReplaceSingleQuotes(spec)
+	spec = pod.ExpandDefaults(spec)
 
 	return true, &result{
 		values: values,
@@ -155,6 +177,7 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 			PodLabels            string
 			PodAnnotations       string
 			Spec                 string
+			Autoscaler           string
 		}{
 			Meta:                 meta,
 			Replicas:             replicas,
@@ -164,15 +187,11 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 			PodLabels:            podLabels,
 			PodAnnotations:       podAnnotations,
 			Spec:                 spec,
+			Autoscaler:           autoscaler,
 		},
 	}, nil
 }
 
-func replaceSingleQuotes(s string) string {
-	r := regexp.MustCompile(`'({{((.*|.*\n.*))}}.*)'`)
-	return r.ReplaceAllString(s, "${1}")
-}
-
 func addWebhookOption(manifest string) string {
 	webhookOptionHeader := "      {{- if .Values.webhook.enabled }}"
 	webhookOptionFooter := "      {{- end }}"
@@ -295,6 +314,7 @@ type result struct {
 		PodLabels            string
 		PodAnnotations       string
 		Spec                 string
+		Autoscaler           string
 	}
 	values helmify.Values
 }
@@ -1,8 +1,10 @@
 package deployment
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -135,85 +137,32 @@ func Test_deployment_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, false, processed)
 	})
-}
-
-var singleQuotesTest = []struct {
-	input    string
-	expected string
-}{
-	{
-		"{{ .Values.x }}",
-		"{{ .Values.x }}",
-	},
-	{
-		"'{{ .Values.x }}'",
-		"{{ .Values.x }}",
-	},
-	{
-		"'{{ .Values.x }}:{{ .Values.y }}'",
-		"{{ .Values.x }}:{{ .Values.y }}",
-	},
-	{
-		"'{{ .Values.x }}:{{ .Values.y \n\t| default .Chart.AppVersion}}'",
-		"{{ .Values.x }}:{{ .Values.y \n\t| default .Chart.AppVersion}}",
-	},
-	{
-		"echo 'x'",
-		"echo 'x'",
-	},
-	{
-		"abcd: x.y['x/y']",
-		"abcd: x.y['x/y']",
-	},
-	{
-		"abcd: x.y[\"'{{}}'\"]",
-		"abcd: x.y[\"{{}}\"]",
-	},
-	{
-		"image: '{{ .Values.x }}'",
-		"image: {{ .Values.x }}",
-	},
-	{
-		"'{{ .Values.x }} y'",
-		"{{ .Values.x }} y",
-	},
-	{
-		"\t\t- mountPath: './x.y'",
-		"\t\t- mountPath: './x.y'",
-	},
-	{
-		"'{{}}'",
-		"{{}}",
-	},
-	{
-		"'{{ {nested} }}'",
-		"{{ {nested} }}",
-	},
-	{
-		"'{{ '{{nested}}' }}'",
-		"{{ '{{nested}}' }}",
-	},
-	{
-		"'{{ unbalanced }'",
-		"'{{ unbalanced }'",
-	},
-	{
-		"'{{\nincomplete content'",
-		"'{{\nincomplete content'",
-	},
-	{
-		"'{{ @#$%^&*() }}'",
-		"{{ @#$%^&*() }}",
-	},
-}
-
-func Test_replaceSingleQuotes(t *testing.T) {
-	for _, tt := range singleQuotesTest {
-		t.Run(tt.input, func(t *testing.T) {
-			s := replaceSingleQuotes(tt.input)
-			if s != tt.expected {
-				t.Errorf("got %q, want %q", s, tt.expected)
-			}
-		})
-	}
+	t.Run("defaults without pod labels", func(t *testing.T) {
+		obj := internal.GenerateObj(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+`)
+		appMeta := metadata.New(config.Config{ChartName: "chart", GenerateDefaults: true})
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `      labels:
+        {{- with .Values.web.podLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+      {{- include "chart.selectorLabels" . | nindent 8 }}`)
+		assert.NotContains(t, buf.String(), "HELMIFY")
+	})
 }
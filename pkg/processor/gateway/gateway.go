@@ -0,0 +1,151 @@
+// Package gateway converts Gateway API objects into Helm templates.
+// See https://gateway-api.sigs.k8s.io/
+package gateway
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Group - Gateway API group. Objects of all group versions are processed.
+const Group = "gateway.networking.k8s.io"
+
+// gatewayTempl - gateway template with class name and listeners taken from values.
+// Listeners are rendered with tpl because certificate refs may contain templated names of chart secrets.
+const gatewayTempl = `%[1]s
+spec:
+  gatewayClassName: {{ .Values.%[2]s.gatewayClassName | quote }}
+  listeners:
+    {{- tpl (toYaml .Values.%[2]s.listeners) $ | nindent 4 }}%[3]s`
+
+// New creates processor for Gateway API Gateway resource.
+func New() helmify.Processor {
+	return &gateway{}
+}
+
+type gateway struct{}
+
+// Process Gateway object into template. Returns false if not capable of processing given resource type.
+// Gateway class name and listeners are placed into values under the gateway name.
+func (g gateway) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != Group || gvk.Kind != "Gateway" {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get gateway %s spec", err, obj.GetName())
+	}
+	className, _, err := unstructured.NestedString(spec, "gatewayClassName")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get gateway %s class name", err, obj.GetName())
+	}
+	listeners, _, err := unstructured.NestedSlice(spec, "listeners")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get gateway %s listeners", err, obj.GetName())
+	}
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		certs, _, _ := unstructured.NestedSlice(listener, "tls", "certificateRefs")
+		templateRefs(appMeta, obj.GetNamespace(), certs, "Secret")
+		if len(certs) != 0 {
+			_ = unstructured.SetNestedSlice(listener, certs, "tls", "certificateRefs")
+		}
+	}
+	if listeners == nil {
+		listeners = []interface{}{}
+	}
+	values := helmify.Values{}
+	err = unstructured.SetNestedField(values, map[string]interface{}{
+		"gatewayClassName": className,
+		"listeners":        listeners,
+	}, nameCamel)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to set gateway values", err)
+	}
+
+	// remaining spec fields, e.g. addresses, are kept in the template
+	delete(spec, "gatewayClassName")
+	delete(spec, "listeners")
+	rest, err := restSpec(spec)
+	if err != nil {
+		return true, nil, err
+	}
+	return true, &result{
+		name:   name,
+		data:   fmt.Sprintf(gatewayTempl, meta, nameCamel, rest),
+		values: values,
+	}, nil
+}
+
+// templateRefs - templates names of object references to chart objects of given default kind.
+// References to objects in other namespaces are not templated.
+func templateRefs(appMeta helmify.AppMetadata, namespace string, refs []interface{}, defaultKind string) {
+	for _, r := range refs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _ := ref["kind"].(string)
+		if kind == "" {
+			kind = defaultKind
+		}
+		if kind != defaultKind {
+			continue
+		}
+		if ns, _ := ref["namespace"].(string); ns != "" && ns != namespace {
+			continue
+		}
+		if name, ok := ref["name"].(string); ok {
+			ref["name"] = appMeta.TemplatedName(name)
+		}
+	}
+}
+
+// restSpec - returns spec fields not exposed in values as yaml with a leading new line.
+func restSpec(spec map[string]interface{}) (string, error) {
+	if len(spec) == 0 {
+		return "", nil
+	}
+	res, err := yamlformat.Marshal(spec, 2)
+	if err != nil {
+		return "", err
+	}
+	return "\n" + strings.TrimRight(res, "\n"), nil
+}
+
+type result struct {
+	name   string
+	data   string
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write([]byte(r.data))
+	return err
+}
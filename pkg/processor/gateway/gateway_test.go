@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	gatewayYaml = `apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: my-app-gateway
+  namespace: my-app-system
+spec:
+  gatewayClassName: istio
+  addresses:
+  - value: 10.0.0.1
+  listeners:
+  - name: https
+    hostname: example.com
+    port: 443
+    protocol: HTTPS
+    tls:
+      certificateRefs:
+      - name: my-app-tls
+      - name: shared-tls
+        namespace: cert-manager`
+	secretYaml = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-tls
+  namespace: my-app-system`
+	serviceYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+  namespace: my-app-system`
+)
+
+func Test_gateway_Process(t *testing.T) {
+	var testInstance gateway
+
+	t.Run("processed", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(gatewayYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(secretYaml))
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, "gateway.yaml", tt.Filename())
+		assert.Equal(t, helmify.Values{"gateway": map[string]interface{}{
+			"gatewayClassName": "istio",
+			"listeners": []interface{}{map[string]interface{}{
+				"name":     "https",
+				"hostname": "example.com",
+				"port":     int64(443),
+				"protocol": "HTTPS",
+				"tls": map[string]interface{}{"certificateRefs": []interface{}{
+					map[string]interface{}{"name": `{{ include "chart.fullname" . }}-tls`},
+					map[string]interface{}{"name": "shared-tls", "namespace": "cert-manager"},
+				}},
+			}},
+		}}, tt.Values())
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Equal(t, `apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: {{ include "chart.fullname" . }}-gateway
+  labels:
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  gatewayClassName: {{ .Values.gateway.gatewayClassName | quote }}
+  listeners:
+    {{- tpl (toYaml .Values.gateway.listeners) $ | nindent 4 }}
+  addresses:
+  - value: 10.0.0.1`, buf.String())
+	})
+	t.Run("skipped", func(t *testing.T) {
+		processed, _, err := testInstance.Process(&metadata.Service{}, internal.TestNs)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
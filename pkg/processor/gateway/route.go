@@ -0,0 +1,120 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/iancoleman/strcase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// routeTempl - route template with enabled toggle and hostnames taken from values.
+const routeTempl = `{{- if .Values.%[2]s.enabled }}
+%[1]s
+spec:
+  {{- with .Values.%[2]s.hostnames }}
+  hostnames:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}%[3]s
+{{- end }}`
+
+// routeKinds - supported Gateway API route kinds.
+var routeKinds = map[string]bool{
+	"HTTPRoute": true,
+	"GRPCRoute": true,
+	"TLSRoute":  true,
+}
+
+// NewRoute creates processor for Gateway API HTTPRoute, GRPCRoute and TLSRoute resources.
+func NewRoute() helmify.Processor {
+	return &route{}
+}
+
+type route struct{}
+
+// Process route object into template. Returns false if not capable of processing given resource type.
+// Route is wrapped into <name>.enabled toggle and its hostnames are placed into values.
+// Parent gateways and backend services owned by the chart are referenced by templated names.
+func (r route) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != Group || !routeKinds[gvk.Kind] {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s %s spec", err, gvk.Kind, obj.GetName())
+	}
+	hostnames, _, err := unstructured.NestedSlice(spec, "hostnames")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s %s hostnames", err, gvk.Kind, obj.GetName())
+	}
+	if hostnames == nil {
+		hostnames = []interface{}{}
+	}
+	delete(spec, "hostnames")
+
+	parentRefs, _, _ := unstructured.NestedSlice(spec, "parentRefs")
+	templateRefs(appMeta, obj.GetNamespace(), parentRefs, "Gateway")
+	rules, _, _ := unstructured.NestedSlice(spec, "rules")
+	for _, rule := range rules {
+		templateBackendRefs(appMeta, obj.GetNamespace(), rule)
+	}
+	if parentRefs != nil {
+		spec["parentRefs"] = parentRefs
+	}
+	if rules != nil {
+		spec["rules"] = rules
+	}
+
+	values := helmify.Values{}
+	err = unstructured.SetNestedField(values, map[string]interface{}{
+		"enabled":   true,
+		"hostnames": hostnames,
+	}, nameCamel)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to set route values", err)
+	}
+	rest, err := restSpec(spec)
+	if err != nil {
+		return true, nil, err
+	}
+	return true, &result{
+		name:   name,
+		data:   fmt.Sprintf(routeTempl, meta, nameCamel, rest),
+		values: values,
+	}, nil
+}
+
+// templateBackendRefs - templates service names in rule backend refs and request mirror filters.
+func templateBackendRefs(appMeta helmify.AppMetadata, namespace string, r interface{}) {
+	rule, ok := r.(map[string]interface{})
+	if !ok {
+		return
+	}
+	backendRefs, _ := rule["backendRefs"].([]interface{})
+	templateRefs(appMeta, namespace, backendRefs, "Service")
+	filters, _ := rule["filters"].([]interface{})
+	for _, backendRef := range backendRefs {
+		if ref, ok := backendRef.(map[string]interface{}); ok {
+			nested, _ := ref["filters"].([]interface{})
+			filters = append(filters, nested...)
+		}
+	}
+	for _, f := range filters {
+		filter, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if mirror, ok := filter["requestMirror"].(map[string]interface{}); ok {
+			templateRefs(appMeta, namespace, []interface{}{mirror["backendRef"]}, "Service")
+		}
+	}
+}
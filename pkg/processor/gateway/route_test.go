@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const httpRouteYaml = `apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: my-app-route
+  namespace: my-app-system
+spec:
+  parentRefs:
+  - name: my-app-gateway
+  - name: shared-gateway
+    namespace: infra
+  hostnames:
+  - example.com
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /
+    filters:
+    - type: RequestMirror
+      requestMirror:
+        backendRef:
+          name: my-app-web
+          port: 8080
+    backendRefs:
+    - name: my-app-web
+      port: 8080
+    - name: external
+      port: 80`
+
+const tlsRouteYaml = `apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: TLSRoute
+metadata:
+  name: my-app-tls-route
+  namespace: my-app-system
+spec:
+  parentRefs:
+  - name: my-app-gateway
+  rules:
+  - backendRefs:
+    - name: my-app-web
+      port: 443`
+
+func Test_route_Process(t *testing.T) {
+	var testInstance route
+
+	t.Run("http route", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(httpRouteYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(gatewayYaml))
+		appMeta.Load(internal.GenerateObj(serviceYaml))
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, "route.yaml", tt.Filename())
+		assert.Equal(t, helmify.Values{"route": map[string]interface{}{
+			"enabled":   true,
+			"hostnames": []interface{}{"example.com"},
+		}}, tt.Values())
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Equal(t, `{{- if .Values.route.enabled }}
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: {{ include "chart.fullname" . }}-route
+  labels:
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  {{- with .Values.route.hostnames }}
+  hostnames:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  parentRefs:
+  - name: '{{ include "chart.fullname" . }}-gateway'
+  - name: shared-gateway
+    namespace: infra
+  rules:
+  - backendRefs:
+    - name: '{{ include "chart.fullname" . }}-web'
+      port: 8080
+    - name: external
+      port: 80
+    filters:
+    - requestMirror:
+        backendRef:
+          name: '{{ include "chart.fullname" . }}-web'
+          port: 8080
+      type: RequestMirror
+    matches:
+    - path:
+        type: PathPrefix
+        value: /
+{{- end }}`, buf.String())
+	})
+	t.Run("tls route", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(tlsRouteYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(gatewayYaml))
+		appMeta.Load(internal.GenerateObj(serviceYaml))
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, helmify.Values{"tlsRoute": map[string]interface{}{
+			"enabled":   true,
+			"hostnames": []interface{}{},
+		}}, tt.Values())
+	})
+	t.Run("skipped", func(t *testing.T) {
+		processed, _, err := testInstance.Process(&metadata.Service{}, internal.GenerateObj(gatewayYaml))
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
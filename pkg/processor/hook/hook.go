@@ -0,0 +1,205 @@
+// Package hook converts k8s objects into Helm hooks.
+// See https://helm.sh/docs/topics/charts_hooks/
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/iancoleman/strcase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultMarker - default annotation or label marking object as Helm hook.
+	// Its value is a comma separated list of hook types. Optional hook weight and delete policy are
+	// set with "<marker>-weight" and "<marker>-delete-policy" annotations or labels.
+	DefaultMarker = "helmify.io/hook"
+	// DefaultType - hook type used if marker label value is not a hook type, e.g. "true".
+	DefaultType = "pre-install,pre-upgrade"
+
+	weightSuffix       = "-weight"
+	deletePolicySuffix = "-delete-policy"
+)
+
+// Hook - Helm hook annotations.
+type Hook struct {
+	// Type - comma separated list of hook types, e.g. pre-install,pre-upgrade.
+	Type string
+	// Weight - hook execution order.
+	Weight string
+	// DeletePolicy - comma separated list of hook delete policies.
+	DeletePolicy string
+}
+
+// Annotations - returns static hook annotations yaml with given indent.
+func (h Hook) Annotations(indent int) string {
+	prefix := strings.Repeat(" ", indent)
+	res := fmt.Sprintf("%s\"helm.sh/hook\": %s", prefix, h.Type)
+	if h.Weight != "" {
+		res += fmt.Sprintf("\n%s\"helm.sh/hook-weight\": %q", prefix, h.Weight)
+	}
+	if h.DeletePolicy != "" {
+		res += fmt.Sprintf("\n%s\"helm.sh/hook-delete-policy\": %s", prefix, h.DeletePolicy)
+	}
+	return res
+}
+
+// templatedAnnotations - returns hook annotations yaml with values taken from helm values.
+func templatedAnnotations(valuesName string, indent int) string {
+	prefix := strings.Repeat(" ", indent)
+	return fmt.Sprintf(`%[1]s"helm.sh/hook": {{ .Values.%[2]s.hook.type | quote }}
+%[1]s{{- with .Values.%[2]s.hook.weight }}
+%[1]s"helm.sh/hook-weight": {{ . | quote }}
+%[1]s{{- end }}
+%[1]s{{- with .Values.%[2]s.hook.deletePolicy }}
+%[1]s"helm.sh/hook-delete-policy": {{ . | quote }}
+%[1]s{{- end }}`, prefix, valuesName)
+}
+
+// Extract - returns hook defined by marker annotation or label of the object.
+// Marker annotations and labels are removed from the object. Returns false if object is not marked as hook.
+func Extract(marker string, obj *unstructured.Unstructured) (Hook, bool) {
+	if marker == "" {
+		marker = DefaultMarker
+	}
+	annotations, labels := obj.GetAnnotations(), obj.GetLabels()
+	lookup := func(key string) string {
+		val, ok := annotations[key]
+		if !ok {
+			val = labels[key]
+		}
+		delete(annotations, key)
+		delete(labels, key)
+		return strings.TrimSpace(val)
+	}
+	_, isAnnotated := annotations[marker]
+	_, isLabeled := labels[marker]
+	if !isAnnotated && !isLabeled {
+		return Hook{}, false
+	}
+	res := Hook{
+		Type:         lookup(marker),
+		Weight:       lookup(marker + weightSuffix),
+		DeletePolicy: lookup(marker + deletePolicySuffix),
+	}
+	if res.Type == "" || res.Type == "true" {
+		res.Type = DefaultType
+	}
+	setOrRemove(obj.SetAnnotations, annotations)
+	setOrRemove(obj.SetLabels, labels)
+	return res, true
+}
+
+func setOrRemove(set func(map[string]string), m map[string]string) {
+	if len(m) == 0 {
+		set(nil)
+		return
+	}
+	set(m)
+}
+
+// Wrap - adds hook annotations to the template metadata. Hook type, weight and delete policy are exposed as values.
+func Wrap(appMeta helmify.AppMetadata, obj *unstructured.Unstructured, template helmify.Template, h Hook) (helmify.Template, error) {
+	name := strcase.ToLowerCamel(appMeta.TrimName(obj.GetName()))
+	values := helmify.Values{}
+	hookValues := map[string]interface{}{"type": h.Type}
+	if h.Weight != "" {
+		hookValues["weight"] = h.Weight
+	}
+	if h.DeletePolicy != "" {
+		hookValues["deletePolicy"] = h.DeletePolicy
+	}
+	err := unstructured.SetNestedField(values, hookValues, name, "hook")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to set hook values", err)
+	}
+	err = values.Merge(template.Values())
+	if err != nil {
+		return nil, err
+	}
+	return &result{Template: template, values: values, name: name}, nil
+}
+
+type result struct {
+	helmify.Template
+	values helmify.Values
+	name   string
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+var (
+	metadataRe    = regexp.MustCompile(`(?m)^metadata:$`)
+	annotationsRe = regexp.MustCompile(`(?m)^  annotations:(.*)$`)
+	nameRe        = regexp.MustCompile(`(?m)^  name: .*$`)
+	topLevelRe    = regexp.MustCompile(`(?m)^\S`)
+)
+
+func (r *result) Write(writer io.Writer) error {
+	buf := bytes.Buffer{}
+	err := r.Template.Write(&buf)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write([]byte(addAnnotations(buf.String(), templatedAnnotations(r.name, 4))))
+	return err
+}
+
+// addAnnotations - inserts annotations into the first object metadata of rendered template.
+func addAnnotations(manifest, annotations string) string {
+	loc := metadataRe.FindStringIndex(manifest)
+	if loc == nil {
+		return manifest
+	}
+	metaStart := loc[1]
+	metaEnd := len(manifest)
+	if next := topLevelRe.FindStringIndex(manifest[metaStart+1:]); next != nil {
+		metaEnd = metaStart + 1 + next[0]
+	}
+	if a := annotationsRe.FindStringSubmatchIndex(manifest[metaStart:metaEnd]); a != nil {
+		inline := strings.TrimSpace(manifest[metaStart+a[2] : metaStart+a[3]])
+		if strings.HasPrefix(inline, "{") && !strings.HasPrefix(inline, "{{") {
+			// flow style annotations, e.g. "annotations: {}", are rewritten in block style
+			if flow, err := flowToBlock(inline, 4); err == nil {
+				return manifest[:metaStart+a[0]] + "  annotations:\n" + annotations + flow + manifest[metaStart+a[1]:]
+			}
+		}
+		pos := metaStart + a[1]
+		return manifest[:pos] + "\n" + annotations + manifest[pos:]
+	}
+	pos := metaStart
+	if n := nameRe.FindStringIndex(manifest[metaStart:metaEnd]); n != nil {
+		pos = metaStart + n[1]
+	}
+	return manifest[:pos] + "\n  annotations:\n" + annotations + manifest[pos:]
+}
+
+// flowToBlock - converts flow style yaml map into block style lines with given indent.
+// Each line is prefixed with a line break.
+func flowToBlock(flow string, indent int) (string, error) {
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(flow), &m); err != nil {
+		return "", err
+	}
+	if len(m) == 0 {
+		return "", nil
+	}
+	block, err := yaml.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	prefix := strings.Repeat(" ", indent)
+	res := ""
+	for _, line := range strings.Split(strings.TrimSuffix(string(block), "\n"), "\n") {
+		res += "\n" + prefix + line
+	}
+	return res, nil
+}
@@ -0,0 +1,156 @@
+package hook
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	strJob = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: db-migrate
+  annotations:
+    helmify.io/hook: pre-install
+    helmify.io/hook-weight: "-5"
+    helmify.io/hook-delete-policy: hook-succeeded
+    other: value`
+	strLabeled = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: seed
+  labels:
+    helmify.io/hook: "true"`
+	strPlain = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: plain
+  labels:
+    app: plain`
+)
+
+func TestExtract(t *testing.T) {
+	t.Run("annotated", func(t *testing.T) {
+		obj := internal.GenerateObj(strJob)
+		h, ok := Extract("", obj)
+		assert.True(t, ok)
+		assert.Equal(t, Hook{Type: "pre-install", Weight: "-5", DeletePolicy: "hook-succeeded"}, h)
+		assert.Equal(t, map[string]string{"other": "value"}, obj.GetAnnotations())
+	})
+	t.Run("labeled", func(t *testing.T) {
+		obj := internal.GenerateObj(strLabeled)
+		h, ok := Extract("", obj)
+		assert.True(t, ok)
+		assert.Equal(t, Hook{Type: DefaultType}, h)
+		assert.Empty(t, obj.GetLabels())
+	})
+	t.Run("custom marker", func(t *testing.T) {
+		obj := internal.GenerateObj(strJob)
+		_, ok := Extract("example.com/hook", obj)
+		assert.False(t, ok)
+		assert.Len(t, obj.GetAnnotations(), 4)
+	})
+	t.Run("not a hook", func(t *testing.T) {
+		obj := internal.GenerateObj(strPlain)
+		_, ok := Extract("", obj)
+		assert.False(t, ok)
+		assert.Equal(t, map[string]string{"app": "plain"}, obj.GetLabels())
+	})
+}
+
+func TestHook_Annotations(t *testing.T) {
+	h := Hook{Type: "post-install,post-upgrade", Weight: "2"}
+	assert.Equal(t, `    "helm.sh/hook": post-install,post-upgrade
+    "helm.sh/hook-weight": "2"`, h.Annotations(4))
+}
+
+type testTemplate string
+
+func (t testTemplate) Filename() string { return "test.yaml" }
+func (t testTemplate) Values() helmify.Values {
+	return helmify.Values{"dbMigrate": map[string]interface{}{"a": "b"}}
+}
+func (t testTemplate) Write(w io.Writer) error {
+	_, err := w.Write([]byte(t))
+	return err
+}
+
+func TestWrap(t *testing.T) {
+	obj := internal.GenerateObj(strJob)
+	h, _ := Extract("", obj)
+	tmpl, err := Wrap(&metadata.Service{}, obj, testTemplate(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ include "test.fullname" . }}-db-migrate
+  labels:
+  {{- include "test.labels" . | nindent 4 }}
+spec:
+  template: {}`), h)
+	require.NoError(t, err)
+	assert.Equal(t, helmify.Values{"dbMigrate": map[string]interface{}{
+		"a":    "b",
+		"hook": map[string]interface{}{"type": "pre-install", "weight": "-5", "deletePolicy": "hook-succeeded"},
+	}}, tmpl.Values())
+
+	buf := bytes.Buffer{}
+	require.NoError(t, tmpl.Write(&buf))
+	assert.Equal(t, `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ include "test.fullname" . }}-db-migrate
+  annotations:
+    "helm.sh/hook": {{ .Values.dbMigrate.hook.type | quote }}
+    {{- with .Values.dbMigrate.hook.weight }}
+    "helm.sh/hook-weight": {{ . | quote }}
+    {{- end }}
+    {{- with .Values.dbMigrate.hook.deletePolicy }}
+    "helm.sh/hook-delete-policy": {{ . | quote }}
+    {{- end }}
+  labels:
+  {{- include "test.labels" . | nindent 4 }}
+spec:
+  template: {}`, buf.String())
+}
+
+func Test_addAnnotations(t *testing.T) {
+	manifest := `metadata:
+  name: test
+  annotations:
+    foo: bar
+spec:
+  annotations:
+    no: no`
+	assert.Equal(t, `metadata:
+  name: test
+  annotations:
+    a: b
+    foo: bar
+spec:
+  annotations:
+    no: no`, addAnnotations(manifest, "    a: b"))
+	t.Run("flow style", func(t *testing.T) {
+		assert.Equal(t, `metadata:
+  annotations:
+    a: b
+  name: test
+spec: {}`, addAnnotations(`metadata:
+  annotations: {}
+  name: test
+spec: {}`, "    a: b"))
+		assert.Equal(t, `metadata:
+  annotations:
+    a: b
+    foo: bar
+    x: "1"
+  name: test`, addAnnotations(`metadata:
+  annotations: {x: "1", foo: bar}
+  name: test`, "    a: b"))
+	})
+}
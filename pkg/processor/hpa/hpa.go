@@ -0,0 +1,220 @@
+package hpa
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/iancoleman/strcase"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	hpaTempl = `{{- if .Values.%[1]s.autoscaling.enabled }}
+%[6]s%[2]s
+spec:
+  scaleTargetRef:
+    apiVersion: %[3]s
+    kind: %[4]s
+    name: %[5]s
+  minReplicas: {{ .Values.%[1]s.autoscaling.minReplicas }}
+  maxReplicas: {{ .Values.%[1]s.autoscaling.maxReplicas }}
+  {{- with .Values.%[1]s.autoscaling.metrics }}
+  metrics:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .Values.%[1]s.autoscaling.behavior }}
+  behavior:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}`
+
+	// ReplicasTempl - workload replicas omitted if HorizontalPodAutoscaler is enabled.
+	ReplicasTempl = `  {{- if not .Values.%[1]s.autoscaling.enabled }}
+  replicas: {{ .Values.%[1]s.replicas }}
+  {{- end }}`
+
+	defaultMaxReplicas    = 10
+	defaultCPUUtilization = 80
+)
+
+var hpaGVC = schema.GroupVersionKind{
+	Group:   "autoscaling",
+	Version: "v2",
+	Kind:    "HorizontalPodAutoscaler",
+}
+
+// IsHPA - returns true if object is HorizontalPodAutoscaler supported by processor.
+func IsHPA(obj *unstructured.Unstructured) bool {
+	return obj.GroupVersionKind() == hpaGVC
+}
+
+// New creates processor for k8s HorizontalPodAutoscaler resource.
+func New() helmify.Processor {
+	return &hpa{}
+}
+
+type hpa struct{}
+
+// Process k8s HorizontalPodAutoscaler object into template. Returns false if not capable of processing given resource type.
+// Values are placed under scaled workload autoscaling key, so the workload can omit replicas if autoscaling is enabled.
+func (h hpa) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if !IsHPA(obj) {
+		return false, nil, nil
+	}
+	autoscaler := autoscalingv2.HorizontalPodAutoscaler{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &autoscaler)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to cast to HorizontalPodAutoscaler", err)
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	res, values, err := template(appMeta, meta, autoscaler.Spec, true)
+	if err != nil {
+		return true, nil, err
+	}
+	return true, &result{
+		name:   appMeta.TrimName(obj.GetName()),
+		data:   res,
+		values: values,
+	}, nil
+}
+
+// ProcessWorkload - makes workload replicas conditional if the workload is scaled by HorizontalPodAutoscaler.
+// Returns empty replicas if workload is not autoscaled. Returns disabled by default autoscaler template
+// to be appended to the workload template if autoscaler generation is enabled and there is no autoscaler for the workload.
+func ProcessWorkload(appMeta helmify.AppMetadata, workload *unstructured.Unstructured, values *helmify.Values) (replicas, autoscaler string, err error) {
+	autoscaled := appMeta.Autoscaled(workload.GetKind(), workload.GetName())
+	if !autoscaled && !appMeta.Config().GenerateAutoscaling {
+		return "", "", nil
+	}
+	nameCamel := strcase.ToLowerCamel(appMeta.TrimName(workload.GetName()))
+	replicasVal, found, err := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+	if err != nil {
+		return "", "", fmt.Errorf("%w: unable to get %s replicas", err, workload.GetName())
+	}
+	if found {
+		_, err = values.Add(replicasVal, nameCamel, "replicas")
+		if err != nil {
+			return "", "", err
+		}
+		replicas = fmt.Sprintf(ReplicasTempl, nameCamel)
+	}
+	if autoscaled {
+		return replicas, "", nil
+	}
+	autoscaler, autoscalerValues, err := disabled(appMeta, workload)
+	if err != nil {
+		return "", "", err
+	}
+	err = values.Merge(autoscalerValues)
+	if err != nil {
+		return "", "", err
+	}
+	return replicas, autoscaler, nil
+}
+
+// disabled - returns disabled by default HorizontalPodAutoscaler template for the workload without autoscaler.
+func disabled(appMeta helmify.AppMetadata, workload *unstructured.Unstructured) (string, helmify.Values, error) {
+	minReplicas, found, err := unstructured.NestedInt64(workload.Object, "spec", "replicas")
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: unable to get %s replicas", err, workload.GetName())
+	}
+	if !found || minReplicas < 1 {
+		minReplicas = 1
+	}
+	maxReplicas := int32(defaultMaxReplicas)
+	if int32(minReplicas) > maxReplicas {
+		maxReplicas = int32(minReplicas)
+	}
+	min := int32(minReplicas)
+	utilization := int32(defaultCPUUtilization)
+	spec := autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+			APIVersion: workload.GetAPIVersion(),
+			Kind:       workload.GetKind(),
+			Name:       workload.GetName(),
+		},
+		MinReplicas: &min,
+		MaxReplicas: maxReplicas,
+		Metrics: []autoscalingv2.MetricSpec{{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: "cpu",
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &utilization,
+				},
+			},
+		}},
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(hpaGVC)
+	obj.SetName(workload.GetName())
+	obj.SetNamespace(workload.GetNamespace())
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return "", nil, err
+	}
+	return template(appMeta, meta, spec, false)
+}
+
+func template(appMeta helmify.AppMetadata, meta string, spec autoscalingv2.HorizontalPodAutoscalerSpec, enabled bool) (string, helmify.Values, error) {
+	// generated autoscaler is appended to the workload template
+	separator := ""
+	if !enabled {
+		separator = "---\n"
+	}
+	target := spec.ScaleTargetRef
+	name := strcase.ToLowerCamel(appMeta.TrimName(target.Name))
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: unable to convert autoscaler spec", err)
+	}
+	minReplicas := int64(1)
+	if spec.MinReplicas != nil {
+		minReplicas = int64(*spec.MinReplicas)
+	}
+	autoscaling := map[string]interface{}{
+		"enabled":     enabled,
+		"minReplicas": minReplicas,
+		"maxReplicas": int64(spec.MaxReplicas),
+	}
+	for _, key := range []string{"metrics", "behavior"} {
+		if value, ok := specMap[key]; ok {
+			autoscaling[key] = value
+		}
+	}
+	values := helmify.Values{}
+	err = unstructured.SetNestedField(values, autoscaling, name, "autoscaling")
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: unable to set autoscaling values", err)
+	}
+	res := fmt.Sprintf(hpaTempl, name, meta, target.APIVersion, target.Kind, appMeta.TemplatedName(target.Name), separator)
+	return res, values, nil
+}
+
+type result struct {
+	name   string
+	data   string
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write([]byte(r.data))
+	return err
+}
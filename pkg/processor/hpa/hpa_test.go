@@ -0,0 +1,115 @@
+package hpa
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+const hpaYaml = `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-app-web
+  namespace: my-app-system
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: my-app-web
+  minReplicas: 2
+  maxReplicas: 5
+  metrics:
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: 70`
+
+const deploymentYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+  namespace: my-app-system
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx`
+
+func Test_hpa_Process(t *testing.T) {
+	var testInstance hpa
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(hpaYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(obj)
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, helmify.Values{"myAppWeb": map[string]interface{}{"autoscaling": map[string]interface{}{
+			"enabled":     true,
+			"minReplicas": int64(2),
+			"maxReplicas": int64(5),
+			"metrics": []interface{}{map[string]interface{}{
+				"type": "Resource",
+				"resource": map[string]interface{}{
+					"name":   "cpu",
+					"target": map[string]interface{}{"type": "Utilization", "averageUtilization": int64(70)},
+				},
+			}},
+		}}}, tt.Values())
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Contains(t, buf.String(), `{{- if .Values.myAppWeb.autoscaling.enabled }}`)
+		assert.Contains(t, buf.String(), `name: {{ include "chart.fullname" . }}-my-app-web`)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
+
+func TestProcessWorkload(t *testing.T) {
+	t.Run("autoscaled", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(hpaYaml))
+		values := helmify.Values{}
+		replicas, autoscaler, err := ProcessWorkload(appMeta, internal.GenerateObj(deploymentYaml), &values)
+		assert.NoError(t, err)
+		assert.Contains(t, replicas, "{{- if not .Values.myAppWeb.autoscaling.enabled }}")
+		assert.Empty(t, autoscaler)
+		assert.Equal(t, helmify.Values{"myAppWeb": map[string]interface{}{"replicas": int64(3)}}, values)
+	})
+	t.Run("generated", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart", GenerateAutoscaling: true})
+		values := helmify.Values{}
+		replicas, autoscaler, err := ProcessWorkload(appMeta, internal.GenerateObj(deploymentYaml), &values)
+		assert.NoError(t, err)
+		assert.Contains(t, replicas, "{{- if not .Values.myAppWeb.autoscaling.enabled }}")
+		assert.Contains(t, autoscaler, "---\napiVersion: autoscaling/v2")
+		enabled, _, _ := unstructured.NestedBool(values, "myAppWeb", "autoscaling", "enabled")
+		assert.False(t, enabled)
+		assert.Equal(t, int64(3), values["myAppWeb"].(map[string]interface{})["autoscaling"].(map[string]interface{})["minReplicas"])
+	})
+	t.Run("disabled", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		values := helmify.Values{}
+		replicas, autoscaler, err := ProcessWorkload(appMeta, internal.GenerateObj(deploymentYaml), &values)
+		assert.NoError(t, err)
+		assert.Empty(t, replicas)
+		assert.Empty(t, autoscaler)
+		assert.Empty(t, values)
+	})
+}
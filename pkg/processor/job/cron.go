@@ -114,6 +114,20 @@ func (p cron) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 	if err != nil {
 		return true, nil, err
 	}
+	podMeta, _, err := unstructured.NestedMap(specMap, "jobTemplate", "spec", "template", "metadata")
+	if err != nil {
+		return true, nil, err
+	}
+	podMeta, err = pod.ProcessTemplateMetaDefaults(nameCamelCase, appMeta, podMeta, values)
+	if err != nil {
+		return true, nil, err
+	}
+	if podMeta != nil {
+		err = unstructured.SetNestedMap(specMap, podMeta, "jobTemplate", "spec", "template", "metadata")
+		if err != nil {
+			return true, nil, err
+		}
+	}
 
 	err = unstructured.SetNestedMap(specMap, podSpecMap, "jobTemplate", "spec", "template", "spec")
 	if err != nil {
@@ -125,6 +139,7 @@ func (p cron) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 		return true, nil, err
 	}
 	specStr = strings.ReplaceAll(specStr, "'", "")
+	specStr = pod.ExpandDefaults(specStr)
 
 	return true, &resultCron{
 		name: name + ".yaml",
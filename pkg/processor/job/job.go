@@ -113,6 +113,20 @@ func (p job) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 	if err != nil {
 		return true, nil, err
 	}
+	podMeta, _, err := unstructured.NestedMap(specMap, "template", "metadata")
+	if err != nil {
+		return true, nil, err
+	}
+	podMeta, err = pod.ProcessTemplateMetaDefaults(nameCamelCase, appMeta, podMeta, values)
+	if err != nil {
+		return true, nil, err
+	}
+	if podMeta != nil {
+		err = unstructured.SetNestedMap(specMap, podMeta, "template", "metadata")
+		if err != nil {
+			return true, nil, err
+		}
+	}
 
 	err = unstructured.SetNestedMap(specMap, podSpecMap, "template", "spec")
 	if err != nil {
@@ -124,6 +138,7 @@ func (p job) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 		return true, nil, err
 	}
 	specStr = strings.ReplaceAll(specStr, "'", "")
+	specStr = pod.ExpandDefaults(specStr)
 
 	return true, &result{
 		name: name + ".yaml",
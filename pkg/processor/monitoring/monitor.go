@@ -0,0 +1,148 @@
+// Package monitoring converts Prometheus operator objects into Helm templates.
+// Objects are rendered only if enabled in values and monitoring.coreos.com API is available in the cluster.
+// See https://prometheus-operator.dev/
+package monitoring
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Group - Prometheus operator API group.
+	Group = "monitoring.coreos.com"
+	// EnabledValue - values toggle for all Prometheus operator objects of the chart.
+	EnabledValue = "metrics.serviceMonitor.enabled"
+
+	// guardTempl - renders object only if enabled and its API is served by the cluster.
+	guardTempl = `{{- if and .Values.` + EnabledValue + ` (.Capabilities.APIVersions.Has "%[1]s") }}
+%[2]s
+spec:%[3]s
+{{- end }}`
+)
+
+// ServiceMonitor creates processor for ServiceMonitor resource.
+func ServiceMonitor() helmify.Processor {
+	return &monitor{kind: "ServiceMonitor", targetKind: "Service"}
+}
+
+// PodMonitor creates processor for PodMonitor resource.
+func PodMonitor() helmify.Processor {
+	return &monitor{kind: "PodMonitor", targetKind: "Pod"}
+}
+
+type monitor struct {
+	kind string
+	// targetKind - kind of objects selected by the monitor.
+	targetKind string
+}
+
+// Process ServiceMonitor or PodMonitor object into template. Returns false if not capable of processing given resource type.
+// Selector matching chart objects is extended with chart selector labels and app namespace in namespace selector
+// is replaced with the release namespace.
+func (m monitor) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != Group || gvk.Kind != m.kind {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s %s spec", err, m.kind, obj.GetName())
+	}
+	selector := metav1.LabelSelector{}
+	if s, ok := spec["selector"].(map[string]interface{}); ok {
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(s, &selector)
+		if err != nil {
+			return true, nil, fmt.Errorf("%w: unable to get %s %s selector", err, m.kind, obj.GetName())
+		}
+	}
+	delete(spec, "selector")
+	// monitor selects objects in its own namespace if namespace selector is not set
+	local := true
+	if nsSelector, ok := spec["namespaceSelector"].(map[string]interface{}); ok {
+		local = templateNamespaces(appMeta, nsSelector)
+	}
+
+	res := ""
+	if len(spec) != 0 {
+		res, err = yamlformat.Marshal(spec, 2)
+		if err != nil {
+			return true, nil, err
+		}
+		res = "\n" + strings.TrimRight(res, "\n")
+	}
+	sel, err := processor.ProcessSelector(appMeta, &selector, local && appMeta.Selects(m.targetKind, &selector), 4)
+	if err != nil {
+		return true, nil, err
+	}
+	res += "\n  selector:" + sel
+	tmpl, err := newResult(appMeta, obj, meta, res)
+	return true, tmpl, err
+}
+
+// templateNamespaces - replaces app namespace in namespace selector matchNames with the release namespace.
+// Returns true if selector matches the app namespace.
+func templateNamespaces(appMeta helmify.AppMetadata, nsSelector map[string]interface{}) bool {
+	if anyNs, _ := nsSelector["any"].(bool); anyNs {
+		return true
+	}
+	names, _ := nsSelector["matchNames"].([]interface{})
+	if len(names) == 0 {
+		return true
+	}
+	found := false
+	for i, name := range names {
+		if name == appMeta.Namespace() && appMeta.Namespace() != "" {
+			names[i] = "{{ .Release.Namespace }}"
+			found = true
+		}
+	}
+	return found
+}
+
+// newResult - wraps object spec into enabled and API capabilities check.
+func newResult(appMeta helmify.AppMetadata, obj *unstructured.Unstructured, meta, spec string) (helmify.Template, error) {
+	values := helmify.Values{}
+	_, err := values.Add(true, strings.Split(EnabledValue, ".")...)
+	if err != nil {
+		return nil, err
+	}
+	gvk := obj.GroupVersionKind()
+	api := gvk.GroupVersion().String() + "/" + gvk.Kind
+	return &result{
+		name:   appMeta.TrimName(obj.GetName()),
+		data:   fmt.Sprintf(guardTempl, api, meta, spec),
+		values: values,
+	}, nil
+}
+
+type result struct {
+	name   string
+	data   string
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write([]byte(r.data))
+	return err
+}
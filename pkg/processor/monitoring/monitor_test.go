@@ -0,0 +1,104 @@
+package monitoring
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	serviceMonitorYaml = `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: my-operator-metrics-monitor
+  namespace: my-operator-system
+spec:
+  endpoints:
+  - path: /metrics
+    port: https
+  namespaceSelector:
+    matchNames:
+    - my-operator-system
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: my-operator
+      control-plane: controller-manager`
+	serviceYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-operator-metrics-service
+  namespace: my-operator-system
+  labels:
+    app.kubernetes.io/name: my-operator
+    control-plane: controller-manager`
+	podMonitorYaml = `apiVersion: monitoring.coreos.com/v1
+kind: PodMonitor
+metadata:
+  name: my-operator-pods
+  namespace: my-operator-system
+spec:
+  podMetricsEndpoints:
+  - port: metrics
+  selector:
+    matchLabels:
+      app: external`
+)
+
+func Test_monitor_Process(t *testing.T) {
+	t.Run("service monitor", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(serviceMonitorYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(serviceYaml))
+		processed, tt, err := ServiceMonitor().Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, "monitor.yaml", tt.Filename())
+		assert.Equal(t, helmify.Values{"metrics": map[string]interface{}{
+			"serviceMonitor": map[string]interface{}{"enabled": true},
+		}}, tt.Values())
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Equal(t, `{{- if and .Values.metrics.serviceMonitor.enabled (.Capabilities.APIVersions.Has "monitoring.coreos.com/v1/ServiceMonitor") }}
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: {{ include "chart.fullname" . }}-monitor
+  labels:
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  endpoints:
+  - path: /metrics
+    port: https
+  namespaceSelector:
+    matchNames:
+    - '{{ .Release.Namespace }}'
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+      {{- include "chart.selectorLabels" . | nindent 6 }}
+{{- end }}`, buf.String())
+	})
+	t.Run("pod monitor", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(podMonitorYaml)
+		appMeta.Load(obj)
+		processed, tt, err := PodMonitor().Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Contains(t, buf.String(), `.Capabilities.APIVersions.Has "monitoring.coreos.com/v1/PodMonitor"`)
+		assert.Contains(t, buf.String(), "  selector:\n    matchLabels:\n      app: external\n{{- end }}")
+	})
+	t.Run("skipped", func(t *testing.T) {
+		processed, _, err := PodMonitor().Process(&metadata.Service{}, internal.GenerateObj(serviceMonitorYaml))
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
@@ -0,0 +1,66 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// escaper - escapes Go template delimiters, so Prometheus templates like {{ $labels.instance }} are not evaluated by Helm.
+// Raw strings are used because rule expressions may be rendered as double-quoted yaml strings.
+var escaper = strings.NewReplacer("{{", "{{`{{`}}", "}}", "{{`}}`}}")
+
+// PrometheusRule creates processor for PrometheusRule resource.
+func PrometheusRule() helmify.Processor {
+	return &prometheusRule{}
+}
+
+type prometheusRule struct{}
+
+// Process PrometheusRule object into template. Returns false if not capable of processing given resource type.
+func (p prometheusRule) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != Group || gvk.Kind != "PrometheusRule" {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get PrometheusRule %s spec", err, obj.GetName())
+	}
+	res, err := yamlformat.Marshal(escape(spec), 2)
+	if err != nil {
+		return true, nil, err
+	}
+	tmpl, err := newResult(appMeta, obj, meta, "\n"+strings.TrimRight(res, "\n"))
+	return true, tmpl, err
+}
+
+// escape - returns value with escaped Go template delimiters in all strings.
+func escape(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return escaper.Replace(v)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			res[escaper.Replace(k)] = escape(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			res = append(res, escape(item))
+		}
+		return res
+	default:
+		return value
+	}
+}
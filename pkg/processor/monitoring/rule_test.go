@@ -0,0 +1,55 @@
+package monitoring
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const prometheusRuleYaml = `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: my-operator-alerts
+  namespace: my-operator-system
+spec:
+  groups:
+  - name: operator
+    rules:
+    - alert: OperatorDown
+      expr: up{job="my-operator"} == 0
+      for: 5m
+      annotations:
+        summary: "Instance {{ $labels.instance }} is down"
+        description: "{{ $value }} targets\nare down"`
+
+func Test_prometheusRule_Process(t *testing.T) {
+	var testInstance prometheusRule
+
+	t.Run("processed", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(prometheusRuleYaml)
+		appMeta.Load(obj)
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Contains(t, buf.String(), `.Capabilities.APIVersions.Has "monitoring.coreos.com/v1/PrometheusRule"`)
+		assert.Contains(t, buf.String(), "summary: Instance {{`{{`}} $labels.instance {{`}}`}} is down")
+		assert.Contains(t, buf.String(), "description: |-\n          {{`{{`}} $value {{`}}`}} targets\n          are down")
+	})
+	t.Run("skipped", func(t *testing.T) {
+		processed, _, err := testInstance.Process(&metadata.Service{}, internal.TestNs)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
+
+func Test_escape(t *testing.T) {
+	assert.Equal(t, "{{`{{`}} $labels.pod {{`}}`}}", escape("{{ $labels.pod }}"))
+	assert.Equal(t, map[string]interface{}{"a": []interface{}{"{{`}}`}}", int64(1)}}, escape(map[string]interface{}{"a": []interface{}{"}}", int64(1)}}))
+}
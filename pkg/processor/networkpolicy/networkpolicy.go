@@ -0,0 +1,290 @@
+package networkpolicy
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// EnabledValue - global values toggle for all chart network policies.
+	EnabledValue = "networkPolicy.enabled"
+	// namespaceLabel - label set by k8s on every namespace with the namespace name.
+	namespaceLabel = "kubernetes.io/metadata.name"
+
+	policyTempl = `{{- if .Values.` + EnabledValue + ` }}
+%[1]s
+spec:
+  podSelector:%[2]s%[3]s
+{{- end }}`
+
+	// ruleTempl - ingress or egress rule with ip blocks and ports taken from values.
+	ruleTempl = `
+  - %[1]s:%[2]s
+    {{- range (index .Values.%[3]s.%[4]s %[5]d).ipBlocks }}
+    - ipBlock:
+        {{- toYaml . | nindent 8 }}
+    {{- end }}
+    {{- with (index .Values.%[3]s.%[4]s %[5]d).ports }}
+    ports:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}`
+
+	// ipBlocksRuleTempl - rule with ip block peers only. Rule is skipped if ip blocks are empty in values,
+	// because rule without peers allows all sources or destinations.
+	ipBlocksRuleTempl = `
+  {{- if (index .Values.%[3]s.%[4]s %[5]d).ipBlocks }}` + ruleTempl + `
+  {{- end }}`
+)
+
+var networkPolicyGVC = schema.GroupVersionKind{
+	Group:   "networking.k8s.io",
+	Version: "v1",
+	Kind:    "NetworkPolicy",
+}
+
+// New creates processor for k8s NetworkPolicy resource.
+func New() helmify.Processor {
+	return &networkPolicy{}
+}
+
+type networkPolicy struct{}
+
+// Process k8s NetworkPolicy object into template. Returns false if not capable of processing given resource type.
+// Pod selectors matching chart workloads are extended with chart selector labels and namespace selectors
+// of the app namespace are replaced with the release namespace. Ip blocks and ports of every rule are
+// placed into values under the policy name. All policies are wrapped into global networkPolicy.enabled toggle.
+func (n networkPolicy) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if obj.GroupVersionKind() != networkPolicyGVC {
+		return false, nil, nil
+	}
+	policy := networkingv1.NetworkPolicy{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &policy)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to cast to NetworkPolicy", err)
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
+	podSelector, err := selector(appMeta, &policy.Spec.PodSelector, true, 4)
+	if err != nil {
+		return true, nil, err
+	}
+	if len(policy.Spec.PolicyTypes) == 0 && hasIPBlocksOnlyRule(policy.Spec) {
+		// policy types are defaulted from rules by k8s: keep egress restricted if all its rules are skipped
+		policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if policy.Spec.Egress != nil {
+			policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		}
+	}
+	spec := ""
+	if len(policy.Spec.PolicyTypes) != 0 {
+		spec, err = yamlformat.Marshal(map[string]interface{}{"policyTypes": policy.Spec.PolicyTypes}, 2)
+		if err != nil {
+			return true, nil, err
+		}
+		spec = "\n" + strings.TrimRight(spec, "\n")
+	}
+
+	values := helmify.Values{}
+	_, err = values.Add(true, strings.Split(EnabledValue, ".")...)
+	if err != nil {
+		return true, nil, err
+	}
+	if policy.Spec.Ingress != nil {
+		rules := make([]rule, 0, len(policy.Spec.Ingress))
+		for _, r := range policy.Spec.Ingress {
+			rules = append(rules, rule{peers: r.From, ports: r.Ports})
+		}
+		res, err := processRules(appMeta, &values, nameCamel, "ingress", "from", rules)
+		if err != nil {
+			return true, nil, err
+		}
+		spec += res
+	}
+	if policy.Spec.Egress != nil {
+		rules := make([]rule, 0, len(policy.Spec.Egress))
+		for _, r := range policy.Spec.Egress {
+			rules = append(rules, rule{peers: r.To, ports: r.Ports})
+		}
+		res, err := processRules(appMeta, &values, nameCamel, "egress", "to", rules)
+		if err != nil {
+			return true, nil, err
+		}
+		spec += res
+	}
+
+	return true, &result{
+		name:   name,
+		data:   fmt.Sprintf(policyTempl, meta, podSelector, spec),
+		values: values,
+	}, nil
+}
+
+// rule - NetworkPolicy ingress or egress rule.
+type rule struct {
+	peers []networkingv1.NetworkPolicyPeer
+	ports []networkingv1.NetworkPolicyPort
+}
+
+// hasIPBlocksOnlyRule - returns true if policy has a rule with ip block peers only.
+func hasIPBlocksOnlyRule(spec networkingv1.NetworkPolicySpec) bool {
+	for _, r := range spec.Ingress {
+		if ipBlocksOnly(r.From) {
+			return true
+		}
+	}
+	for _, r := range spec.Egress {
+		if ipBlocksOnly(r.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipBlocksOnly - returns true if all peers are ip blocks.
+func ipBlocksOnly(peers []networkingv1.NetworkPolicyPeer) bool {
+	for _, p := range peers {
+		if p.IPBlock == nil {
+			return false
+		}
+	}
+	return len(peers) != 0
+}
+
+// processRules - returns rules template with peer selectors. Ip blocks and ports of every rule are added to values.
+func processRules(appMeta helmify.AppMetadata, values *helmify.Values, nameCamel, key, peersKey string, rules []rule) (string, error) {
+	res := "\n  " + key + ":"
+	if len(rules) == 0 {
+		res += " []"
+	}
+	ruleValues := make([]interface{}, 0, len(rules))
+	for i, r := range rules {
+		ipBlocks := make([]interface{}, 0)
+		peers := ""
+		for _, p := range r.peers {
+			if p.IPBlock != nil {
+				ipBlock, err := runtime.DefaultUnstructuredConverter.ToUnstructured(p.IPBlock)
+				if err != nil {
+					return "", fmt.Errorf("%w: unable to convert ip block", err)
+				}
+				ipBlocks = append(ipBlocks, ipBlock)
+				continue
+			}
+			peer, err := processPeer(appMeta, p)
+			if err != nil {
+				return "", err
+			}
+			peers += peer
+		}
+		ports := make([]interface{}, 0, len(r.ports))
+		for j := range r.ports {
+			port, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&r.ports[j])
+			if err != nil {
+				return "", fmt.Errorf("%w: unable to convert port", err)
+			}
+			ports = append(ports, port)
+		}
+		ruleValues = append(ruleValues, map[string]interface{}{"ipBlocks": ipBlocks, "ports": ports})
+		templ := ruleTempl
+		if ipBlocksOnly(r.peers) {
+			templ = ipBlocksRuleTempl
+		}
+		res += fmt.Sprintf(templ, peersKey, peers, nameCamel, key, i)
+	}
+	err := unstructured.SetNestedSlice(*values, ruleValues, nameCamel, key)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to set %s values", err, key)
+	}
+	return res, nil
+}
+
+// processPeer - returns peer list item with templated pod and namespace selectors.
+func processPeer(appMeta helmify.AppMetadata, peer networkingv1.NetworkPolicyPeer) (string, error) {
+	res := ""
+	prefix := "\n    - "
+	// pods in the release namespace are selected if peer has no namespace selector
+	local := peer.NamespaceSelector == nil
+	if peer.NamespaceSelector != nil {
+		local = templateNamespace(appMeta, peer.NamespaceSelector)
+		s, err := selector(appMeta, peer.NamespaceSelector, false, 8)
+		if err != nil {
+			return "", err
+		}
+		res += prefix + "namespaceSelector:" + s
+		prefix = "\n      "
+	}
+	if peer.PodSelector != nil {
+		s, err := selector(appMeta, peer.PodSelector, local, 8)
+		if err != nil {
+			return "", err
+		}
+		res += prefix + "podSelector:" + s
+	}
+	return res, nil
+}
+
+// templateNamespace - replaces app namespace name in namespace selector with the release namespace.
+// Returns true if selector refers to the app namespace.
+func templateNamespace(appMeta helmify.AppMetadata, sel *metav1.LabelSelector) bool {
+	ns := appMeta.Namespace()
+	if ns == "" {
+		return false
+	}
+	found := false
+	if sel.MatchLabels[namespaceLabel] == ns {
+		sel.MatchLabels[namespaceLabel] = "{{ .Release.Namespace }}"
+		found = true
+	}
+	for i, expr := range sel.MatchExpressions {
+		if expr.Key != namespaceLabel {
+			continue
+		}
+		for j, v := range expr.Values {
+			if v == ns {
+				sel.MatchExpressions[i].Values[j] = "{{ .Release.Namespace }}"
+				found = found || expr.Operator == metav1.LabelSelectorOpIn
+			}
+		}
+	}
+	return found
+}
+
+// selector - returns label selector yaml with given indent. Selector matching chart workloads is extended
+// with chart selector labels if local is true.
+func selector(appMeta helmify.AppMetadata, sel *metav1.LabelSelector, local bool, indent int) (string, error) {
+	return processor.ProcessSelector(appMeta, sel, local && appMeta.Selects("Pod", sel), indent)
+}
+
+type result struct {
+	name   string
+	data   string
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write([]byte(r.data))
+	return err
+}
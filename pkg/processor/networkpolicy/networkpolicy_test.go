@@ -0,0 +1,218 @@
+package networkpolicy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	policyYaml = `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: my-app-db
+  namespace: my-app-system
+spec:
+  podSelector:
+    matchLabels:
+      app: db
+  policyTypes:
+  - Ingress
+  - Egress
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app: web
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: my-app-system
+      podSelector:
+        matchLabels:
+          app: web
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: monitoring
+      podSelector:
+        matchLabels:
+          app: web
+    - ipBlock:
+        cidr: 10.0.0.0/16
+        except:
+        - 10.0.1.0/24
+    ports:
+    - protocol: TCP
+      port: 5432
+  egress:
+  - to:
+    - podSelector:
+        matchLabels:
+          app: external`
+	ipBlocksPolicyYaml = `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: my-app-egress
+  namespace: my-app-system
+spec:
+  podSelector: {}
+  egress:
+  - to:
+    - ipBlock:
+        cidr: 10.0.0.0/16`
+	dbYaml = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+  namespace: my-app-system
+spec:
+  template:
+    metadata:
+      labels:
+        app: db`
+	webYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+  namespace: my-app-system
+spec:
+  template:
+    metadata:
+      labels:
+        app: web`
+)
+
+func Test_networkPolicy_Process(t *testing.T) {
+	var testInstance networkPolicy
+
+	t.Run("processed", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(policyYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(dbYaml))
+		appMeta.Load(internal.GenerateObj(webYaml))
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, "db.yaml", tt.Filename())
+		assert.Equal(t, helmify.Values{
+			"networkPolicy": map[string]interface{}{"enabled": true},
+			"db": map[string]interface{}{
+				"ingress": []interface{}{map[string]interface{}{
+					"ipBlocks": []interface{}{map[string]interface{}{
+						"cidr":   "10.0.0.0/16",
+						"except": []interface{}{"10.0.1.0/24"},
+					}},
+					"ports": []interface{}{map[string]interface{}{"protocol": "TCP", "port": int64(5432)}},
+				}},
+				"egress": []interface{}{map[string]interface{}{
+					"ipBlocks": []interface{}{},
+					"ports":    []interface{}{},
+				}},
+			},
+		}, tt.Values())
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Equal(t, `{{- if .Values.networkPolicy.enabled }}
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: {{ include "chart.fullname" . }}-db
+  labels:
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  podSelector:
+    matchLabels:
+      app: db
+      {{- include "chart.selectorLabels" . | nindent 6 }}
+  policyTypes:
+  - Ingress
+  - Egress
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app: web
+          {{- include "chart.selectorLabels" . | nindent 10 }}
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: '{{ .Release.Namespace }}'
+      podSelector:
+        matchLabels:
+          app: web
+          {{- include "chart.selectorLabels" . | nindent 10 }}
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: monitoring
+      podSelector:
+        matchLabels:
+          app: web
+    {{- range (index .Values.db.ingress 0).ipBlocks }}
+    - ipBlock:
+        {{- toYaml . | nindent 8 }}
+    {{- end }}
+    {{- with (index .Values.db.ingress 0).ports }}
+    ports:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+  egress:
+  - to:
+    - podSelector:
+        matchLabels:
+          app: external
+    {{- range (index .Values.db.egress 0).ipBlocks }}
+    - ipBlock:
+        {{- toYaml . | nindent 8 }}
+    {{- end }}
+    {{- with (index .Values.db.egress 0).ports }}
+    ports:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+{{- end }}`, buf.String())
+	})
+	t.Run("ip blocks only", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(ipBlocksPolicyYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(dbYaml))
+		processed, tt, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Equal(t, `{{- if .Values.networkPolicy.enabled }}
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: {{ include "chart.fullname" . }}-egress
+  labels:
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+  egress:
+  {{- if (index .Values.egress.egress 0).ipBlocks }}
+  - to:
+    {{- range (index .Values.egress.egress 0).ipBlocks }}
+    - ipBlock:
+        {{- toYaml . | nindent 8 }}
+    {{- end }}
+    {{- with (index .Values.egress.egress 0).ports }}
+    ports:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+  {{- end }}
+{{- end }}`, buf.String())
+	})
+	t.Run("skipped", func(t *testing.T) {
+		processed, _, err := testInstance.Process(&metadata.Service{}, internal.TestNs)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
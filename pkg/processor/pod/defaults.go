@@ -0,0 +1,132 @@
+package pod
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/iancoleman/strcase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Placeholders are put into pod spec map and replaced with conditional Helm blocks after the map is marshalled to yaml.
+const (
+	withPlaceholder   = "HELMIFYWITH."
+	scalarPlaceholder = "HELMIFYSCALAR."
+	inlinePlaceholder = "HELMIFYINLINE."
+)
+
+var (
+	withRe       = regexp.MustCompile(`(?m)^( *)([\w-]+): ` + regexp.QuoteMeta(withPlaceholder) + `([\w.]+)$`)
+	scalarRe     = regexp.MustCompile(`(?m)^( *)([\w-]+): ` + regexp.QuoteMeta(scalarPlaceholder) + `([\w.]+)$`)
+	inlineItemRe = regexp.MustCompile(`(?m)^( *)- ` + regexp.QuoteMeta(inlinePlaceholder) + `([\w.]+)$`)
+	inlineKeyRe  = regexp.MustCompile(`(?m)^( *)` + regexp.QuoteMeta(inlinePlaceholder) + `([\w.]+): ""$`)
+)
+
+// ExpandDefaults - replaces default values placeholders in marshalled yaml with conditional Helm blocks,
+// so empty defaults are not rendered into manifests.
+func ExpandDefaults(yaml string) string {
+	yaml = withRe.ReplaceAllStringFunc(yaml, func(s string) string {
+		m := withRe.FindStringSubmatch(s)
+		return fmt.Sprintf("%[1]s{{- with .Values.%[3]s }}\n%[1]s%[2]s:\n%[1]s  {{- toYaml . | nindent %[4]d }}\n%[1]s{{- end }}", m[1], m[2], m[3], len(m[1])+2)
+	})
+	yaml = scalarRe.ReplaceAllString(yaml, "${1}{{- with .Values.${3} }}\n${1}${2}: {{ . }}\n${1}{{- end }}")
+	inline := func(re *regexp.Regexp) func(string) string {
+		return func(s string) string {
+			m := re.FindStringSubmatch(s)
+			return fmt.Sprintf("%[1]s{{- with .Values.%[2]s }}\n%[1]s{{- toYaml . | nindent %[3]d }}\n%[1]s{{- end }}", m[1], m[2], len(m[1]))
+		}
+	}
+	yaml = inlineItemRe.ReplaceAllStringFunc(yaml, inline(inlineItemRe))
+	return inlineKeyRe.ReplaceAllStringFunc(yaml, inline(inlineKeyRe))
+}
+
+// ProcessTemplateMetaDefaults - adds podLabels and podAnnotations placeholders into pod template metadata
+// if generation of defaults is enabled. Returns updated metadata, which should be expanded with ExpandDefaults
+// after marshalling.
+func ProcessTemplateMetaDefaults(objName string, appMeta helmify.AppMetadata, meta map[string]interface{}, values helmify.Values) (map[string]interface{}, error) {
+	if !appMeta.Config().GenerateDefaults {
+		return meta, nil
+	}
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	for key, valuesKey := range map[string]string{"labels": "podLabels", "annotations": "podAnnotations"} {
+		existing, ok := meta[key].(map[string]interface{})
+		switch {
+		case ok && len(existing) != 0:
+			existing[inlinePlaceholder+objName+"."+valuesKey] = ""
+		case key == "labels":
+			// labels are marshalled without key by some processors, so the block is always placed inside the map.
+			meta[key] = map[string]interface{}{inlinePlaceholder + objName + "." + valuesKey: ""}
+		default:
+			meta[key] = withPlaceholder + objName + "." + valuesKey
+		}
+		err := unstructured.SetNestedField(values, map[string]interface{}{}, objName, valuesKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to set %s default value", err, valuesKey)
+		}
+	}
+	return meta, nil
+}
+
+// processSpecDefaults - replaces common pod spec customization options with placeholders
+// and adds empty defaults into values.
+func processSpecDefaults(objName string, specMap map[string]interface{}, values helmify.Values) error {
+	for _, key := range []string{"affinity", "nodeSelector", "tolerations", "topologySpreadConstraints"} {
+		specMap[key] = withPlaceholder + objName + "." + key
+	}
+	specMap["priorityClassName"] = scalarPlaceholder + objName + ".priorityClassName"
+	defaults := map[string]interface{}{
+		"affinity":          map[string]interface{}{},
+		"priorityClassName": "",
+		"extraContainers":   []interface{}{},
+		"extraVolumes":      []interface{}{},
+	}
+	for key, val := range defaults {
+		_, exists, err := unstructured.NestedFieldNoCopy(values, objName, key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		err = unstructured.SetNestedField(values, val, objName, key)
+		if err != nil {
+			return fmt.Errorf("%w: unable to set %s default value", err, key)
+		}
+	}
+
+	appendInline(specMap, "volumes", objName+".extraVolumes")
+	for _, containerKey := range []string{"containers", "initContainers"} {
+		containers, _ := specMap[containerKey].([]interface{})
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := container["name"].(string)
+			containerName := strcase.ToLowerCamel(name)
+			for _, key := range []string{"extraEnv", "extraVolumeMounts"} {
+				err := unstructured.SetNestedField(values, []interface{}{}, objName, containerName, key)
+				if err != nil {
+					return fmt.Errorf("%w: unable to set %s default value", err, key)
+				}
+			}
+			appendInline(container, "env", objName+"."+containerName+".extraEnv")
+			appendInline(container, "volumeMounts", objName+"."+containerName+".extraVolumeMounts")
+		}
+	}
+	appendInline(specMap, "containers", objName+".extraContainers")
+	return nil
+}
+
+// appendInline - appends values list placeholder to a list. Creates conditional list if not exists.
+func appendInline(obj map[string]interface{}, key, valuesPath string) {
+	list, ok := obj[key].([]interface{})
+	if !ok || len(list) == 0 {
+		obj[key] = withPlaceholder + valuesPath
+		return
+	}
+	obj[key] = append(list, inlinePlaceholder+valuesPath)
+}
@@ -3,6 +3,7 @@ package pod
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/arttor/helmify/pkg/cluster"
@@ -18,6 +19,9 @@ const imagePullPolicyTemplate = "{{ .Values.%[1]s.%[2]s.imagePullPolicy }}"
 const envValue = "{{ quote .Values.%[1]s.%[2]s.%[3]s.%[4]s }}"
 const baseIndent = 8
 
+// singleQuotesRe - matches template expressions quoted by yaml marshaller.
+var singleQuotesRe = regexp.MustCompile(`'({{((.*|.*\n.*))}}.*)'`)
+
 func ProcessSpec(objName string, appMeta helmify.AppMetadata, spec corev1.PodSpec, addIndent int) (map[string]interface{}, helmify.Values, error) {
 	nindent := baseIndent + addIndent
 
@@ -178,6 +182,13 @@ func ProcessSpec(objName string, appMeta helmify.AppMetadata, spec corev1.PodSpe
 		}
 	}
 
+	if appMeta.Config().GenerateDefaults {
+		err = processSpecDefaults(objName, specMap, values)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return specMap, values, nil
 }
 
@@ -355,3 +366,8 @@ func processEnv(name string, appMeta helmify.AppMetadata, c corev1.Container, va
 	}
 	return c, nil
 }
+
+// ReplaceSingleQuotes - removes single quotes added by yaml marshaller around template expressions.
+func ReplaceSingleQuotes(s string) string {
+	return singleQuotesRe.ReplaceAllString(s, "${1}")
+}
@@ -3,6 +3,7 @@ package pod
 import (
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/metadata"
 	appsv1 "k8s.io/api/apps/v1"
@@ -527,4 +528,168 @@ func Test_pod_Process(t *testing.T) {
 			},
 		}, tmpl)
 	})
+	t.Run("deployment with defaults", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithNoArgs)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy)
+		assert.NoError(t, err)
+		appMeta := metadata.New(config.Config{GenerateDefaults: true})
+		specMap, tmpl, err := ProcessSpec("nginx", appMeta, deploy.Spec.Template.Spec, 0)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "HELMIFYWITH.nginx.affinity", specMap["affinity"])
+		assert.Equal(t, "HELMIFYWITH.nginx.tolerations", specMap["tolerations"])
+		assert.Equal(t, "HELMIFYSCALAR.nginx.priorityClassName", specMap["priorityClassName"])
+		assert.Equal(t, "HELMIFYWITH.nginx.extraVolumes", specMap["volumes"])
+		containers := specMap["containers"].([]interface{})
+		assert.Equal(t, "HELMIFYINLINE.nginx.extraContainers", containers[len(containers)-1])
+		container := containers[0].(map[string]interface{})
+		env := container["env"].([]interface{})
+		assert.Equal(t, "HELMIFYINLINE.nginx.nginx.extraEnv", env[len(env)-1])
+		assert.Equal(t, "HELMIFYWITH.nginx.nginx.extraVolumeMounts", container["volumeMounts"])
+
+		nginx := tmpl["nginx"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{}, nginx["affinity"])
+		assert.Equal(t, "", nginx["priorityClassName"])
+		assert.Equal(t, []interface{}{}, nginx["extraContainers"])
+		assert.Equal(t, []interface{}{}, nginx["extraVolumes"])
+		assert.Equal(t, []interface{}{}, nginx["nginx"].(map[string]interface{})["extraEnv"])
+		assert.Equal(t, []interface{}{}, nginx["nginx"].(map[string]interface{})["extraVolumeMounts"])
+	})
+}
+
+func TestExpandDefaults(t *testing.T) {
+	in := `      affinity: HELMIFYWITH.app.affinity
+      containers:
+      - env:
+        - name: A
+        - HELMIFYINLINE.app.c.extraEnv
+      labels:
+        HELMIFYINLINE.app.podLabels: ""
+        app: test
+      priorityClassName: HELMIFYSCALAR.app.priorityClassName`
+	expected := `      {{- with .Values.app.affinity }}
+      affinity:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      containers:
+      - env:
+        - name: A
+        {{- with .Values.app.c.extraEnv }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+      labels:
+        {{- with .Values.app.podLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        app: test
+      {{- with .Values.app.priorityClassName }}
+      priorityClassName: {{ . }}
+      {{- end }}`
+	assert.Equal(t, expected, ExpandDefaults(in))
+}
+
+func TestProcessTemplateMetaDefaults(t *testing.T) {
+	appMeta := metadata.New(config.Config{GenerateDefaults: true})
+	t.Run("without labels", func(t *testing.T) {
+		values := helmify.Values{}
+		meta, err := ProcessTemplateMetaDefaults("web", appMeta, nil, values)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"labels":      map[string]interface{}{"HELMIFYINLINE.web.podLabels": ""},
+			"annotations": "HELMIFYWITH.web.podAnnotations",
+		}, meta)
+		assert.Equal(t, helmify.Values{"web": map[string]interface{}{
+			"podLabels":      map[string]interface{}{},
+			"podAnnotations": map[string]interface{}{},
+		}}, values)
+	})
+	t.Run("with labels", func(t *testing.T) {
+		meta, err := ProcessTemplateMetaDefaults("web", appMeta, map[string]interface{}{
+			"labels": map[string]interface{}{"app": "web"},
+		}, helmify.Values{})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"app": "web", "HELMIFYINLINE.web.podLabels": ""}, meta["labels"])
+	})
+}
+
+var singleQuotesTest = []struct {
+	input    string
+	expected string
+}{
+	{
+		"{{ .Values.x }}",
+		"{{ .Values.x }}",
+	},
+	{
+		"'{{ .Values.x }}'",
+		"{{ .Values.x }}",
+	},
+	{
+		"'{{ .Values.x }}:{{ .Values.y }}'",
+		"{{ .Values.x }}:{{ .Values.y }}",
+	},
+	{
+		"'{{ .Values.x }}:{{ .Values.y \n\t| default .Chart.AppVersion}}'",
+		"{{ .Values.x }}:{{ .Values.y \n\t| default .Chart.AppVersion}}",
+	},
+	{
+		"echo 'x'",
+		"echo 'x'",
+	},
+	{
+		"abcd: x.y['x/y']",
+		"abcd: x.y['x/y']",
+	},
+	{
+		"abcd: x.y[\"'{{}}'\"]",
+		"abcd: x.y[\"{{}}\"]",
+	},
+	{
+		"image: '{{ .Values.x }}'",
+		"image: {{ .Values.x }}",
+	},
+	{
+		"'{{ .Values.x }} y'",
+		"{{ .Values.x }} y",
+	},
+	{
+		"\t\t- mountPath: './x.y'",
+		"\t\t- mountPath: './x.y'",
+	},
+	{
+		"'{{}}'",
+		"{{}}",
+	},
+	{
+		"'{{ {nested} }}'",
+		"{{ {nested} }}",
+	},
+	{
+		"'{{ '{{nested}}' }}'",
+		"{{ '{{nested}}' }}",
+	},
+	{
+		"'{{ unbalanced }'",
+		"'{{ unbalanced }'",
+	},
+	{
+		"'{{\nincomplete content'",
+		"'{{\nincomplete content'",
+	},
+	{
+		"'{{ @#$%^&*() }}'",
+		"{{ @#$%^&*() }}",
+	},
+}
+
+func TestReplaceSingleQuotes(t *testing.T) {
+	for _, tt := range singleQuotesTest {
+		t.Run(tt.input, func(t *testing.T) {
+			s := ReplaceSingleQuotes(tt.input)
+			if s != tt.expected {
+				t.Errorf("got %q, want %q", s, tt.expected)
+			}
+		})
+	}
 }
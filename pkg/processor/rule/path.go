@@ -0,0 +1,166 @@
+package rule
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// wildcard - list item selector matching all items.
+const wildcard = "[*]"
+
+// filterRe - JSONPath filter expression selecting list item by name, e.g. [?(@.name=="web")].
+var filterRe = regexp.MustCompile(`^\?\(@\.name\s*==\s*["']([^"']+)["']\)$`)
+
+// parsePath - splits JSONPath-like field path into keys and list item selectors.
+// Items are selected by index, by name or with wildcard, e.g.
+// "spec.containers[*].ports" -> ["spec", "containers", "[*]", "ports"] and
+// "$.spec.containers[?(@.name=="web")].ports" -> ["spec", "containers", "[name=web]", "ports"].
+func parsePath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	var res []string
+	for p != "" {
+		if strings.HasPrefix(p, "[") {
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid field path %q: unclosed bracket", path)
+			}
+			item, err := parseSelector(p[1:end])
+			if err != nil || len(res) == 0 {
+				return nil, fmt.Errorf("invalid list item selector %q in field path %q", p[:end+1], path)
+			}
+			res = append(res, item)
+			p = strings.TrimPrefix(p[end+1:], ".")
+			continue
+		}
+		end := strings.IndexAny(p, ".[")
+		if end < 0 {
+			end = len(p)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("invalid field path %q", path)
+		}
+		res = append(res, p[:end])
+		p = p[end:]
+		if strings.HasPrefix(p, ".") {
+			p = p[1:]
+			if p == "" {
+				return nil, fmt.Errorf("invalid field path %q", path)
+			}
+		}
+	}
+	if len(res) == 0 || isItem(res[len(res)-1]) {
+		return nil, fmt.Errorf("field path %q must end with a field name", path)
+	}
+	return res, nil
+}
+
+// parseSelector - normalizes list item selector to [<index>], [name=<name>] or [*].
+func parseSelector(selector string) (string, error) {
+	if selector == "*" {
+		return wildcard, nil
+	}
+	if m := filterRe.FindStringSubmatch(selector); m != nil {
+		return "[name=" + m[1] + "]", nil
+	}
+	if name, ok := strings.CutPrefix(selector, "name="); ok && name != "" {
+		return "[" + selector + "]", nil
+	}
+	if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+		return "[" + selector + "]", nil
+	}
+	return "", fmt.Errorf("invalid list item selector %q", selector)
+}
+
+func isItem(segment string) bool {
+	return strings.HasPrefix(segment, "[")
+}
+
+// parseItem - returns list item index or name of the item selector.
+func parseItem(segment string) (int, string) {
+	item := strings.TrimSuffix(strings.TrimPrefix(segment, "["), "]")
+	if name, ok := strings.CutPrefix(item, "name="); ok {
+		return -1, name
+	}
+	index, _ := strconv.Atoi(item)
+	return index, ""
+}
+
+// match - object field matched by the field path.
+type match struct {
+	// path - field path without wildcards.
+	path  []string
+	value interface{}
+	// vars - names of list items matched by wildcards, e.g. {"container": "web"}.
+	vars map[string]string
+}
+
+// expand - returns object fields matching the field path. Wildcards are replaced with selectors of matched items.
+// Matched item is referenced in value name by singular list key, e.g. {{container}} for containers[*].
+func expand(obj interface{}, path []string) []match {
+	res := []match{}
+	var walk func(obj interface{}, i int, m match)
+	walk = func(obj interface{}, i int, m match) {
+		if i == len(path) {
+			if obj != nil {
+				m.value = obj
+				res = append(res, m)
+			}
+			return
+		}
+		p := path[i]
+		if !isItem(p) {
+			if fields, ok := obj.(map[string]interface{}); ok {
+				if val, ok := fields[p]; ok {
+					m.path = append(m.path[:len(m.path):len(m.path)], p)
+					walk(val, i+1, m)
+				}
+			}
+			return
+		}
+		items, ok := obj.([]interface{})
+		if !ok {
+			return
+		}
+		index, name := parseItem(p)
+		for j, item := range items {
+			fields, _ := item.(map[string]interface{})
+			itemName, _ := fields["name"].(string)
+			if p != wildcard && !(name == "" && j == index) && !(name != "" && itemName == name) {
+				continue
+			}
+			next := m
+			next.path = append(m.path[:len(m.path):len(m.path)], p)
+			if p == wildcard {
+				next.path[len(next.path)-1] = "[" + strconv.Itoa(j) + "]"
+				if itemName != "" {
+					next.path[len(next.path)-1] = "[name=" + itemName + "]"
+				} else {
+					itemName = strconv.Itoa(j)
+				}
+				next.vars = map[string]string{singular(path[i-1]): strcase.ToLowerCamel(itemName)}
+				for k, v := range m.vars {
+					next.vars[k] = v
+				}
+			}
+			walk(item, i+1, next)
+		}
+	}
+	walk(obj, 0, match{vars: map[string]string{}})
+	return res
+}
+
+// singular - returns list item variable name for the list key, e.g. containers -> container.
+func singular(key string) string {
+	switch {
+	case strings.HasSuffix(key, "ies"):
+		return strings.TrimSuffix(key, "ies") + "y"
+	case strings.HasSuffix(key, "s"):
+		return strings.TrimSuffix(key, "s")
+	default:
+		return key
+	}
+}
@@ -0,0 +1,279 @@
+// Package rule applies project config rules to processed templates.
+package rule
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// globalValues - chart-wide values shared by templates which are not nested under values prefix.
+var globalValues = map[string]bool{"imagePullSecrets": true}
+
+// Wrap - extracts rule values from the input object into template values and nests template values under
+// rule values prefix. Input is the object before processing because processors are allowed to modify it.
+func Wrap(appMeta helmify.AppMetadata, input *unstructured.Unstructured, template helmify.Template, r config.Rule) (helmify.Template, error) {
+	values := helmify.Values{}
+	err := values.Merge(template.Values())
+	if err != nil {
+		return nil, err
+	}
+	res := &result{Template: template, kind: input.GetKind(), name: input.GetName()}
+	for _, v := range r.Values {
+		path, err := parsePath(v.Path)
+		if err != nil {
+			return nil, err
+		}
+		matches := expand(input.Object, path)
+		if len(matches) == 0 {
+			logrus.WithFields(logrus.Fields{
+				"Kind": input.GetKind(),
+				"Name": input.GetName(),
+				"Path": v.Path,
+			}).Warn("Skipping value rule: field not found")
+		}
+		for _, m := range matches {
+			m.vars["name"] = strcase.ToLowerCamel(appMeta.TrimName(input.GetName()))
+			m.vars["kind"] = strcase.ToLowerCamel(input.GetKind())
+			valueName, err := valueName(v.Value, m.vars)
+			if err != nil {
+				return nil, err
+			}
+			err = unstructured.SetNestedField(values, m.value, strings.Split(valueName, ".")...)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to set value %s", err, valueName)
+			}
+			res.fields = append(res.fields, field{path: m.path, ref: reference(m.value, valueName)})
+		}
+	}
+	if r.ValuesPrefix == "" {
+		res.values = values
+		return res, nil
+	}
+	var keys []string
+	nested := helmify.Values{}
+	res.values = helmify.Values{}
+	for k, v := range values {
+		if globalValues[k] {
+			res.values[k] = v
+			continue
+		}
+		keys = append(keys, regexp.QuoteMeta(k))
+		nested[k] = v
+	}
+	if len(keys) == 0 {
+		return res, nil
+	}
+	err = unstructured.SetNestedField(res.values, map[string]interface{}(nested), strings.Split(r.ValuesPrefix, ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to set values prefix %s", err, r.ValuesPrefix)
+	}
+	res.prefixRe = regexp.MustCompile(`\.Values\.((` + strings.Join(keys, "|") + `)\b)`)
+	res.prefix = r.ValuesPrefix
+	return res, nil
+}
+
+// varRe - matches value name variables, e.g. {{container}}.
+var varRe = regexp.MustCompile(`{{\s*(\w+)\s*}}`)
+
+// valueName - replaces variables in value name template with matched object field names.
+func valueName(tmpl string, vars map[string]string) (string, error) {
+	var err error
+	res := varRe.ReplaceAllStringFunc(tmpl, func(v string) string {
+		name := varRe.FindStringSubmatch(v)[1]
+		val, ok := vars[name]
+		if !ok {
+			err = fmt.Errorf("unknown variable %s in value %q", v, tmpl)
+		}
+		return val
+	})
+	return res, err
+}
+
+// field - object field replaced with values reference.
+type field struct {
+	path []string
+	// ref - values reference. Placeholder is replaced with nindent value.
+	ref string
+}
+
+const indentPlaceholder = "%INDENT%"
+
+// reference - returns helm template reference to the value.
+func reference(val interface{}, name string) string {
+	switch val.(type) {
+	case string:
+		return "{{ .Values." + name + " | quote }}"
+	case map[string]interface{}, []interface{}:
+		return "{{- toYaml .Values." + name + " | nindent " + indentPlaceholder + " }}"
+	default:
+		return "{{ .Values." + name + " }}"
+	}
+}
+
+type result struct {
+	helmify.Template
+	kind, name string
+	values     helmify.Values
+	fields     []field
+	prefix     string
+	prefixRe   *regexp.Regexp
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	buf := bytes.Buffer{}
+	err := r.Template.Write(&buf)
+	if err != nil {
+		return err
+	}
+	manifest := buf.String()
+	for _, f := range r.fields {
+		var replaced bool
+		manifest, replaced, err = replace(manifest, r.kind, f)
+		if err != nil {
+			return fmt.Errorf("%w: unable to extract %s of %s %s", err, strings.Join(f.path, "."), r.kind, r.name)
+		}
+		if !replaced {
+			logrus.WithFields(logrus.Fields{
+				"Kind": r.kind,
+				"Name": r.name,
+				"Path": strings.Join(f.path, "."),
+			}).Warn("Value rule field is not found in template")
+		}
+	}
+	if r.prefixRe != nil {
+		manifest = r.prefixRe.ReplaceAllString(manifest, ".Values."+r.prefix+".$1")
+	}
+	_, err = writer.Write([]byte(manifest))
+	return err
+}
+
+// actionRe - matches template actions. Long actions may be wrapped into several lines.
+var actionRe = regexp.MustCompile(`(?s){{.*?}}`)
+
+const actionMask = "\x00"
+
+// replace - replaces field value in the document of given kind with its values reference.
+// Template actions are masked to locate the field with yaml parser.
+func replace(manifest, kind string, f field) (string, bool, error) {
+	lines := strings.Split(manifest, "\n")
+	masked := strings.Split(actionRe.ReplaceAllStringFunc(manifest, func(action string) string {
+		return actionMask + strings.Repeat("\n", strings.Count(action, "\n"))
+	}), "\n")
+	// continued - lines which are continuations of wrapped actions.
+	continued := make([]bool, len(lines))
+	for i, l := range masked {
+		if strings.TrimSpace(strings.ReplaceAll(l, actionMask, "")) == "" {
+			continued[i] = !strings.Contains(l, actionMask) && strings.TrimSpace(lines[i]) != ""
+			masked[i] = "#"
+			continue
+		}
+		masked[i] = strings.ReplaceAll(l, actionMask, "helmify")
+	}
+	dec := yaml.NewDecoder(strings.NewReader(strings.Join(masked, "\n")))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return manifest, false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		root := doc.Content[0]
+		if _, k := findKey(root, "kind"); k == nil || k.Value != kind {
+			continue
+		}
+		key, value := find(root, f.path)
+		if key == nil {
+			continue
+		}
+		line := lines[key.Line-1]
+		col := key.Column - 1
+		colon := strings.Index(line[col:], ":")
+		if colon < 0 {
+			return "", false, fmt.Errorf("unexpected field at line %d", key.Line)
+		}
+		ref := strings.ReplaceAll(f.ref, indentPlaceholder, strconv.Itoa(col+2))
+		replaced := line[:col+colon+1] + " " + ref
+		res := append([]string{}, lines[:key.Line-1]...)
+		res = append(res, replaced)
+		end := lastLine(value)
+		for end < len(lines) && continued[end] {
+			end++
+		}
+		res = append(res, lines[end:]...)
+		return strings.Join(res, "\n"), true, nil
+	}
+}
+
+// find - returns key and value nodes of the field path. Path must end with a mapping key.
+func find(node *yaml.Node, path []string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+	for _, p := range path {
+		if node == nil {
+			return nil, nil
+		}
+		if !isItem(p) {
+			key, node = findKey(node, p)
+			continue
+		}
+		key = nil
+		if node.Kind != yaml.SequenceNode {
+			return nil, nil
+		}
+		index, name := parseItem(p)
+		var item *yaml.Node
+		for i, n := range node.Content {
+			if _, nameNode := findKey(n, "name"); (name == "" && i == index) || (name != "" && nameNode != nil && nameNode.Value == name) {
+				item = n
+				break
+			}
+		}
+		node = item
+	}
+	return key, node
+}
+
+func findKey(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// lastLine - returns last line number of the node.
+func lastLine(node *yaml.Node) int {
+	res := node.Line
+	if node.Kind == yaml.ScalarNode && (node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle) {
+		res += strings.Count(strings.TrimSuffix(node.Value, "\n"), "\n") + 1
+	}
+	for _, c := range node.Content {
+		if l := lastLine(c); l > res {
+			res = l
+		}
+	}
+	return res
+}
@@ -0,0 +1,172 @@
+package rule
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const strDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:v1
+      - name: web
+        image: web:v1
+        ports:
+        - containerPort: 8080
+          name: http
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: 8080`
+
+type testTemplate string
+
+func (t testTemplate) Filename() string { return "test.yaml" }
+func (t testTemplate) Values() helmify.Values {
+	return helmify.Values{
+		"web":              map[string]interface{}{"replicas": int64(2)},
+		"imagePullSecrets": []interface{}{},
+	}
+}
+func (t testTemplate) Write(w io.Writer) error {
+	_, err := w.Write([]byte(t))
+	return err
+}
+
+const template = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "test.fullname" . }}-web
+  labels:
+  {{- include "test.labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.web.replicas }}
+  template:
+    spec:
+      containers:
+      - image: sidecar:v1
+        name: sidecar
+      - image: web:v1
+        name: web
+        ports:
+        - containerPort: 8080
+          name: http
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: 8080
+      imagePullSecrets: {{ .Values.imagePullSecrets | default list | toJson
+        }}`
+
+func TestWrap(t *testing.T) {
+	obj := internal.GenerateObj(strDeployment)
+	tmpl, err := Wrap(&metadata.Service{}, obj, testTemplate(template), config.Rule{
+		ValuesPrefix: "backend",
+		Values: []config.ValueRule{
+			{Path: "spec.template.spec.containers[name=web].ports", Value: "web.ports"},
+			{Path: "spec.template.spec.containers[1].readinessProbe.httpGet.path", Value: "web.readinessPath"},
+			{Path: "spec.template.spec.containers[name=web].livenessProbe", Value: "web.livenessProbe"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, helmify.Values{
+		"imagePullSecrets": []interface{}{},
+		"backend": map[string]interface{}{
+			"web": map[string]interface{}{
+				"replicas":      int64(2),
+				"readinessPath": "/healthz",
+				"ports":         []interface{}{map[string]interface{}{"containerPort": int64(8080), "name": "http"}},
+			},
+		},
+	}, tmpl.Values())
+
+	buf := bytes.Buffer{}
+	require.NoError(t, tmpl.Write(&buf))
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "test.fullname" . }}-web
+  labels:
+  {{- include "test.labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.backend.web.replicas }}
+  template:
+    spec:
+      containers:
+      - image: sidecar:v1
+        name: sidecar
+      - image: web:v1
+        name: web
+        ports: {{- toYaml .Values.backend.web.ports | nindent 10 }}
+        readinessProbe:
+          httpGet:
+            path: {{ .Values.backend.web.readinessPath | quote }}
+            port: 8080
+      imagePullSecrets: {{ .Values.imagePullSecrets | default list | toJson
+        }}`, buf.String())
+}
+
+func TestWrap_wildcard(t *testing.T) {
+	obj := internal.GenerateObj(strDeployment)
+	tmpl, err := Wrap(&metadata.Service{}, obj, testTemplate(template), config.Rule{
+		Values: []config.ValueRule{
+			{Path: "$.spec.template.spec.containers[*].image", Value: "{{name}}.{{container}}.image"},
+			{Path: `spec.template.spec.containers[?(@.name=="web")].ports[*].containerPort`, Value: "{{ name }}.{{port}}Port"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, helmify.Values{
+		"imagePullSecrets": []interface{}{},
+		"web": map[string]interface{}{
+			"replicas": int64(2),
+			"sidecar":  map[string]interface{}{"image": "sidecar:v1"},
+			"web":      map[string]interface{}{"image": "web:v1"},
+			"httpPort": int64(8080),
+		},
+	}, tmpl.Values())
+
+	buf := bytes.Buffer{}
+	require.NoError(t, tmpl.Write(&buf))
+	assert.Contains(t, buf.String(), `      - image: {{ .Values.web.sidecar.image | quote }}
+        name: sidecar
+      - image: {{ .Values.web.web.image | quote }}
+        name: web
+        ports:
+        - containerPort: {{ .Values.web.httpPort }}
+          name: http
+`)
+
+	_, err = Wrap(&metadata.Service{}, obj, testTemplate(template), config.Rule{
+		Values: []config.ValueRule{{Path: "spec.replicas", Value: "{{name}}.{{container}}.replicas"}},
+	})
+	assert.Error(t, err)
+}
+
+func Test_parsePath(t *testing.T) {
+	path, err := parsePath("spec.containers[name=web].ports[0].containerPort")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spec", "containers", "[name=web]", "ports", "[0]", "containerPort"}, path)
+	path, err = parsePath(`$.spec.containers[?(@.name=="web")].ports[*].containerPort`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spec", "containers", "[name=web]", "ports", "[*]", "containerPort"}, path)
+
+	for _, invalid := range []string{"", "spec..ports", "spec.", "spec.ports[0]", "spec.ports[web].name", "spec.ports[name=].name", "[0].name", "spec.ports[0"} {
+		_, err = parsePath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const selectorLabelsTempl = `
+%[1]s{{- include "%[2]s.selectorLabels" . | nindent %[3]d }}`
+
+// ProcessSelector - returns label selector yaml with given indent. Empty selector is returned as inline {}.
+// Selector is extended with chart selector labels if selectorLabels is true. Labels provided by
+// chart selector labels are removed from selector in this case.
+func ProcessSelector(appMeta helmify.AppMetadata, sel *metav1.LabelSelector, selectorLabels bool, indent int) (string, error) {
+	if len(sel.MatchLabels) == 0 && len(sel.MatchExpressions) == 0 {
+		return " {}", nil
+	}
+	matchLabels := make(map[string]string, len(sel.MatchLabels))
+	for k, v := range sel.MatchLabels {
+		// provided by chart selector labels
+		if selectorLabels && (k == "app.kubernetes.io/name" || k == "app.kubernetes.io/instance") {
+			continue
+		}
+		matchLabels[k] = v
+	}
+	res := ""
+	if len(matchLabels) != 0 || selectorLabels {
+		res = "\n" + strings.Repeat(" ", indent) + "matchLabels:"
+		if len(matchLabels) != 0 {
+			labels, err := yamlformat.Marshal(matchLabels, indent+2)
+			if err != nil {
+				return "", err
+			}
+			res += "\n" + strings.TrimRight(labels, "\n")
+		}
+		if selectorLabels {
+			res += fmt.Sprintf(selectorLabelsTempl, strings.Repeat(" ", indent+2), appMeta.ChartName(), indent+2)
+		}
+	}
+	if len(sel.MatchExpressions) != 0 {
+		expressions, err := yamlformat.Marshal(map[string]interface{}{"matchExpressions": sel.MatchExpressions}, indent)
+		if err != nil {
+			return "", err
+		}
+		res += "\n" + strings.TrimRight(expressions, "\n")
+	}
+	return res, nil
+}
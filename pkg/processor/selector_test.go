@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessSelector(t *testing.T) {
+	testMeta := metadata.New(config.Config{ChartName: "chart-name"})
+	sel := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "web", "app.kubernetes.io/name": "web"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+		},
+	}
+	res, err := ProcessSelector(testMeta, sel, true, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, `
+  matchLabels:
+    app: web
+    {{- include "chart-name.selectorLabels" . | nindent 4 }}
+  matchExpressions:
+  - key: tier
+    operator: Exists`, res)
+
+	res, err = ProcessSelector(testMeta, sel, false, 2)
+	assert.NoError(t, err)
+	assert.Contains(t, res, "app.kubernetes.io/name: web")
+	assert.NotContains(t, res, "selectorLabels")
+
+	res, err = ProcessSelector(testMeta, &metav1.LabelSelector{}, true, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, " {}", res)
+}
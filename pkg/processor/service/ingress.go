@@ -2,20 +2,54 @@ package service
 
 import (
 	"fmt"
+	"io"
+	"strings"
+
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/processor"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
-	"io"
+	"github.com/iancoleman/strcase"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"text/template"
 )
 
-var ingressTempl, _ = template.New("ingress").Parse(
-	`{{ .Meta }}
-{{ .Spec }}`)
+// ingressTempl - ingress template with hosts, tls, className and annotations taken from values.
+// Backends and tls are rendered with tpl because they may contain templated names of chart objects.
+const ingressTempl = `{{- if .Values.%[1]s.enabled }}
+%[2]s
+  annotations:
+    {{- with .Values.%[1]s.annotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+spec:
+  {{- with .Values.%[1]s.className }}
+  ingressClassName: {{ . | quote }}
+  {{- end }}%[3]s
+  {{- with .Values.%[1]s.tls }}
+  tls:
+    {{- tpl (toYaml .) $ | nindent 4 }}
+  {{- end }}
+  rules:
+  {{- range .Values.%[1]s.hosts }}
+  - host: {{ .host | quote }}
+    {{- with .paths }}
+    http:
+      paths:
+      {{- range . }}
+      - backend:
+          {{- tpl (toYaml .backend) $ | nindent 10 }}
+        {{- with .path }}
+        path: {{ . }}
+        {{- end }}
+        {{- with .pathType }}
+        pathType: {{ . }}
+        {{- end }}
+      {{- end }}
+    {{- end }}
+  {{- end }}
+{{- end }}`
 
 var ingressGVC = schema.GroupVersionKind{
 	Group:   "networking.k8s.io",
@@ -30,7 +64,9 @@ func NewIngress() helmify.Processor {
 
 type ingress struct{}
 
-// Process k8s Service object into template. Returns false if not capable of processing given resource type.
+// Process k8s Ingress object into template. Returns false if not capable of processing given resource type.
+// Hosts, paths, tls, className and annotations are placed into values under the ingress name
+// together with enabled toggle, following the layout of charts created by helm create.
 func (r ingress) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
 	if obj.GroupVersionKind() != ingressGVC {
 		return false, nil, nil
@@ -40,23 +76,36 @@ func (r ingress) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstruct
 	if err != nil {
 		return true, nil, fmt.Errorf("%w: unable to cast to ingress", err)
 	}
+	annotations := obj.GetAnnotations()
+	obj = obj.DeepCopy()
+	obj.SetAnnotations(nil)
 	meta, err := processor.ProcessObjMeta(appMeta, obj)
 	if err != nil {
 		return true, nil, err
 	}
 	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
 	processIngressSpec(appMeta, &ing.Spec)
-	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": &ing.Spec}, 0)
+	values, err := ingressValues(ing.Spec, annotations)
 	if err != nil {
 		return true, nil, err
 	}
+	// remaining spec fields, e.g. defaultBackend, are kept in the template
+	ing.Spec.IngressClassName, ing.Spec.TLS, ing.Spec.Rules = nil, nil, nil
+	spec := ""
+	if ing.Spec.DefaultBackend != nil {
+		spec, err = yamlformat.Marshal(map[string]interface{}{"defaultBackend": ing.Spec.DefaultBackend}, 2)
+		if err != nil {
+			return true, nil, err
+		}
+		spec = "\n" + strings.TrimRight(spec, "\n")
+	}
 
 	return true, &ingressResult{
-		name: name + ".yaml",
-		data: struct {
-			Meta string
-			Spec string
-		}{Meta: meta, Spec: spec},
+		name:   name + ".yaml",
+		data:   fmt.Sprintf(ingressTempl, nameCamel, meta, spec),
+		values: helmify.Values{nameCamel: values},
 	}, nil
 }
 
@@ -73,14 +122,60 @@ func processIngressSpec(appMeta helmify.AppMetadata, ing *networkingv1.IngressSp
 			}
 		}
 	}
+	for i := range ing.TLS {
+		if ing.TLS[i].SecretName != "" {
+			ing.TLS[i].SecretName = appMeta.TemplatedName(ing.TLS[i].SecretName)
+		}
+	}
 }
 
-type ingressResult struct {
-	name string
-	data struct {
-		Meta string
-		Spec string
+// ingressValues - returns ingress values: enabled toggle, className, annotations, hosts with paths and tls.
+func ingressValues(spec networkingv1.IngressSpec, annotations map[string]string) (map[string]interface{}, error) {
+	className := ""
+	if spec.IngressClassName != nil {
+		className = *spec.IngressClassName
+	}
+	annotationValues := make(map[string]interface{}, len(annotations))
+	for k, v := range annotations {
+		annotationValues[k] = v
 	}
+	hosts := make([]interface{}, 0, len(spec.Rules))
+	for _, rule := range spec.Rules {
+		host := map[string]interface{}{"host": rule.Host}
+		if rule.HTTP != nil {
+			paths := make([]interface{}, 0, len(rule.HTTP.Paths))
+			for i := range rule.HTTP.Paths {
+				path, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rule.HTTP.Paths[i])
+				if err != nil {
+					return nil, fmt.Errorf("%w: unable to convert ingress path", err)
+				}
+				paths = append(paths, path)
+			}
+			host["paths"] = paths
+		}
+		hosts = append(hosts, host)
+	}
+	tls := make([]interface{}, 0, len(spec.TLS))
+	for i := range spec.TLS {
+		t, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec.TLS[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to convert ingress tls", err)
+		}
+		tls = append(tls, t)
+	}
+	return map[string]interface{}{
+		"enabled":     true,
+		"className":   className,
+		"annotations": annotationValues,
+		"hosts":       hosts,
+		"tls":         tls,
+	}, nil
+}
+
+type ingressResult struct {
+	name   string
+	data   string
+	values helmify.Values
 }
 
 func (r *ingressResult) Filename() string {
@@ -88,9 +183,10 @@ func (r *ingressResult) Filename() string {
 }
 
 func (r *ingressResult) Values() helmify.Values {
-	return helmify.Values{}
+	return r.values
 }
 
 func (r *ingressResult) Write(writer io.Writer) error {
-	return ingressTempl.Execute(writer, r.data)
+	_, err := writer.Write([]byte(r.data))
+	return err
 }
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -27,14 +30,96 @@ spec:
                 port:
                   number: 8443`
 
+const ingressTLSYaml = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: myapp-ingress
+spec:
+  ingressClassName: nginx
+  tls:
+    - hosts:
+        - example.com
+      secretName: myapp-tls
+    - hosts:
+        - example.org
+      secretName: external-tls
+  rules:
+    - host: example.com
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: myapp-service
+                port:
+                  name: http`
+
+const ingressServiceYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: myapp-service`
+
+const ingressSecretYaml = `apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp-tls`
+
 func Test_ingress_Process(t *testing.T) {
 	var testInstance ingress
 
 	t.Run("processed", func(t *testing.T) {
 		obj := internal.GenerateObj(ingressYaml)
-		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		processed, tt, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, helmify.Values{"myappIngress": map[string]interface{}{
+			"enabled":     true,
+			"className":   "",
+			"annotations": map[string]interface{}{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+			"hosts": []interface{}{map[string]interface{}{
+				"host": "",
+				"paths": []interface{}{map[string]interface{}{
+					"path":     "/testpath",
+					"pathType": "Prefix",
+					"backend": map[string]interface{}{"service": map[string]interface{}{
+						"name": "myapp-service",
+						"port": map[string]interface{}{"number": int64(8443)},
+					}},
+				}},
+			}},
+			"tls": []interface{}{},
+		}}, tt.Values())
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Contains(t, buf.String(), "{{- if .Values.myappIngress.enabled }}")
+		assert.Contains(t, buf.String(), "{{- with .Values.myappIngress.annotations }}")
+		assert.NotContains(t, buf.String(), "rewrite-target")
+	})
+	t.Run("tls", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(ingressTLSYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(ingressServiceYaml))
+		appMeta.Load(internal.GenerateObj(ingressSecretYaml))
+		processed, tt, err := testInstance.Process(appMeta, obj)
 		assert.NoError(t, err)
 		assert.Equal(t, true, processed)
+		values := tt.Values()["ingress"].(map[string]interface{})
+		assert.Equal(t, "nginx", values["className"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"hosts": []interface{}{"example.com"}, "secretName": `{{ include "chart.fullname" . }}-tls`},
+			map[string]interface{}{"hosts": []interface{}{"example.org"}, "secretName": "external-tls"},
+		}, values["tls"])
+		backend := values["hosts"].([]interface{})[0].(map[string]interface{})["paths"].([]interface{})[0].(map[string]interface{})["backend"]
+		assert.Equal(t, map[string]interface{}{"service": map[string]interface{}{
+			"name": `{{ include "chart.fullname" . }}-service`,
+			"port": map[string]interface{}{"name": "http"},
+		}}, backend)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Contains(t, buf.String(), "{{- if .Values.ingress.enabled }}")
+		assert.Contains(t, buf.String(), "{{- tpl (toYaml .) $ | nindent 4 }}")
 	})
 	t.Run("skipped", func(t *testing.T) {
 		obj := internal.TestNs
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/arttor/helmify/pkg/processor/hpa"
 	"github.com/arttor/helmify/pkg/processor/pod"
 
 	"github.com/arttor/helmify/pkg/helmify"
@@ -27,7 +28,13 @@ var statefulsetGVC = schema.GroupVersionKind{
 var statefulsetTempl, _ = template.New("statefulset").Parse(
 	`{{- .Meta }}
 spec:
-{{ .Spec }}`)
+{{- if .Replicas }}
+{{ .Replicas }}
+{{- end }}
+{{ .Spec }}
+{{- if .Autoscaler }}
+{{ .Autoscaler }}
+{{- end }}`)
 
 // New creates processor for k8s StatefulSet resource.
 func New() helmify.Processor {
@@ -75,6 +82,13 @@ func (d statefulset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unst
 		}
 		ssSpecMap["replicas"] = repl
 	}
+	replicas, autoscaler, err := hpa.ProcessWorkload(appMeta, obj, &values)
+	if err != nil {
+		return true, nil, err
+	}
+	if replicas != "" {
+		delete(ssSpecMap, "replicas")
+	}
 
 	for i, claim := range ssSpec.VolumeClaimTemplates {
 		volName := claim.ObjectMeta.Name
@@ -117,6 +131,20 @@ func (d statefulset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unst
 	if err != nil {
 		return true, nil, err
 	}
+	podMeta, _, err := unstructured.NestedMap(ssSpecMap, "template", "metadata")
+	if err != nil {
+		return true, nil, err
+	}
+	podMeta, err = pod.ProcessTemplateMetaDefaults(nameCamel, appMeta, podMeta, values)
+	if err != nil {
+		return true, nil, err
+	}
+	if podMeta != nil {
+		err = unstructured.SetNestedMap(ssSpecMap, podMeta, "template", "metadata")
+		if err != nil {
+			return true, nil, err
+		}
+	}
 	err = unstructured.SetNestedMap(ssSpecMap, podSpecMap, "template", "spec")
 	if err != nil {
 		return true, nil, err
@@ -127,23 +155,30 @@ func (d statefulset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unst
 		return true, nil, err
 	}
 	spec = strings.ReplaceAll(spec, "'", "")
+	spec = pod.ExpandDefaults(spec)
 
 	return true, &result{
 		values: values,
 		data: struct {
-			Meta string
-			Spec string
+			Meta       string
+			Replicas   string
+			Spec       string
+			Autoscaler string
 		}{
-			Meta: meta,
-			Spec: spec,
+			Meta:       meta,
+			Replicas:   replicas,
+			Spec:       spec,
+			Autoscaler: autoscaler,
 		},
 	}, nil
 }
 
 type result struct {
 	data struct {
-		Meta string
-		Spec string
+		Meta       string
+		Replicas   string
+		Spec       string
+		Autoscaler string
 	}
 	values helmify.Values
 }
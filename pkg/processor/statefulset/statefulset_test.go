@@ -0,0 +1,84 @@
+package statefulset
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const strStatefulSet = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: postgres:15
+`
+
+func Test_statefulset_Process(t *testing.T) {
+	var testInstance statefulset
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSet)
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("defaults", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSet)
+		appMeta := metadata.New(config.Config{ChartName: "chart", GenerateDefaults: true})
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "        {{- with .Values.db.podLabels }}\n")
+		assert.Contains(t, buf.String(), "      {{- with .Values.db.podAnnotations }}\n")
+		assert.NotContains(t, buf.String(), "HELMIFY")
+	})
+	t.Run("defaults without pod labels", func(t *testing.T) {
+		obj := internal.GenerateObj(`apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    spec:
+      containers:
+      - name: db
+        image: postgres:15
+`)
+		appMeta := metadata.New(config.Config{ChartName: "chart", GenerateDefaults: true})
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "      labels:\n        {{- with .Values.db.podLabels }}\n")
+		assert.NotContains(t, buf.String(), "HELMIFY")
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/arttor/helmify/pkg/cluster"
 	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor/hook"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -30,14 +31,16 @@ kind: Certificate
 metadata:
   name: {{ include "%[1]s.fullname" . }}-%[2]s
   annotations:
-    "helm.sh/hook": post-install,post-upgrade
-    "helm.sh/hook-weight": "2"
+%[4]s
   labels:
   {{- include "%[1]s.labels" . | nindent 4 }}
 spec:
 %[3]s`
 )
 
+// certHook - certificate is created after cert-manager subchart is installed.
+var certHook = hook.Hook{Type: "post-install,post-upgrade", Weight: "2"}
+
 var certGVC = schema.GroupVersionKind{
 	Group:   "cert-manager.io",
 	Version: "v1",
@@ -101,7 +104,7 @@ func (c cert) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 
 		tmpl = fmt.Sprintf("%s\n%s\n%s", WebhookHeader, tmpl, WebhookFooter)
 	}
-	res := fmt.Sprintf(tmpl, appMeta.ChartName(), name, string(spec))
+	res := fmt.Sprintf(tmpl, appMeta.ChartName(), name, string(spec), certHook.Annotations(4))
 	return true, &certResult{
 		name:   name,
 		data:   []byte(res),
@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor/hook"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -26,14 +27,16 @@ kind: Issuer
 metadata:
   name: {{ include "%[1]s.fullname" . }}-%[2]s
   annotations:
-    "helm.sh/hook": post-install,post-upgrade
-    "helm.sh/hook-weight": "1"
+%[4]s
   labels:
   {{- include "%[1]s.labels" . | nindent 4 }}
 spec:
 %[3]s`
 )
 
+// issuerHook - issuer is created after cert-manager subchart is installed.
+var issuerHook = hook.Hook{Type: "post-install,post-upgrade", Weight: "1"}
+
 var issuerGVC = schema.GroupVersionKind{
 	Group:   "cert-manager.io",
 	Version: "v1",
@@ -70,7 +73,7 @@ func (i issuer) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructu
 
 		tmpl = fmt.Sprintf("%s\n%s\n%s", WebhookHeader, tmpl, WebhookFooter)
 	}
-	res := fmt.Sprintf(tmpl, appMeta.ChartName(), name, string(spec))
+	res := fmt.Sprintf(tmpl, appMeta.ChartName(), name, string(spec), issuerHook.Annotations(4))
 	return true, &issResult{
 		name: name,
 		data: []byte(res),
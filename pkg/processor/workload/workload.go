@@ -0,0 +1,353 @@
+package workload
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/arttor/helmify/pkg/processor/hpa"
+	"github.com/arttor/helmify/pkg/processor/pod"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// RolloutGroup - Argo Rollouts API group.
+	RolloutGroup = "argoproj.io"
+
+	workloadTempl = `%[1]s%[2]s
+spec:%[3]s
+%[4]s%[5]s`
+
+	// valuesTempl - field rendered from values with tpl because it may contain templated names of chart objects.
+	valuesTempl = `
+%[1]s{{- tpl (toYaml .Values.%[2]s) $ | nindent %[3]d }}`
+
+	selectorLabelsTempl = `
+%[1]s{{- include "%[2]s.selectorLabels" . | nindent %[3]d }}`
+)
+
+// rolloutStrategyValues - Rollout strategy fields placed into values.
+var rolloutStrategyValues = map[string][]string{
+	"canary":    {"steps", "analysis"},
+	"blueGreen": {"prePromotionAnalysis", "postPromotionAnalysis"},
+}
+
+// rolloutServices - Rollout strategy fields referencing chart services.
+var rolloutServices = map[string][]string{
+	"canary":    {"canaryService", "stableService"},
+	"blueGreen": {"activeService", "previewService"},
+}
+
+// New creates processor for workloads of any kind with pod template, e.g. Argo Rollout or OpenKruise CloneSet.
+func New() helmify.Processor {
+	return &workload{}
+}
+
+type workload struct{}
+
+// Process object with pod template into template. Returns false if object has no pod template.
+// Pod template is found at spec.template or at path configured for the workload kind. Pod spec, selector and
+// replicas are processed the same way as for Deployment. Canary steps and analysis of Argo Rollout are placed
+// into values.
+func (w workload) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	gvk := obj.GroupVersionKind()
+	path := appMeta.Config().TemplatePath(gvk.Group, gvk.Kind)
+	containers, _, _ := unstructured.NestedSlice(obj.Object, append(path, "spec", "containers")...)
+	if len(containers) == 0 {
+		return false, nil, nil
+	}
+	templateMap, _, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s pod template", err, gvk.Kind)
+	}
+	podTemplate := corev1.PodTemplateSpec{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(templateMap, &podTemplate)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to cast %s pod template", err, gvk.Kind)
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	rest, err := processRest(obj)
+	if err != nil {
+		return true, nil, err
+	}
+	specMap, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s spec", err, gvk.Kind)
+	}
+
+	values := helmify.Values{}
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+	s := &spec{fields: specMap}
+
+	if repl, found, _ := unstructured.NestedInt64(specMap, "replicas"); found {
+		specMap["replicas"], err = values.Add(repl, nameCamel, "replicas")
+		if err != nil {
+			return true, nil, err
+		}
+	}
+	replicas, autoscaler, err := hpa.ProcessWorkload(appMeta, obj, &values)
+	if err != nil {
+		return true, nil, err
+	}
+	if replicas != "" {
+		delete(specMap, "replicas")
+		replicas = "\n" + replicas
+	}
+
+	// path of pod template relative to spec
+	templatePath := path[1:]
+	err = processSelector(appMeta, s, append(templatePath[:len(templatePath)-1:len(templatePath)-1], "selector"))
+	if err != nil {
+		return true, nil, err
+	}
+	err = processPodTemplate(appMeta, s, templatePath, nameCamel, podTemplate, &values)
+	if err != nil {
+		return true, nil, err
+	}
+	if gvk.Group == RolloutGroup && gvk.Kind == "Rollout" {
+		err = processRolloutStrategy(appMeta, s, nameCamel, &values)
+		if err != nil {
+			return true, nil, err
+		}
+	}
+
+	res, err := s.marshal()
+	if err != nil {
+		return true, nil, err
+	}
+	if autoscaler != "" {
+		autoscaler = "\n" + autoscaler
+	}
+	return true, &result{
+		name:   name,
+		data:   fmt.Sprintf(workloadTempl, meta, rest, replicas, res, autoscaler),
+		values: values,
+	}, nil
+}
+
+// processRest - returns top level object fields other than metadata and spec.
+func processRest(obj *unstructured.Unstructured) (string, error) {
+	rest := map[string]interface{}{}
+	for k, v := range obj.Object {
+		switch k {
+		case "apiVersion", "kind", "metadata", "spec", "status":
+			continue
+		}
+		rest[k] = v
+	}
+	if len(rest) == 0 {
+		return "", nil
+	}
+	res, err := yamlformat.Marshal(rest, 0)
+	if err != nil {
+		return "", err
+	}
+	return "\n" + res, nil
+}
+
+// processSelector - replaces pod selector next to pod template with selector extended with chart selector labels.
+func processSelector(appMeta helmify.AppMetadata, s *spec, path []string) error {
+	selectorMap, found, err := unstructured.NestedMap(s.fields, path...)
+	if err != nil || !found {
+		// selector is optional for some workloads, e.g. when pods are selected by owner reference
+		return nil
+	}
+	selector := metav1.LabelSelector{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, &selector)
+	if err != nil {
+		return fmt.Errorf("%w: unable to cast selector", err)
+	}
+	res, err := processor.ProcessSelector(appMeta, &selector, true, indent(path)+2)
+	if err != nil {
+		return err
+	}
+	return s.set(res, path...)
+}
+
+// processPodTemplate - replaces pod template with processed pod spec and labels extended with chart selector labels.
+func processPodTemplate(appMeta helmify.AppMetadata, s *spec, path []string, nameCamel string, podTemplate corev1.PodTemplateSpec, values *helmify.Values) error {
+	podMeta := map[string]interface{}{}
+	podLabels := map[string]interface{}{}
+	for k, v := range podTemplate.Labels {
+		// provided by chart selector labels
+		if k == "app.kubernetes.io/name" || k == "app.kubernetes.io/instance" {
+			continue
+		}
+		podLabels[k] = v
+	}
+	if len(podLabels) != 0 {
+		podMeta["labels"] = podLabels
+	}
+	if len(podTemplate.Annotations) != 0 {
+		annotations := map[string]interface{}{}
+		for k, v := range podTemplate.Annotations {
+			annotations[k] = v
+		}
+		podMeta["annotations"] = annotations
+	}
+	podMeta, err := pod.ProcessTemplateMetaDefaults(nameCamel, appMeta, podMeta, *values)
+	if err != nil {
+		return err
+	}
+	labelsIndent := indent(path) + 6
+	labels := ""
+	if podMeta["labels"] != nil {
+		labels, err = yamlformat.Marshal(podMeta["labels"], labelsIndent)
+		if err != nil {
+			return err
+		}
+		labels = "\n" + labels
+	}
+	labels += fmt.Sprintf(selectorLabelsTempl, strings.Repeat(" ", labelsIndent-2), appMeta.ChartName(), labelsIndent)
+
+	podSpec, podValues, err := pod.ProcessSpec(nameCamel, appMeta, podTemplate.Spec, indent(path)-2)
+	if err != nil {
+		return err
+	}
+	err = values.Merge(podValues)
+	if err != nil {
+		return err
+	}
+	// pod spec may contain values unsupported by unstructured deep copy, so it is set without copying
+	parent, _, err := unstructured.NestedFieldNoCopy(s.fields, path[:len(path)-1]...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to set pod template", err)
+	}
+	parentMap, ok := parent.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unable to set pod template: %s is not an object", strings.Join(path[:len(path)-1], "."))
+	}
+	parentMap[path[len(path)-1]] = map[string]interface{}{"metadata": podMeta, "spec": podSpec}
+	return s.set(labels, append(path, "metadata", "labels")...)
+}
+
+// processRolloutStrategy - places canary steps and analysis of Argo Rollout into values and templates names
+// of referenced services and analysis templates.
+func processRolloutStrategy(appMeta helmify.AppMetadata, s *spec, nameCamel string, values *helmify.Values) error {
+	for strategy, keys := range rolloutServices {
+		for _, key := range keys {
+			svc, found, _ := unstructured.NestedString(s.fields, "strategy", strategy, key)
+			if !found || svc == "" {
+				continue
+			}
+			err := unstructured.SetNestedField(s.fields, appMeta.TemplatedName(svc), "strategy", strategy, key)
+			if err != nil {
+				return fmt.Errorf("%w: unable to set %s", err, key)
+			}
+		}
+	}
+	for strategy, keys := range rolloutStrategyValues {
+		for _, key := range keys {
+			path := []string{"strategy", strategy, key}
+			val, found, _ := unstructured.NestedFieldCopy(s.fields, path...)
+			if !found {
+				continue
+			}
+			templateAnalysisNames(appMeta, val)
+			_, err := values.AddYaml(val, 0, false, nameCamel, "strategy", strategy, key)
+			if err != nil {
+				return err
+			}
+			res := fmt.Sprintf(valuesTempl, strings.Repeat(" ", indent(path)+2), nameCamel+".strategy."+strategy+"."+key, indent(path)+2)
+			err = s.set(res, path...)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// templateAnalysisNames - replaces names of namespaced AnalysisTemplates referenced by Rollout analysis
+// or canary steps with templated names.
+func templateAnalysisNames(appMeta helmify.AppMetadata, val interface{}) {
+	switch v := val.(type) {
+	case []interface{}:
+		for _, step := range v {
+			if step, ok := step.(map[string]interface{}); ok {
+				templateAnalysisNames(appMeta, step["analysis"])
+			}
+		}
+	case map[string]interface{}:
+		templates, _ := v["templates"].([]interface{})
+		for _, t := range templates {
+			t, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := t["templateName"].(string)
+			clusterScope, _ := t["clusterScope"].(bool)
+			if name != "" && !clusterScope {
+				t["templateName"] = appMeta.TemplatedName(name)
+			}
+		}
+	}
+}
+
+// indent - returns indent of spec field with given path.
+func indent(path []string) int {
+	return 2 * len(path)
+}
+
+// spec - workload spec fields with placeholders for fields rendered as yaml blocks.
+type spec struct {
+	fields map[string]interface{}
+	blocks []string
+}
+
+// set - replaces spec field with placeholder substituted with given block after marshalling.
+// Block is a field value starting with a new line or a space.
+func (s *spec) set(block string, path ...string) error {
+	err := unstructured.SetNestedField(s.fields, placeholder(len(s.blocks)), path...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to set %s", err, strings.Join(path, "."))
+	}
+	s.blocks = append(s.blocks, block)
+	return nil
+}
+
+func (s *spec) marshal() (string, error) {
+	res, err := yamlformat.Marshal(s.fields, 2)
+	if err != nil {
+		return "", err
+	}
+	for i, block := range s.blocks {
+		res = strings.Replace(res, " "+placeholder(i), block, 1)
+	}
+	res = pod.ReplaceSingleQuotes(res)
+	return pod.ExpandDefaults(res), nil
+}
+
+func placeholder(i int) string {
+	return fmt.Sprintf("helmify%dplaceholder", i)
+}
+
+type result struct {
+	name   string
+	data   string
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write([]byte(r.data))
+	return err
+}
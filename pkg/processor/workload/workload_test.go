@@ -0,0 +1,160 @@
+package workload
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rolloutYaml = `apiVersion: argoproj.io/v1alpha1
+kind: Rollout
+metadata:
+  name: my-app-web
+  namespace: my-app
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+  strategy:
+    canary:
+      canaryService: my-app-canary
+      stableService: my-app-stable
+      steps:
+      - setWeight: 20
+      - analysis:
+          templates:
+          - templateName: my-app-success-rate
+      - pause: {}`
+	cloneSetYaml = `apiVersion: apps.kruise.io/v1alpha1
+kind: CloneSet
+metadata:
+  name: my-app-worker
+  namespace: my-app
+spec:
+  workload:
+    template:
+      metadata:
+        labels:
+          app: worker
+      spec:
+        containers:
+        - name: worker
+          image: busybox:1.36`
+	configMapYaml = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+  namespace: my-app`
+)
+
+func Test_workload_Process(t *testing.T) {
+	t.Run("rollout", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		obj := internal.GenerateObj(rolloutYaml)
+		appMeta.Load(obj)
+		for _, name := range []string{"my-app-canary", "my-app-stable", "my-app-success-rate"} {
+			ref := internal.GenerateObj(configMapYaml)
+			ref.SetName(name)
+			appMeta.Load(ref)
+		}
+		processed, tt, err := New().Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		assert.Equal(t, "web.yaml", tt.Filename())
+		values := tt.Values()["web"].(map[string]interface{})
+		assert.Equal(t, int64(3), values["replicas"])
+		assert.Equal(t, map[string]interface{}{"repository": "nginx", "tag": "1.25"}, values["web"].(map[string]interface{})["image"])
+		assert.Equal(t, map[string]interface{}{"canary": map[string]interface{}{"steps": []interface{}{
+			map[string]interface{}{"setWeight": int64(20)},
+			map[string]interface{}{"analysis": map[string]interface{}{"templates": []interface{}{
+				map[string]interface{}{"templateName": `{{ include "chart.fullname" . }}-success-rate`},
+			}}},
+			map[string]interface{}{"pause": map[string]interface{}{}},
+		}}}, values["strategy"])
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Equal(t, `apiVersion: argoproj.io/v1alpha1
+kind: Rollout
+metadata:
+  name: {{ include "chart.fullname" . }}-web
+  labels:
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.web.replicas }}
+  selector:
+    matchLabels:
+      app: web
+      {{- include "chart.selectorLabels" . | nindent 6 }}
+  strategy:
+    canary:
+      canaryService: {{ include "chart.fullname" . }}-canary
+      stableService: {{ include "chart.fullname" . }}-stable
+      steps:
+        {{- tpl (toYaml .Values.web.strategy.canary.steps) $ | nindent 8 }}
+  template:
+    metadata:
+      labels:
+        app: web
+      {{- include "chart.selectorLabels" . | nindent 8 }}
+    spec:
+      containers:
+      - env:
+        - name: KUBERNETES_CLUSTER_DOMAIN
+          value: {{ quote .Values.kubernetesClusterDomain }}
+        image: {{ .Values.web.web.image.repository }}:{{ .Values.web.web.image.tag
+          | default .Chart.AppVersion }}
+        name: web
+        resources: {}
+      nodeSelector: {{- toYaml .Values.web.nodeSelector | nindent 8 }}
+      serviceAccountName: {{ include "chart.serviceAccountName" . }}
+      tolerations: {{- toYaml .Values.web.tolerations | nindent 8 }}
+      topologySpreadConstraints: {{- toYaml .Values.web.topologySpreadConstraints |
+        nindent 8 }}`, buf.String())
+	})
+	t.Run("custom template path", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart", Workloads: []config.Workload{
+			{Kind: "CloneSet", Group: "apps.kruise.io", TemplatePath: "spec.workload.template"},
+		}})
+		obj := internal.GenerateObj(cloneSetYaml)
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(configMapYaml))
+		processed, tt, err := New().Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tt.Write(&buf))
+		assert.Contains(t, buf.String(), `
+  workload:
+    template:
+      metadata:
+        labels:
+          app: worker
+        {{- include "chart.selectorLabels" . | nindent 10 }}
+      spec:
+        containers:`)
+		assert.Contains(t, buf.String(), "image: {{ .Values.worker.worker.image.repository }}")
+	})
+	t.Run("skipped", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		processed, _, err := New().Process(appMeta, internal.GenerateObj(configMapYaml))
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+		processed, _, err = New().Process(appMeta, internal.GenerateObj(cloneSetYaml))
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+}
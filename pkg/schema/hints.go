@@ -0,0 +1,176 @@
+package schema
+
+import "strings"
+
+// hint - known schema of a values field produced by processors.
+type hint struct {
+	// pattern - values path. '*' matches any key, '[]' matches array items.
+	pattern string
+	// sibling - optional key which must be present next to the matched field.
+	sibling string
+	schema  func() *Schema
+}
+
+// hints describe values shapes known by processors (see pkg/processor).
+// Hint is applied only if the value type matches hint type, otherwise the type is inferred from the value.
+var hints = []hint{
+	// pod.ProcessSpec
+	{pattern: "*.*.resources", schema: resources},
+	{pattern: "*.*.image", schema: image},
+	{pattern: "*.*.imagePullPolicy", schema: enum("Always", "IfNotPresent", "Never")},
+	{pattern: "*.*.env.*", schema: scalar},
+	{pattern: "*.nodeSelector", schema: stringMap},
+	{pattern: "*.tolerations", schema: tolerations},
+	{pattern: "*.topologySpreadConstraints", schema: objectArray},
+	{pattern: "*.affinity", schema: object},
+	{pattern: "*.podSecurityContext", schema: object},
+	{pattern: "imagePullSecrets", schema: imagePullSecrets},
+	// deployment, statefulset
+	{pattern: "*.replicas", schema: replicas},
+	{pattern: "*.revisionHistoryLimit", schema: replicas},
+	{pattern: "*.strategy.type", schema: enum("Recreate", "RollingUpdate")},
+	{pattern: "*.strategy.rollingUpdate.*", schema: intOrString},
+	{pattern: "*.volumeClaims.*", schema: resources},
+	// service
+	{pattern: "*.type", sibling: "ports", schema: enum("ClusterIP", "NodePort", "LoadBalancer", "ExternalName")},
+	{pattern: "*.ports", sibling: "type", schema: servicePorts},
+	{pattern: "*.ipFamilyPolicy", schema: enum("SingleStack", "PreferDualStack", "RequireDualStack")},
+	{pattern: "*.ipFamilies", schema: ipFamilies},
+	{pattern: "*.loadBalancerSourceRanges", schema: stringArray},
+}
+
+func lookupHint(path []string, parent map[string]interface{}) *Schema {
+	for _, h := range hints {
+		if !h.match(path, parent) {
+			continue
+		}
+		s := h.schema()
+		if !sameType(s, parent[path[len(path)-1]]) {
+			continue
+		}
+		return s
+	}
+	return nil
+}
+
+func (h hint) match(path []string, parent map[string]interface{}) bool {
+	if parent == nil {
+		return false
+	}
+	pattern := strings.Split(h.pattern, ".")
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != path[i] {
+			return false
+		}
+	}
+	if h.sibling != "" {
+		_, ok := parent[h.sibling]
+		return ok
+	}
+	return true
+}
+
+// sameType - checks if value can be described by schema top level type.
+func sameType(s *Schema, value interface{}) bool {
+	types, ok := s.Type.([]string)
+	if !ok {
+		types = []string{s.Type.(string)}
+	}
+	valType := generator{}.inferType(nil, value, nil).Type
+	for _, t := range types {
+		if t == valType || (t == "number" && valType == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func enum(values ...interface{}) func() *Schema {
+	return func() *Schema {
+		return &Schema{Type: "string", Enum: values}
+	}
+}
+
+func scalar() *Schema {
+	return &Schema{Type: []string{"string", "number", "boolean"}}
+}
+
+func intOrString() *Schema {
+	return &Schema{Type: []string{"integer", "string"}}
+}
+
+func object() *Schema {
+	return &Schema{Type: "object"}
+}
+
+func objectArray() *Schema {
+	return &Schema{Type: "array", Items: object()}
+}
+
+func stringMap() *Schema {
+	return &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}
+}
+
+func stringArray() *Schema {
+	return &Schema{Type: "array", Items: &Schema{Type: "string"}}
+}
+
+func replicas() *Schema {
+	minimum := int64(0)
+	return &Schema{Type: "integer", Minimum: &minimum}
+}
+
+func quantityMap() *Schema {
+	return &Schema{Type: "object", AdditionalProperties: &Schema{Type: []string{"string", "number"}}}
+}
+
+func resources() *Schema {
+	return &Schema{Type: "object", Properties: map[string]*Schema{
+		"limits":   quantityMap(),
+		"requests": quantityMap(),
+	}}
+}
+
+func image() *Schema {
+	return &Schema{Type: "object", Properties: map[string]*Schema{
+		"repository": {Type: "string"},
+		"tag":        {Type: "string"},
+	}}
+}
+
+func tolerations() *Schema {
+	return &Schema{Type: "array", Items: &Schema{Type: "object", Properties: map[string]*Schema{
+		"key":               {Type: "string"},
+		"operator":          enum("Exists", "Equal")(),
+		"value":             {Type: "string"},
+		"effect":            enum("NoSchedule", "PreferNoSchedule", "NoExecute")(),
+		"tolerationSeconds": {Type: "integer"},
+	}}}
+}
+
+func servicePorts() *Schema {
+	return &Schema{Type: "array", Items: &Schema{
+		Type:     "object",
+		Required: []string{"port"},
+		Properties: map[string]*Schema{
+			"name":       {Type: "string"},
+			"port":       {Type: "integer"},
+			"targetPort": intOrString(),
+			"nodePort":   {Type: "integer"},
+			"protocol":   enum("TCP", "UDP", "SCTP")(),
+		},
+	}}
+}
+
+func ipFamilies() *Schema {
+	return &Schema{Type: "array", Items: enum("IPv4", "IPv6")()}
+}
+
+func imagePullSecrets() *Schema {
+	return &Schema{Type: "array", Items: &Schema{Type: "object", Properties: map[string]*Schema{
+		"name": {Type: "string"},
+	}}}
+}
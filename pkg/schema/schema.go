@@ -0,0 +1,238 @@
+// Package schema generates JSON schema for Helm chart values.yaml.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+)
+
+const draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema - JSON schema node.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *int64             `json:"minimum,omitempty"`
+	MinLength            *int64             `json:"minLength,omitempty"`
+	// deny - schema is boolean false schema matching no values.
+	deny bool
+}
+
+// False - returns schema matching no values. Used as additionalProperties to disallow unknown object keys.
+func False() *Schema {
+	return &Schema{deny: true}
+}
+
+// IsFalse - returns true if schema matches no values.
+func (s *Schema) IsFalse() bool {
+	return s != nil && s.deny
+}
+
+type schemaJSON Schema
+
+// MarshalJSON - marshals false schema as boolean.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.deny {
+		return []byte("false"), nil
+	}
+	return json.Marshal((*schemaJSON)(s))
+}
+
+// UnmarshalJSON - unmarshals schema object or boolean false schema.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	if string(data) == "false" {
+		*s = Schema{deny: true}
+		return nil
+	}
+	return json.Unmarshal(data, (*schemaJSON)(s))
+}
+
+// requiredRe matches values referenced with helm 'required' function: {{ required "msg" .Values.a.b }}.
+var requiredRe = regexp.MustCompile(`required\s+"[^"]*"\s+\.Values\.([\w.]+)`)
+
+// RequiredValues - returns values paths referenced in templates with helm 'required' function.
+func RequiredValues(templates ...string) [][]string {
+	var res [][]string
+	seen := map[string]struct{}{}
+	for _, tpl := range templates {
+		for _, match := range requiredRe.FindAllStringSubmatch(tpl, -1) {
+			if _, ok := seen[match[1]]; ok {
+				continue
+			}
+			seen[match[1]] = struct{}{}
+			res = append(res, strings.Split(match[1], "."))
+		}
+	}
+	return res
+}
+
+// valuesRe matches values referenced in templates: .Values.a.b or $.Values.a.b.
+var valuesRe = regexp.MustCompile(`\.Values((?:\.\w+)+)`)
+
+// ReferencedValues - returns values paths referenced in templates.
+func ReferencedValues(templates ...string) [][]string {
+	var res [][]string
+	seen := map[string]struct{}{}
+	for _, tpl := range templates {
+		for _, match := range valuesRe.FindAllStringSubmatch(tpl, -1) {
+			path := strings.TrimPrefix(match[1], ".")
+			if _, ok := seen[path]; ok {
+				continue
+			}
+			seen[path] = struct{}{}
+			res = append(res, strings.Split(path, "."))
+		}
+	}
+	return res
+}
+
+// Generate - generates JSON schema for given values.
+// Types are inferred from the values and refined with known k8s shapes (see hints).
+// Given required paths are added to 'required' list of their parent objects, required strings can't be empty.
+// Objects accessed in templates only by their keys don't allow unknown keys to catch misspelled values.
+// Objects referenced in templates as a whole, e.g. rendered with toYaml, and values referenced in templates
+// but missing in values, e.g. nameOverride, are allowed to have any content.
+func Generate(values helmify.Values, required, referenced [][]string) ([]byte, error) {
+	g := generator{referenced: map[string]bool{}}
+	for _, path := range referenced {
+		g.referenced[strings.Join(path, ".")] = true
+	}
+	root := g.infer(nil, map[string]interface{}(values), nil)
+	root.Schema = draft
+	if root.Properties == nil {
+		root.Properties = map[string]*Schema{}
+	}
+	// global values are passed to every chart by helm.
+	if _, ok := root.Properties["global"]; !ok {
+		root.Properties["global"] = &Schema{Type: "object"}
+	}
+	for _, path := range referenced {
+		allowReferenced(root, path)
+	}
+	for _, path := range required {
+		markRequired(root, path)
+	}
+	res, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to marshal values schema", err)
+	}
+	return append(res, '\n'), nil
+}
+
+// generator - infers values schema.
+type generator struct {
+	// referenced - values paths referenced in templates.
+	referenced map[string]bool
+}
+
+// owned - returns true if object at path is accessed in templates only by its keys. Unknown keys of such
+// object are never used by templates.
+func (g generator) owned(path []string) bool {
+	for i := 1; i <= len(path); i++ {
+		if g.referenced[strings.Join(path[:i], ".")] {
+			return false
+		}
+	}
+	return true
+}
+
+func (g generator) infer(path []string, value interface{}, parent map[string]interface{}) *Schema {
+	res := g.inferType(path, value, parent)
+	// empty maps have no known structure and are usually rendered with toYaml.
+	if res.Properties != nil && res.AdditionalProperties == nil && g.owned(path) {
+		res.AdditionalProperties = False()
+	}
+	return res
+}
+
+func (g generator) inferType(path []string, value interface{}, parent map[string]interface{}) *Schema {
+	if hint := lookupHint(path, parent); hint != nil {
+		return hint
+	}
+	switch val := value.(type) {
+	case map[string]interface{}:
+		res := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for k, v := range val {
+			res.Properties[k] = g.infer(append(path[:len(path):len(path)], k), v, val)
+		}
+		if len(res.Properties) == 0 {
+			res.Properties = nil
+		}
+		return res
+	case helmify.Values:
+		return g.inferType(path, map[string]interface{}(val), parent)
+	case map[string]string:
+		return &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}
+	case []interface{}:
+		res := &Schema{Type: "array"}
+		if len(val) != 0 {
+			res.Items = g.infer(append(path[:len(path):len(path)], "[]"), val[0], nil)
+		}
+		return res
+	case []string:
+		return &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	case string:
+		return &Schema{Type: "string"}
+	case bool:
+		return &Schema{Type: "boolean"}
+	case int, int8, int16, int32, int64:
+		return &Schema{Type: "integer"}
+	case float32, float64:
+		return &Schema{Type: "number"}
+	case nil:
+		return &Schema{}
+	}
+	return &Schema{}
+}
+
+// allowReferenced - adds value referenced in templates to the object not allowing unknown keys if it is missing.
+func allowReferenced(s *Schema, path []string) {
+	for _, key := range path {
+		if !s.AdditionalProperties.IsFalse() {
+			return
+		}
+		next, ok := s.Properties[key]
+		if !ok {
+			s.Properties[key] = &Schema{}
+			return
+		}
+		s = next
+	}
+}
+
+func markRequired(s *Schema, path []string) {
+	for _, key := range path {
+		if s == nil || s.Properties == nil {
+			return
+		}
+		if !contains(s.Required, key) {
+			s.Required = append(s.Required, key)
+			sort.Strings(s.Required)
+		}
+		s = s.Properties[key]
+	}
+	// generated defaults of required values are empty strings satisfying 'required' keyword
+	if s != nil && s.Type == "string" {
+		minLength := int64(1)
+		s.MinLength = &minLength
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
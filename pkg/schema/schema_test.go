@@ -0,0 +1,167 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+func TestRequiredValues(t *testing.T) {
+	tpl := `data:
+  ca.crt: {{ required "mySecretCa.caCrt is required" .Values.mySecretCa.caCrt | b64enc | quote }}
+  var1: {{ required "mySecretVars.var1 is required" .Values.mySecretVars.var1 | quote }}`
+	res := RequiredValues(tpl, tpl)
+	assert.Equal(t, [][]string{{"mySecretCa", "caCrt"}, {"mySecretVars", "var1"}}, res)
+}
+
+func TestGenerate(t *testing.T) {
+	values := helmify.Values{
+		"kubernetesClusterDomain": "cluster.local",
+		"imagePullSecrets":        []string{},
+		"mySecret": map[string]interface{}{
+			"password": "",
+		},
+		"myapp": map[string]interface{}{
+			"replicas":     int64(3),
+			"nodeSelector": map[string]interface{}{},
+			"tolerations":  []interface{}{},
+			"strategy": map[string]interface{}{
+				"type": "Recreate",
+			},
+			"app": map[string]interface{}{
+				"image": map[string]interface{}{
+					"repository": "nginx",
+					"tag":        "latest",
+				},
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{"cpu": "100m"},
+				},
+			},
+		},
+		"myService": map[string]interface{}{
+			"type":  "ClusterIP",
+			"ports": []interface{}{map[string]interface{}{"port": int64(80)}},
+		},
+		"myConfig": map[string]interface{}{
+			"type":      "not-a-service-type",
+			"resources": "not-an-object",
+		},
+	}
+	res, err := Generate(values, [][]string{{"mySecret", "password"}}, nil)
+	require.NoError(t, err)
+
+	s := Schema{}
+	require.NoError(t, json.Unmarshal(res, &s))
+	assert.Equal(t, draft, s.Schema)
+	assert.Equal(t, []string{"mySecret"}, s.Required)
+	assert.Equal(t, []string{"password"}, s.Properties["mySecret"].Required)
+	assert.Equal(t, "string", s.Properties["mySecret"].Properties["password"].Type)
+	assert.Equal(t, int64(1), *s.Properties["mySecret"].Properties["password"].MinLength)
+
+	myapp := s.Properties["myapp"]
+	assert.Equal(t, "integer", myapp.Properties["replicas"].Type)
+	assert.Equal(t, []interface{}{"Recreate", "RollingUpdate"}, myapp.Properties["strategy"].Properties["type"].Enum)
+	assert.Equal(t, "string", myapp.Properties["nodeSelector"].AdditionalProperties.Type)
+	assert.NotNil(t, myapp.Properties["tolerations"].Items.Properties["effect"].Enum)
+	assert.NotNil(t, myapp.Properties["app"].Properties["resources"].Properties["requests"])
+
+	svc := s.Properties["myService"]
+	assert.Equal(t, []interface{}{"ClusterIP", "NodePort", "LoadBalancer", "ExternalName"}, svc.Properties["type"].Enum)
+	assert.Equal(t, []string{"port"}, svc.Properties["ports"].Items.Required)
+
+	t.Run("hints are not applied to mismatching fields", func(t *testing.T) {
+		conf := s.Properties["myConfig"]
+		assert.Nil(t, conf.Properties["type"].Enum)
+		assert.Equal(t, "string", conf.Properties["resources"].Type)
+	})
+}
+
+func TestReferencedValues(t *testing.T) {
+	tpl := `replicas: {{ .Values.myapp.replicas }}
+{{- with .Values.myapp.podLabels }}
+image: {{ $.Values.myapp.app.image.repository }}:{{ .Values.myapp.app.image.tag }}
+{{- end }}
+resources: {{- toYaml .Values.myapp.app.resources | nindent 2 }}
+name: {{ .Values.nameOverride }}`
+	assert.Equal(t, [][]string{
+		{"myapp", "replicas"},
+		{"myapp", "podLabels"},
+		{"myapp", "app", "image", "repository"},
+		{"myapp", "app", "image", "tag"},
+		{"myapp", "app", "resources"},
+		{"nameOverride"},
+	}, ReferencedValues(tpl, tpl))
+}
+
+func TestGenerate_AdditionalProperties(t *testing.T) {
+	values := helmify.Values{
+		"myapp": map[string]interface{}{
+			"replicas":     int64(3),
+			"podLabels":    map[string]interface{}{"team": "web"},
+			"nodeSelector": map[string]interface{}{},
+			"app": map[string]interface{}{
+				"image": map[string]interface{}{"repository": "nginx", "tag": "latest"},
+				"resources": map[string]interface{}{
+					"limits": map[string]interface{}{"cpu": "100m"},
+				},
+				"env": map[string]interface{}{"FOO": "bar"},
+			},
+		},
+		"mySecret": map[string]interface{}{"password": ""},
+	}
+	referenced := ReferencedValues(`replicas: {{ .Values.myapp.replicas }}
+labels: {{- toYaml .Values.myapp.podLabels | nindent 2 }}
+nodeSelector: {{- toYaml .Values.myapp.nodeSelector | nindent 2 }}
+image: {{ .Values.myapp.app.image.repository }}:{{ .Values.myapp.app.image.tag }}
+resources: {{- toYaml .Values.myapp.app.resources | nindent 2 }}
+env: {{ .Values.myapp.app.env.FOO }}
+password: {{ required "mySecret.password is required" .Values.mySecret.password }}
+name: {{ .Values.nameOverride }}`)
+	res, err := Generate(values, [][]string{{"mySecret", "password"}}, referenced)
+	require.NoError(t, err)
+	s := Schema{}
+	require.NoError(t, json.Unmarshal(res, &s))
+	assert.True(t, s.Properties["myapp"].AdditionalProperties.IsFalse())
+	assert.False(t, s.Properties["myapp"].Properties["podLabels"].AdditionalProperties.IsFalse())
+
+	validate := func(values string) error {
+		vals, err := chartutil.ReadValues([]byte(values))
+		require.NoError(t, err)
+		return chartutil.ValidateAgainstSingleSchema(vals, res)
+	}
+	assert.NoError(t, validate(`
+global: {}
+nameOverride: web
+mySecret:
+  password: secret
+myapp:
+  replicas: 1
+  podLabels:
+    team: api
+    tier: backend
+  nodeSelector:
+    disk: ssd
+  app:
+    image:
+      tag: "1.25"
+    resources:
+      claims:
+      - name: gpu
+      limits:
+        memory: 128Mi
+    env:
+      FOO: baz`))
+	for name, values := range map[string]string{
+		"misspelled key":        "myapp:\n  replica: 1",
+		"misspelled nested key": "myapp:\n  app:\n    image:\n      tga: \"1.25\"",
+		"misspelled root key":   "myap:\n  replicas: 1",
+		"unused env key":        "myapp:\n  app:\n    env:\n      BAR: baz",
+		"empty required value":  "mySecret:\n  password: \"\"",
+	} {
+		assert.Error(t, validate(values), name)
+	}
+}
@@ -0,0 +1,110 @@
+package script
+
+import (
+	"fmt"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// toStarlark - converts unstructured value into Starlark value.
+func toStarlark(v interface{}) (starlark.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(val), nil
+	case string:
+		return starlark.String(val), nil
+	case int64:
+		return starlark.MakeInt64(val), nil
+	case int:
+		return starlark.MakeInt(val), nil
+	case float64:
+		return starlark.Float(val), nil
+	case []interface{}:
+		items := make([]starlark.Value, 0, len(val))
+		for _, item := range val {
+			converted, err := toStarlark(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, converted)
+		}
+		return starlark.NewList(items), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		res := starlark.NewDict(len(val))
+		for _, k := range keys {
+			converted, err := toStarlark(val[k])
+			if err != nil {
+				return nil, err
+			}
+			if err = res.SetKey(starlark.String(k), converted); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// fromStarlark - converts Starlark value into unstructured value.
+func fromStarlark(v starlark.Value) (interface{}, error) {
+	switch val := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(val), nil
+	case starlark.String:
+		return string(val), nil
+	case starlark.Int:
+		i, ok := val.Int64()
+		if !ok {
+			return nil, fmt.Errorf("int %s is too large", val)
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(val), nil
+	case *starlark.List:
+		return fromIterable(val, val.Len())
+	case starlark.Tuple:
+		return fromIterable(val, val.Len())
+	case *starlark.Dict:
+		res := make(map[string]interface{}, val.Len())
+		for _, item := range val.Items() {
+			k, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("dict key %s is not a string", item[0])
+			}
+			converted, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			res[k] = converted
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %s", v.Type())
+	}
+}
+
+func fromIterable(v starlark.Iterable, size int) ([]interface{}, error) {
+	res := make([]interface{}, 0, size)
+	iter := v.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		converted, err := fromStarlark(item)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, converted)
+	}
+	return res, nil
+}
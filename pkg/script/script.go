@@ -0,0 +1,332 @@
+// Package script runs Starlark scripts transforming k8s objects before processing and converting objects of
+// claimed kinds into Helm templates. Scripts register handlers with predeclared functions:
+//
+//	transform(fn, kind="", group="", version="") - fn(obj) mutates object dict in place or returns a new one.
+//	  Return False to drop the object.
+//	processor(kind, fn, group="", version="") - fn(obj, meta) returns template string, dict with "template",
+//	  "filename" and "skip" keys or None to pass the object to other processors.
+//	to_yaml(value, indent=0) - returns value marshalled to yaml with given indent.
+//
+// meta provides chart_name, namespace, name (trimmed object name), values_key, templated_name(name),
+// templated_string(str), trim_name(name), object_meta() and values with add(value, *name),
+// add_yaml(value, indent, new_line, *name) and add_secret(to_base64, *name) methods.
+package script
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// FileName - name of the script discovered next to the chart directory.
+const FileName = "helmify.star"
+
+// fileOptions - Starlark dialect of scripts. Nested functions, lambdas and floats are always enabled.
+var fileOptions = &syntax.FileOptions{Set: true}
+
+// Script - loaded Starlark script with registered handlers.
+type Script struct {
+	path       string
+	thread     *starlark.Thread
+	transforms []handler
+	processors []handler
+}
+
+// handler - script function registered for objects of matching kind. Empty fields match any value.
+type handler struct {
+	group, version, kind string
+	fn                   starlark.Callable
+}
+
+func (h handler) matches(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return (h.kind == "" || h.kind == gvk.Kind) && (h.group == "" || h.group == gvk.Group) &&
+		(h.version == "" || h.version == gvk.Version)
+}
+
+// Load - executes script and registers its handlers.
+func Load(path string) (*Script, error) {
+	s := &Script{path: path}
+	s.thread = &starlark.Thread{
+		Name: path,
+		Print: func(_ *starlark.Thread, msg string) {
+			logrus.WithField("Script", path).Info(msg)
+		},
+	}
+	predeclared := starlark.StringDict{
+		"transform": starlark.NewBuiltin("transform", s.registerTransform),
+		"processor": starlark.NewBuiltin("processor", s.registerProcessor),
+		"to_yaml":   starlark.NewBuiltin("to_yaml", toYaml),
+	}
+	_, err := starlark.ExecFileOptions(fileOptions, s.thread, path, nil, predeclared)
+	if err != nil {
+		return nil, scriptErr(err, path)
+	}
+	return s, nil
+}
+
+func (s *Script) registerTransform(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var h handler
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "fn", &h.fn, "kind?", &h.kind, "group?", &h.group, "version?", &h.version)
+	if err != nil {
+		return nil, err
+	}
+	s.transforms = append(s.transforms, h)
+	return starlark.None, nil
+}
+
+func (s *Script) registerProcessor(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var h handler
+	err := starlark.UnpackArgs(b.Name(), args, kwargs, "kind", &h.kind, "fn", &h.fn, "group?", &h.group, "version?", &h.version)
+	if err != nil {
+		return nil, err
+	}
+	if h.kind == "" {
+		return nil, fmt.Errorf("%s: kind is required", b.Name())
+	}
+	s.processors = append(s.processors, h)
+	return starlark.None, nil
+}
+
+// Transform - applies script transforms to the object. Returns nil if object is dropped by a transform.
+func (s *Script) Transform(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	for _, h := range s.transforms {
+		if !h.matches(obj) {
+			continue
+		}
+		in, err := toStarlark(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		out, err := starlark.Call(s.thread, h.fn, starlark.Tuple{in}, nil)
+		if err != nil {
+			return nil, scriptErr(err, s.path)
+		}
+		switch out {
+		case starlark.None:
+			out = in
+		case starlark.False:
+			return nil, nil
+		}
+		res, err := fromStarlark(out)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s transform result for %s %s", err, s.path, obj.GetKind(), obj.GetName())
+		}
+		object, ok := res.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s transform result for %s %s: dict expected", s.path, obj.GetKind(), obj.GetName())
+		}
+		obj = &unstructured.Unstructured{Object: object}
+	}
+	return obj, nil
+}
+
+// Processors - returns processors for kinds claimed by the script.
+func (s *Script) Processors() []helmify.Processor {
+	res := make([]helmify.Processor, 0, len(s.processors))
+	for _, h := range s.processors {
+		res = append(res, &scriptProcessor{script: s, handler: h})
+	}
+	return res
+}
+
+type scriptProcessor struct {
+	script  *Script
+	handler handler
+}
+
+// Name - returns script path.
+func (p *scriptProcessor) Name() string {
+	return "script:" + p.script.path
+}
+
+// Process - calls script processor function for objects of claimed kind.
+func (p *scriptProcessor) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if !p.handler.matches(obj) {
+		return false, nil, nil
+	}
+	in, err := toStarlark(obj.Object)
+	if err != nil {
+		return true, nil, err
+	}
+	values := helmify.Values{}
+	name := appMeta.TrimName(obj.GetName())
+	out, err := starlark.Call(p.script.thread, p.handler.fn, starlark.Tuple{in, newMeta(appMeta, obj, &values)}, nil)
+	if err != nil {
+		return true, nil, scriptErr(err, p.script.path)
+	}
+	res := &result{values: values, filename: name + ".yaml"}
+	switch val := out.(type) {
+	case starlark.NoneType:
+		return false, nil, nil
+	case starlark.String:
+		res.data = []byte(val)
+	case *starlark.Dict:
+		fields, err := fromStarlark(val)
+		if err != nil {
+			return true, nil, err
+		}
+		m := fields.(map[string]interface{})
+		if skip, _ := m["skip"].(bool); skip {
+			return true, nil, nil
+		}
+		template, _ := m["template"].(string)
+		res.data = []byte(template)
+		if filename, ok := m["filename"].(string); ok && filename != "" {
+			if filepath.IsAbs(filename) || strings.HasPrefix(filepath.Clean(filename), "..") {
+				return true, nil, fmt.Errorf("%s processor returned file %q for %s %s: must be relative to chart templates directory",
+					p.script.path, filename, obj.GetKind(), obj.GetName())
+			}
+			res.filename = filename
+		}
+	default:
+		return true, nil, fmt.Errorf("invalid %s processor result for %s %s: string, dict or None expected, got %s",
+			p.script.path, obj.GetKind(), obj.GetName(), out.Type())
+	}
+	return true, res, nil
+}
+
+// newMeta - returns app metadata helpers for the processed object.
+func newMeta(appMeta helmify.AppMetadata, obj *unstructured.Unstructured, values *helmify.Values) starlark.Value {
+	name := appMeta.TrimName(obj.GetName())
+	str := func(fnName string, fn func(string) string) *starlark.Builtin {
+		return starlark.NewBuiltin(fnName, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var arg string
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &arg); err != nil {
+				return nil, err
+			}
+			return starlark.String(fn(arg)), nil
+		})
+	}
+	return starlarkstruct.FromStringDict(starlark.String("meta"), starlark.StringDict{
+		"chart_name":       starlark.String(appMeta.ChartName()),
+		"namespace":        starlark.String(appMeta.Namespace()),
+		"name":             starlark.String(name),
+		"values_key":       starlark.String(strcase.ToLowerCamel(name)),
+		"templated_name":   str("templated_name", appMeta.TemplatedName),
+		"templated_string": str("templated_string", appMeta.TemplatedString),
+		"trim_name":        str("trim_name", appMeta.TrimName),
+		"object_meta": starlark.NewBuiltin("object_meta", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+				return nil, err
+			}
+			meta, err := processor.ProcessObjMeta(appMeta, obj.DeepCopy())
+			return starlark.String(meta), err
+		}),
+		"values": newValues(values),
+	})
+}
+
+// newValues - returns helmify.Values helpers adding values and returning their template references.
+func newValues(values *helmify.Values) starlark.Value {
+	names := func(args starlark.Tuple) ([]string, error) {
+		res := make([]string, 0, len(args))
+		for _, a := range args {
+			name, ok := starlark.AsString(a)
+			if !ok {
+				return nil, fmt.Errorf("value name must be a string, got %s", a.Type())
+			}
+			res = append(res, name)
+		}
+		return res, nil
+	}
+	return starlarkstruct.FromStringDict(starlark.String("values"), starlark.StringDict{
+		"add": starlark.NewBuiltin("add", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s: value and name are required", b.Name())
+			}
+			value, err := fromStarlark(args[0])
+			if err != nil {
+				return nil, err
+			}
+			name, err := names(args[1:])
+			if err != nil {
+				return nil, err
+			}
+			res, err := values.Add(value, name...)
+			return starlark.String(res), err
+		}),
+		"add_yaml": starlark.NewBuiltin("add_yaml", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+			if len(args) < 4 {
+				return nil, fmt.Errorf("%s: value, indent, new_line and name are required", b.Name())
+			}
+			value, err := fromStarlark(args[0])
+			if err != nil {
+				return nil, err
+			}
+			indent, err := starlark.AsInt32(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: invalid indent", err, b.Name())
+			}
+			name, err := names(args[3:])
+			if err != nil {
+				return nil, err
+			}
+			res, err := values.AddYaml(value, indent, bool(args[2].Truth()), name...)
+			return starlark.String(res), err
+		}),
+		"add_secret": starlark.NewBuiltin("add_secret", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s: to_base64 and name are required", b.Name())
+			}
+			name, err := names(args[1:])
+			if err != nil {
+				return nil, err
+			}
+			res, err := values.AddSecret(bool(args[0].Truth()), name...)
+			return starlark.String(res), err
+		}),
+	})
+}
+
+func toYaml(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var value starlark.Value
+	var indent int
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "value", &value, "indent?", &indent); err != nil {
+		return nil, err
+	}
+	v, err := fromStarlark(value)
+	if err != nil {
+		return nil, err
+	}
+	res, err := yamlformat.Marshal(v, indent)
+	return starlark.String(res), err
+}
+
+// scriptErr - adds Starlark backtrace to script execution error.
+func scriptErr(err error, path string) error {
+	if evalErr, ok := err.(*starlark.EvalError); ok {
+		return fmt.Errorf("%w: script %s failed: %s", err, path, evalErr.Backtrace())
+	}
+	return fmt.Errorf("%w: script %s failed", err, path)
+}
+
+type result struct {
+	data     []byte
+	values   helmify.Values
+	filename string
+}
+
+func (r *result) Filename() string {
+	return r.filename
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write(r.data)
+	return err
+}
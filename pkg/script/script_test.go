@@ -0,0 +1,166 @@
+package script
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/resolve"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	strWidget = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-app-widget
+  namespace: my-ns
+  labels:
+    drop: "false"
+spec:
+  size: 3
+  secretRef: my-app-credentials
+  parts: [a, b]`
+	strCredentials = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-credentials
+  namespace: my-ns`
+	strDropped = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-dropped
+  labels:
+    drop: "true"`
+
+	testScript = `
+def drop(obj):
+    if obj["metadata"].get("labels", {}).get("drop") == "true":
+        return False
+    obj["metadata"]["labels"]["transformed"] = "true"
+
+transform(drop)
+
+def widget(obj, meta):
+    if obj["spec"]["size"] == 0:
+        return None
+    spec = obj["spec"]
+    size = meta.values.add(spec["size"], meta.values_key, "size")
+    parts = meta.values.add_yaml(spec["parts"], 4, True, meta.values_key, "parts")
+    token = meta.values.add_secret(False, meta.values_key, "token")
+    return {
+        "template": "\n".join([
+            meta.object_meta(),
+            "spec:",
+            "  size: " + size,
+            "  secretRef: " + meta.templated_name(spec["secretRef"]),
+            "  parts: " + parts,
+            "  token: " + token,
+        ]),
+        "filename": "widgets/" + meta.trim_name("my-app-widget") + ".yaml",
+    }
+
+processor("Widget", widget, group="example.com")
+`
+)
+
+func loadScript(t *testing.T, content string) *Script {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	s, err := Load(path)
+	require.NoError(t, err)
+	return s
+}
+
+func TestScript(t *testing.T) {
+	s := loadScript(t, testScript)
+
+	dropped, err := s.Transform(internal.GenerateObj(strDropped))
+	require.NoError(t, err)
+	assert.Nil(t, dropped)
+
+	widget, err := s.Transform(internal.GenerateObj(strWidget))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"drop": "false", "transformed": "true"}, widget.GetLabels())
+
+	appMeta := metadata.New(config.Config{ChartName: "chart"})
+	appMeta.Load(widget)
+	appMeta.Load(internal.GenerateObj(strCredentials))
+	processors := s.Processors()
+	require.Len(t, processors, 1)
+
+	processed, _, err := processors[0].Process(appMeta, internal.GenerateObj(strCredentials))
+	require.NoError(t, err)
+	assert.False(t, processed)
+
+	processed, tmpl, err := processors[0].Process(appMeta, widget)
+	require.NoError(t, err)
+	require.True(t, processed)
+	assert.Equal(t, "widgets/widget.yaml", tmpl.Filename())
+	assert.Equal(t, helmify.Values{"widget": map[string]interface{}{
+		"size":  int64(3),
+		"parts": []interface{}{"a", "b"},
+		"token": "",
+	}}, tmpl.Values())
+	buf := bytes.Buffer{}
+	require.NoError(t, tmpl.Write(&buf))
+	assert.Equal(t, `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: {{ include "chart.fullname" . }}-widget
+  labels:
+    drop: "false"
+    transformed: "true"
+  {{- include "chart.labels" . | nindent 4 }}
+spec:
+  size: {{ .Values.widget.size }}
+  secretRef: {{ include "chart.fullname" . }}-credentials
+  parts: {{ .Values.widget.parts | toYaml | nindent 4 }}
+  token: {{ required "widget.token is required" .Values.widget.token | quote }}`, buf.String())
+
+	require.NoError(t, unstructured.SetNestedField(widget.Object, int64(0), "spec", "size"))
+	processed, _, err = processors[0].Process(appMeta, widget)
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"syntax":        "def broken(:\n",
+		"missing kind":  "def fn(obj, meta):\n    return None\n\nprocessor('', fn)\n",
+		"runtime error": "fail('boom')\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			_, err := Load(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad_Dialect(t *testing.T) {
+	loadScript(t, "kinds = set(['Widget'])\nratio = 1 / 2.0\ndef outer():\n    def inner(obj):\n        return obj\n    return inner\ntransform(outer())\n")
+	assert.False(t, resolve.AllowSet, "global resolver flags must stay untouched")
+}
+
+func TestProcess_InvalidFilename(t *testing.T) {
+	s := loadScript(t, "processor('Widget', lambda obj, meta: {'template': 'kind: Widget', 'filename': '../widget.yaml'})\n")
+	processed, _, err := s.Processors()[0].Process(metadata.New(config.Config{}), internal.GenerateObj(strWidget))
+	assert.True(t, processed)
+	assert.ErrorContains(t, err, "must be relative to chart templates directory")
+}
+
+func TestTransform_InvalidResult(t *testing.T) {
+	s := loadScript(t, "transform(lambda obj: 'not an object')\n")
+	_, err := s.Transform(internal.GenerateObj(strWidget))
+	assert.Error(t, err)
+}
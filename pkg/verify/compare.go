@@ -0,0 +1,242 @@
+package verify
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// comparator - collects differences between input and rendered object fields.
+type comparator struct {
+	kind     string
+	name     string
+	renamer  *strings.Replacer
+	markers  []string
+	findings []Finding
+}
+
+func (c *comparator) compare(path []string, expected, actual interface{}) {
+	p := pathString(path)
+	if c.ignored(path) {
+		return
+	}
+	switch exp := expected.(type) {
+	case map[string]interface{}:
+		act, ok := actual.(map[string]interface{})
+		if !ok {
+			if len(exp) != 0 {
+				c.add(Changed, p, expected, actual)
+			}
+			return
+		}
+		for k, v := range exp {
+			actVal, found := act[k]
+			if !found {
+				if !isEmpty(v) && !c.ignored(append(path, k)) {
+					c.add(Lost, join(p, k), v, nil)
+				}
+				continue
+			}
+			c.compare(append(path, k), v, actVal)
+		}
+		for k, v := range act {
+			if _, found := exp[k]; found || c.ignored(append(path, k)) || isHelmManaged(k, path) {
+				continue
+			}
+			if v = withoutHelmManaged(k, v); !isEmpty(v) {
+				c.add(Added, join(p, k), nil, v)
+			}
+		}
+	case []interface{}:
+		act, ok := actual.([]interface{})
+		if !ok {
+			if len(exp) != 0 {
+				c.add(Changed, p, expected, actual)
+			}
+			return
+		}
+		matched := map[int]bool{}
+		for i, v := range exp {
+			name, named := itemName(v)
+			if named {
+				// named items are matched by name, because conversion may add items like env variables
+				j := findNamed(act, name)
+				if j < 0 {
+					j = findNamed(act, c.renamer.Replace(name))
+				}
+				if j < 0 {
+					c.add(Lost, p+"["+name+"]", v, nil)
+					continue
+				}
+				matched[j] = true
+				c.compare(append(path[:len(path):len(path)], "["+name+"]"), v, act[j])
+				continue
+			}
+			if i >= len(act) {
+				c.add(Lost, p+"["+strconv.Itoa(i)+"]", v, nil)
+				continue
+			}
+			matched[i] = true
+			c.compare(append(path[:len(path):len(path)], "["+strconv.Itoa(i)+"]"), v, act[i])
+		}
+		for j, v := range act {
+			if matched[j] {
+				continue
+			}
+			item := strconv.Itoa(j)
+			if name, named := itemName(v); named {
+				item = name
+			}
+			c.add(Added, p+"["+item+"]", nil, v)
+		}
+	default:
+		if !c.scalarEqual(expected, actual) {
+			c.add(Changed, p, expected, actual)
+		}
+	}
+}
+
+func (c *comparator) scalarEqual(expected, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	if s, ok := expected.(string); ok && c.renamer.Replace(s) == actual {
+		// reference to an object which is renamed by the chart
+		return true
+	}
+	// rendered yaml may change numbers representation
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+// ignored - returns true if field is expected to be changed by the conversion.
+func (c *comparator) ignored(path []string) bool {
+	p := pathString(path)
+	return ignoredPaths[p] || ignoredKindPaths[c.kind][p] || c.isMarker(path)
+}
+
+// isMarker - returns true if path is an annotation or label consumed by the conversion.
+func (c *comparator) isMarker(path []string) bool {
+	if len(path) != 3 || path[0] != "metadata" || (path[1] != "annotations" && path[1] != "labels") {
+		return false
+	}
+	for _, m := range c.markers {
+		if strings.HasPrefix(path[2], m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparator) add(t FindingType, path string, expected, actual interface{}) {
+	c.findings = append(c.findings, Finding{Type: t, Kind: c.kind, Name: c.name, Path: path, Expected: expected, Actual: actual})
+}
+
+// pathString - joins field path with dots except for list items: spec.containers[web].image
+func pathString(path []string) string {
+	res := ""
+	for _, p := range path {
+		if strings.HasPrefix(p, "[") {
+			res += p
+			continue
+		}
+		res = join(res, p)
+	}
+	return res
+}
+
+// newRenamer - replaces input object names with rendered names. Longer names are replaced first.
+func newRenamer(renames []string) *strings.Replacer {
+	pairs := make([][2]string, 0, len(renames)/2)
+	for i := 0; i+1 < len(renames); i += 2 {
+		pairs = append(pairs, [2]string{renames[i], renames[i+1]})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if len(pairs[i][0]) != len(pairs[j][0]) {
+			return len(pairs[i][0]) > len(pairs[j][0])
+		}
+		return pairs[i][0] < pairs[j][0]
+	})
+	oldnew := make([]string, 0, len(renames))
+	for _, p := range pairs {
+		oldnew = append(oldnew, p[0], p[1])
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func itemName(item interface{}) (string, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := m["name"].(string)
+	return name, ok
+}
+
+// findNamed - returns index of list item with given name or -1.
+func findNamed(items []interface{}, name string) int {
+	for i, item := range items {
+		if n, ok := itemName(item); ok && n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// helmManagedLabels - labels added by chart helpers to every object.
+var helmManagedLabels = map[string]bool{
+	"helm.sh/chart":                true,
+	"app.kubernetes.io/managed-by": true,
+	"app.kubernetes.io/instance":   true,
+	"app.kubernetes.io/name":       true,
+	"app.kubernetes.io/version":    true,
+}
+
+// isHelmManaged - returns true if key is a label or annotation added by helm or chart helpers.
+func isHelmManaged(key string, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	switch path[len(path)-1] {
+	case "labels", "matchLabels", "selector":
+		return helmManagedLabels[key] || strings.HasPrefix(key, "helm.sh/")
+	case "annotations":
+		return strings.HasPrefix(key, "helm.sh/")
+	}
+	return false
+}
+
+// withoutHelmManaged - removes helm managed labels and annotations from added labels or annotations map.
+func withoutHelmManaged(key string, value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok || (key != "labels" && key != "matchLabels" && key != "annotations") {
+		return value
+	}
+	res := map[string]interface{}{}
+	for k, v := range m {
+		if !isHelmManaged(k, []string{key}) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func isEmpty(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(val) == 0
+	case []interface{}:
+		return len(val) == 0
+	}
+	return false
+}
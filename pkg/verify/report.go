@@ -0,0 +1,122 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report - verification results of the chart rendered with values of each environment.
+type Report []Result
+
+// Summary - number of input objects by verification status.
+type Summary struct {
+	Objects int `json:"objects"`
+	OK      int `json:"ok"`
+	Changed int `json:"changed"`
+	Lost    int `json:"lost"`
+}
+
+// Summary - counts input objects by verification status.
+func (r Result) Summary() Summary {
+	res := Summary{Objects: len(r.Objects)}
+	for _, o := range r.Objects {
+		switch o.Status {
+		case StatusOK:
+			res.OK++
+		case StatusChanged:
+			res.Changed++
+		case StatusLost:
+			res.Lost++
+		}
+	}
+	return res
+}
+
+// OK returns true if all results are OK.
+func (r Report) OK() bool {
+	for _, res := range r {
+		if !res.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+type jsonResult struct {
+	Result
+	OK      bool    `json:"ok"`
+	Summary Summary `json:"summary"`
+}
+
+type jsonReport struct {
+	OK      bool         `json:"ok"`
+	Results []jsonResult `json:"results"`
+}
+
+// WriteJSON - writes report in JSON format.
+func (r Report) WriteJSON(w io.Writer) error {
+	report := jsonReport{OK: r.OK(), Results: make([]jsonResult, 0, len(r))}
+	for _, res := range r {
+		report.Results = append(report.Results, jsonResult{Result: res, OK: res.OK(), Summary: res.Summary()})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// WriteText - writes human-readable report. Lost fields are prefixed with "-", added with "+" and changed with "~".
+func (r Report) WriteText(w io.Writer) error {
+	b := strings.Builder{}
+	for i, res := range r {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Chart %s (release %s, namespace %s", res.Chart, res.Release, res.Namespace)
+		if res.ValuesFile != "" {
+			fmt.Fprintf(&b, ", values %s", res.ValuesFile)
+		}
+		b.WriteString(")\n")
+		for _, msg := range res.LintErrors {
+			fmt.Fprintf(&b, "  lint error: %s\n", msg)
+		}
+		for _, msg := range res.LintWarnings {
+			fmt.Fprintf(&b, "  lint warning: %s\n", msg)
+		}
+		for _, o := range res.Objects {
+			fmt.Fprintf(&b, "  %s %s: %s\n", o.Kind, o.Name, o.Status)
+			for _, f := range o.Findings {
+				if f.Path == "" {
+					continue
+				}
+				switch f.Type {
+				case Lost:
+					fmt.Fprintf(&b, "    - %s: %s\n", f.Path, inline(f.Expected))
+				case Added:
+					fmt.Fprintf(&b, "    + %s: %s\n", f.Path, inline(f.Actual))
+				default:
+					fmt.Fprintf(&b, "    ~ %s: %s -> %s\n", f.Path, inline(f.Expected), inline(f.Actual))
+				}
+			}
+		}
+		s := res.Summary()
+		fmt.Fprintf(&b, "  %d objects: %d ok, %d changed, %d lost\n", s.Objects, s.OK, s.Changed, s.Lost)
+	}
+	if r.OK() {
+		b.WriteString("OK\n")
+	} else {
+		b.WriteString("FAILED\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// inline - formats value as a single line JSON.
+func inline(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
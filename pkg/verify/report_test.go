@@ -0,0 +1,68 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testReport = Report{{
+	Chart:     "chart",
+	Release:   "my-app",
+	Namespace: "my-ns",
+	Objects: []ObjectResult{
+		{Kind: "Service", Name: "my-app-svc", Rendered: "my-app-svc", Status: StatusOK, Findings: []Finding{
+			{Type: Added, Path: "spec.type", Actual: "ClusterIP"},
+		}},
+		{Kind: "Deployment", Name: "my-app-web", Rendered: "my-app-web", Status: StatusChanged, Findings: []Finding{
+			{Type: Lost, Path: "spec.template.spec.hostNetwork", Expected: true},
+			{Type: Changed, Path: "spec.replicas", Expected: int64(3), Actual: int64(1)},
+		}},
+		{Kind: "ConfigMap", Name: "my-app-config", Status: StatusLost, Findings: []Finding{{Type: Lost}}},
+	},
+}}
+
+func TestReport_WriteText(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.NoError(t, testReport.WriteText(&buf))
+	assert.Equal(t, `Chart chart (release my-app, namespace my-ns)
+  Service my-app-svc: ok
+    + spec.type: "ClusterIP"
+  Deployment my-app-web: changed
+    - spec.template.spec.hostNetwork: true
+    ~ spec.replicas: 3 -> 1
+  ConfigMap my-app-config: lost
+  3 objects: 1 ok, 1 changed, 1 lost
+FAILED
+`, buf.String())
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.NoError(t, testReport.WriteJSON(&buf))
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, false, res["ok"])
+	results := res["results"].([]interface{})
+	require.Len(t, results, 1)
+	result := results[0].(map[string]interface{})
+	assert.Equal(t, "my-app", result["release"])
+	assert.Equal(t, map[string]interface{}{"objects": 3.0, "ok": 1.0, "changed": 1.0, "lost": 1.0}, result["summary"])
+	deployment := result["objects"].([]interface{})[1].(map[string]interface{})
+	assert.Equal(t, "changed", deployment["status"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "lost", "path": "spec.template.spec.hostNetwork", "expected": true},
+		map[string]interface{}{"type": "changed", "path": "spec.replicas", "expected": 3.0, "actual": 1.0},
+	}, deployment["findings"])
+}
+
+func TestReport_OK(t *testing.T) {
+	assert.False(t, testReport.OK())
+	ok := Report{{Objects: []ObjectResult{{Status: StatusOK, Findings: []Finding{{Type: Added, Path: "spec.type"}}}}}}
+	assert.True(t, ok.OK())
+	ok[0].LintErrors = []string{"error"}
+	assert.False(t, ok.OK())
+}
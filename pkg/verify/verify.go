@@ -0,0 +1,390 @@
+// Package verify checks generated chart with Helm SDK: lints it, renders it and compares rendered objects
+// with k8s objects the chart was generated from.
+package verify
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/schema"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/lint/support"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// FindingType - kind of difference between input and rendered object.
+type FindingType string
+
+const (
+	// Lost - field or object from input is missing in rendered chart.
+	Lost FindingType = "lost"
+	// Changed - field value from input differs from rendered chart.
+	Changed FindingType = "changed"
+	// Added - field is added by the chart. Informational, does not fail verification.
+	Added FindingType = "added"
+)
+
+// Finding - difference between input object and object rendered from the chart.
+type Finding struct {
+	Type FindingType `json:"type"`
+	Kind string      `json:"-"`
+	Name string      `json:"-"`
+	// Path - dot separated field path. Empty if the whole object is lost.
+	Path     string      `json:"path,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+func (f Finding) String() string {
+	if f.Path == "" {
+		return fmt.Sprintf("%s %s: object %s", f.Kind, f.Name, f.Type)
+	}
+	switch f.Type {
+	case Lost:
+		return fmt.Sprintf("%s %s: field %s lost", f.Kind, f.Name, f.Path)
+	case Added:
+		return fmt.Sprintf("%s %s: field %s added", f.Kind, f.Name, f.Path)
+	}
+	return fmt.Sprintf("%s %s: field %s changed from %v to %v", f.Kind, f.Name, f.Path, f.Expected, f.Actual)
+}
+
+// ObjectStatus - input object verification status.
+type ObjectStatus string
+
+const (
+	// StatusOK - rendered object reproduces input object.
+	StatusOK ObjectStatus = "ok"
+	// StatusChanged - some input object fields are lost or changed.
+	StatusChanged ObjectStatus = "changed"
+	// StatusLost - input object is not rendered.
+	StatusLost ObjectStatus = "lost"
+)
+
+// ObjectResult - differences between input object and matching rendered object.
+type ObjectResult struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Rendered - name of the matching rendered object.
+	Rendered string       `json:"rendered,omitempty"`
+	Status   ObjectStatus `json:"status"`
+	Findings []Finding    `json:"findings,omitempty"`
+}
+
+// Result - chart verification result.
+type Result struct {
+	Chart     string `json:"chart"`
+	Release   string `json:"release"`
+	Namespace string `json:"namespace"`
+	// ValuesFile - values file used to render the chart in addition to values.yaml.
+	ValuesFile string `json:"valuesFile,omitempty"`
+	// LintErrors - errors reported by helm lint.
+	LintErrors []string `json:"lintErrors,omitempty"`
+	// LintWarnings - warnings reported by helm lint.
+	LintWarnings []string `json:"lintWarnings,omitempty"`
+	// Objects - input objects compared with objects rendered from the chart.
+	Objects []ObjectResult `json:"objects"`
+}
+
+// Findings - returns differences of all objects.
+func (r Result) Findings() []Finding {
+	var res []Finding
+	for _, o := range r.Objects {
+		res = append(res, o.Findings...)
+	}
+	return res
+}
+
+// OK returns true if chart has no lint errors and rendered objects reproduce input objects.
+func (r Result) OK() bool {
+	if len(r.LintErrors) != 0 {
+		return false
+	}
+	for _, o := range r.Objects {
+		if o.Status != StatusOK {
+			return false
+		}
+	}
+	return true
+}
+
+// Options - chart verification parameters.
+type Options struct {
+	// ChartPath - path to generated chart dir.
+	ChartPath string
+	// Release - release name used for lint and rendering. Defaults to chart name.
+	Release string
+	// Namespace - release namespace used for lint and rendering.
+	Namespace string
+	// ValuesFile - optional values file name to read from chart dir.
+	ValuesFile string
+	// Values - optional values overriding chart values.yaml and ValuesFile.
+	Values map[string]interface{}
+	// TrimName - matches input object names with names templated by the chart. See helmify.AppMetadata.
+	TrimName func(name string) string
+	// IgnoreMarkers - annotations and labels consumed by the conversion. Input keys with these prefixes are not compared.
+	IgnoreMarkers []string
+}
+
+// ignoredPaths - fields which are expected to be changed by the conversion.
+var ignoredPaths = map[string]bool{
+	"metadata.name":              true,
+	"metadata.namespace":         true,
+	"metadata.creationTimestamp": true,
+	"metadata.resourceVersion":   true,
+	"metadata.uid":               true,
+	"metadata.generation":        true,
+	"metadata.managedFields":     true,
+	"status":                     true,
+}
+
+// ignoredKinds - objects which are not included into chart on purpose.
+var ignoredKinds = map[string]bool{
+	// release namespace is created by helm
+	"Namespace": true,
+}
+
+// ignoredKindPaths - fields replaced with required values by the conversion.
+var ignoredKindPaths = map[string]map[string]bool{
+	"Secret": {"data": true, "stringData": true},
+}
+
+// Chart - lints chart, renders it and compares rendered objects with given input objects.
+// Returns error only if chart cannot be loaded or rendered.
+func Chart(opts Options, objects []*unstructured.Unstructured) (Result, error) {
+	chrt, err := loader.Load(opts.ChartPath)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: unable to load chart %s", err, opts.ChartPath)
+	}
+	if opts.Release == "" {
+		opts.Release = chrt.Name()
+	}
+	values := map[string]interface{}{}
+	if opts.ValuesFile != "" {
+		values, err = chartutil.ReadValuesFile(filepath.Join(opts.ChartPath, opts.ValuesFile))
+		if err != nil {
+			return Result{}, fmt.Errorf("%w: unable to read %s", err, opts.ValuesFile)
+		}
+	}
+	values = chartutil.CoalesceTables(opts.Values, values)
+	values = chartutil.CoalesceTables(values, requiredPlaceholders(chrt))
+	res := Result{Chart: opts.ChartPath, Release: opts.Release, Namespace: opts.Namespace, ValuesFile: opts.ValuesFile}
+
+	lint := action.NewLint()
+	lint.Strict = true
+	lint.Namespace = opts.Namespace
+	var lintRes *action.LintResult
+	discardLog(func() { lintRes = lint.Run([]string{opts.ChartPath}, values) })
+	for _, msg := range lintRes.Messages {
+		switch msg.Severity {
+		case support.ErrorSev:
+			res.LintErrors = append(res.LintErrors, msg.Error())
+		case support.WarningSev:
+			res.LintWarnings = append(res.LintWarnings, msg.Error())
+		}
+	}
+
+	rendered, err := render(chrt, opts, values, objects)
+	if err != nil {
+		return res, err
+	}
+	prefix := fullname(opts.Release, chrt.Name(), chrt.Values, values)
+	trimName := opts.TrimName
+	if trimName == nil {
+		trimName = func(name string) string { return name }
+	}
+	renderedKey := func(obj *unstructured.Unstructured) string {
+		return obj.GetKind() + "/" + trimName(strings.TrimPrefix(obj.GetName(), prefix+"-"))
+	}
+	byKey := map[string]*unstructured.Unstructured{}
+	for _, obj := range rendered {
+		byKey[renderedKey(obj)] = obj
+	}
+	matched := map[*unstructured.Unstructured]*unstructured.Unstructured{}
+	used := map[*unstructured.Unstructured]bool{}
+	for _, obj := range objects {
+		if actual, ok := byKey[obj.GetKind()+"/"+trimName(obj.GetName())]; ok && !used[actual] {
+			matched[obj] = actual
+			used[actual] = true
+		}
+	}
+	// objects named by chart helpers, like service account, are matched if they are the only ones of their kind
+	for _, obj := range objects {
+		if _, ok := matched[obj]; ok {
+			continue
+		}
+		if actual := onlyUnmatched(obj.GetKind(), objects, matched, rendered, used); actual != nil {
+			matched[obj] = actual
+			used[actual] = true
+		}
+	}
+	// input object names referenced by other objects are templated by the chart
+	var renames []string
+	for obj, actual := range matched {
+		if obj.GetName() != actual.GetName() {
+			renames = append(renames, obj.GetName(), actual.GetName())
+		}
+	}
+	c := comparator{renamer: newRenamer(renames), markers: opts.IgnoreMarkers}
+	for _, obj := range objects {
+		if ignoredKinds[obj.GetKind()] {
+			continue
+		}
+		objRes := ObjectResult{Kind: obj.GetKind(), Name: obj.GetName(), Status: StatusOK}
+		actual, ok := matched[obj]
+		if !ok {
+			objRes.Status = StatusLost
+			objRes.Findings = []Finding{{Type: Lost, Kind: obj.GetKind(), Name: obj.GetName()}}
+			res.Objects = append(res.Objects, objRes)
+			continue
+		}
+		objRes.Rendered = actual.GetName()
+		c.kind, c.name, c.findings = obj.GetKind(), obj.GetName(), nil
+		c.compare(nil, obj.Object, actual.Object)
+		sort.SliceStable(c.findings, func(i, j int) bool { return c.findings[i].Path < c.findings[j].Path })
+		objRes.Findings = c.findings
+		for _, f := range c.findings {
+			if f.Type != Added {
+				objRes.Status = StatusChanged
+			}
+		}
+		res.Objects = append(res.Objects, objRes)
+	}
+	return res, nil
+}
+
+// fullname - returns chart fullname helper result prefixing templated object names.
+func fullname(release, chartName string, chartValues, values map[string]interface{}) string {
+	for _, v := range []map[string]interface{}{values, chartValues} {
+		if override, ok := v["fullnameOverride"].(string); ok && override != "" {
+			return override
+		}
+	}
+	if strings.Contains(release, chartName) {
+		return release
+	}
+	return release + "-" + chartName
+}
+
+// onlyUnmatched - returns rendered object if it is the only unmatched object of the kind both in input and rendered objects.
+func onlyUnmatched(kind string, objects []*unstructured.Unstructured, matched map[*unstructured.Unstructured]*unstructured.Unstructured,
+	rendered []*unstructured.Unstructured, used map[*unstructured.Unstructured]bool) *unstructured.Unstructured {
+	inputs := 0
+	for _, obj := range objects {
+		if _, ok := matched[obj]; !ok && obj.GetKind() == kind {
+			inputs++
+		}
+	}
+	var res *unstructured.Unstructured
+	for _, obj := range rendered {
+		if used[obj] || obj.GetKind() != kind {
+			continue
+		}
+		if res != nil {
+			return nil
+		}
+		res = obj
+	}
+	if inputs != 1 {
+		return nil
+	}
+	return res
+}
+
+// requiredPlaceholder - value of required chart values missing in values.yaml, e.g. secret data.
+const requiredPlaceholder = "helmify-verify"
+
+// requiredPlaceholders - returns placeholder values of required values. Generated values.yaml contains empty
+// required values which don't pass values schema. Fields with required values are not compared.
+func requiredPlaceholders(chrt *chart.Chart) map[string]interface{} {
+	templates := make([]string, 0, len(chrt.Templates))
+	for _, t := range chrt.Templates {
+		templates = append(templates, string(t.Data))
+	}
+	res := map[string]interface{}{}
+	for _, path := range schema.RequiredValues(templates...) {
+		_ = unstructured.SetNestedField(res, requiredPlaceholder, path...)
+	}
+	return res
+}
+
+// render - renders chart templates and CRDs. APIs of input objects are available in rendering capabilities,
+// so templates guarded by .Capabilities.APIVersions.Has checks are rendered.
+func render(chrt *chart.Chart, opts Options, vals map[string]interface{}, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	caps := chartutil.DefaultCapabilities.Copy()
+	for _, obj := range objects {
+		gv := obj.GroupVersionKind().GroupVersion().String()
+		caps.APIVersions = append(caps.APIVersions, gv, gv+"/"+obj.GetKind())
+	}
+	values, err := chartutil.ToRenderValues(chrt, vals, chartutil.ReleaseOptions{
+		Name:      opts.Release,
+		Namespace: opts.Namespace,
+		IsInstall: true,
+	}, caps)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to prepare chart values", err)
+	}
+	// secrets are generated with required values, so values.yaml alone cannot be rendered without lint mode.
+	var files map[string]string
+	discardLog(func() { files, err = engine.Engine{LintMode: true}.Render(chrt, values) })
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to render chart %s", err, opts.ChartPath)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var manifests []string
+	for _, name := range names {
+		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+			manifests = append(manifests, files[name])
+		}
+	}
+	for _, crd := range chrt.CRDObjects() {
+		manifests = append(manifests, string(crd.File.Data))
+	}
+	var res []*unstructured.Unstructured
+	for _, manifest := range manifests {
+		split := releaseutil.SplitManifests(manifest)
+		keys := make([]string, 0, len(split))
+		for k := range split {
+			keys = append(keys, k)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+		for _, k := range keys {
+			data, err := yaml.YAMLToJSON([]byte(split[k]))
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to decode rendered manifest:\n%s", err, split[k])
+			}
+			if string(data) == "null" {
+				continue
+			}
+			obj := &unstructured.Unstructured{}
+			// unstructured decoding keeps integers as int64 like input objects
+			err = obj.UnmarshalJSON(data)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to decode rendered manifest:\n%s", err, split[k])
+			}
+			res = append(res, obj)
+		}
+	}
+	return res, nil
+}
+
+// discardLog - helm lint mode logs every missing required value with standard logger.
+func discardLog(fn func()) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+	fn()
+}
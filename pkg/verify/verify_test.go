@@ -0,0 +1,154 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const chartYaml = `apiVersion: v2
+name: chart
+description: test chart
+type: application
+version: 0.1.0
+appVersion: "0.1.0"
+icon: https://example.com/icon.png
+`
+
+const deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}-web
+spec:
+  replicas: {{ .Values.web.replicas }}
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      serviceAccountName: {{ .Release.Name }}-sa
+      containers:
+      - name: web
+        image: nginx:1.25
+        env:
+        - name: ADDED
+          value: a
+        - name: MODE
+          value: prod
+`
+
+const deploymentYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+  namespace: my-ns
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      serviceAccountName: my-app-sa
+      hostNetwork: true
+      containers:
+      - name: web
+        image: nginx:1.25
+        env:
+        - name: MODE
+          value: dev`
+
+const serviceAccountYaml = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: my-app-sa
+  namespace: my-ns`
+
+func writeChart(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "chart")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chartYaml), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte("web:\n  replicas: 3\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "deployment.yaml"), []byte(deploymentTemplate), 0o600))
+	return dir
+}
+
+func trimName(name string) string {
+	if len(name) > len("my-app-") && name[:len("my-app-")] == "my-app-" {
+		return name[len("my-app-"):]
+	}
+	return name
+}
+
+func TestChart(t *testing.T) {
+	dir := writeChart(t)
+	res, err := Chart(Options{ChartPath: dir, Namespace: "my-ns", TrimName: trimName}, []*unstructured.Unstructured{
+		internal.GenerateObj(deploymentYaml),
+		internal.GenerateObj(serviceAccountYaml),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.LintErrors)
+	assert.Equal(t, []Finding{
+		{Type: Added, Kind: "Deployment", Name: "my-app-web", Path: "spec.template.spec.containers[web].env[ADDED]", Actual: map[string]interface{}{"name": "ADDED", "value": "a"}},
+		{Type: Changed, Kind: "Deployment", Name: "my-app-web", Path: "spec.template.spec.containers[web].env[MODE].value", Expected: "dev", Actual: "prod"},
+		{Type: Lost, Kind: "Deployment", Name: "my-app-web", Path: "spec.template.spec.hostNetwork", Expected: true},
+		{Type: Changed, Kind: "Deployment", Name: "my-app-web", Path: "spec.template.spec.serviceAccountName", Expected: "my-app-sa", Actual: "chart-sa"},
+		{Type: Lost, Kind: "ServiceAccount", Name: "my-app-sa"},
+	}, res.Findings())
+	assert.False(t, res.OK())
+	assert.Equal(t, Summary{Objects: 2, Changed: 1, Lost: 1}, res.Summary())
+	assert.Equal(t, "chart-web", res.Objects[0].Rendered)
+
+	t.Run("values override", func(t *testing.T) {
+		res, err = Chart(Options{ChartPath: dir, Namespace: "my-ns", TrimName: trimName, Values: map[string]interface{}{
+			"web": map[string]interface{}{"replicas": 1},
+		}}, []*unstructured.Unstructured{internal.GenerateObj(deploymentYaml)})
+		require.NoError(t, err)
+		assert.Contains(t, res.Findings(), Finding{Type: Changed, Kind: "Deployment", Name: "my-app-web", Path: "spec.replicas", Expected: int64(3), Actual: int64(1)})
+	})
+}
+
+func TestChart_release(t *testing.T) {
+	dir := writeChart(t)
+	res, err := Chart(Options{ChartPath: dir, Release: "my-app", Namespace: "my-ns", TrimName: trimName}, []*unstructured.Unstructured{
+		internal.GenerateObj(deploymentYaml),
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Objects, 1)
+	assert.Equal(t, "my-app-web", res.Objects[0].Rendered)
+	assert.Equal(t, "my-app", res.Release)
+}
+
+func TestComparator(t *testing.T) {
+	c := comparator{kind: "Deployment", name: "web", renamer: newRenamer([]string{"my-app-cert", "chart-cert"}), markers: []string{"helmify.io/hook"}}
+	c.compare(nil, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "web",
+			"namespace":   "my-ns",
+			"annotations": map[string]interface{}{"helmify.io/hook": "true", "cert": "ns/my-app-cert"},
+		},
+		"spec": map[string]interface{}{"empty": map[string]interface{}{}, "port": int64(80), "args": []interface{}{"a", "b"}},
+	}, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "chart-web",
+			"annotations": map[string]interface{}{"cert": "ns/chart-cert", "helm.sh/hook": "pre-install"},
+			"labels":      map[string]interface{}{"helm.sh/chart": "chart-0.1.0", "app.kubernetes.io/name": "chart"},
+		},
+		"spec": map[string]interface{}{"port": float64(80), "args": []interface{}{"a"}, "resources": map[string]interface{}{}, "type": "ClusterIP"},
+	})
+	assert.ElementsMatch(t, []Finding{
+		{Type: Lost, Kind: "Deployment", Name: "web", Path: "spec.args[1]", Expected: "b"},
+		{Type: Added, Kind: "Deployment", Name: "web", Path: "spec.type", Actual: "ClusterIP"},
+	}, c.findings)
+}